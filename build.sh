@@ -13,18 +13,16 @@ rm -f hair_style_service.zip
 mkdir -p build
 
 # 编译
+# 云函数以自定义运行时部署，入口为 cmd/scf；cmd/server 用于本地或自建服务器运行
 echo "编译中..."
-go build -o build/hair_style_service api/handler.go
+go build -o build/hair_style_service ./cmd/scf
 
 # 复制配置文件
 cp -r config build/
 cp template.yaml build/
 
-# 创建启动脚本
-cat > build/scf_bootstrap << 'EOF'
-#!/bin/bash
-./hair_style_service
-EOF
+# 复制启动脚本
+cp scf_bootstrap build/
 
 # 设置执行权限
 chmod +x build/scf_bootstrap
@@ -36,4 +34,4 @@ cd build
 zip -r ../hair_style_service.zip ./*
 cd ..
 
-echo "打包完成：hair_style_service.zip" 
\ No newline at end of file
+echo "打包完成：hair_style_service.zip"
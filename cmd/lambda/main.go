@@ -0,0 +1,28 @@
+// AWS Lambda 入口，处理 API Gateway v2（HTTP API）事件
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/adapter/lambda"
+	"github.com/MRsummer/ChangeHairStyle/pkg/app"
+	"github.com/MRsummer/ChangeHairStyle/pkg/logger"
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+)
+
+func main() {
+	// 初始化日志系统
+	logger.Init()
+
+	svc, err := app.Load()
+	if err != nil {
+		logger.Fatalf("初始化失败: %v", err)
+	}
+	defer svc.Close()
+
+	router := app.NewRouter(svc)
+	awslambda.Start(func(ctx context.Context, event json.RawMessage) (json.RawMessage, error) {
+		return lambda.Handle(ctx, router, event)
+	})
+}
@@ -0,0 +1,33 @@
+// 腾讯云云函数事件函数入口，以自定义运行时处理 API 网关触发的事件
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/adapter/scf"
+	"github.com/MRsummer/ChangeHairStyle/pkg/app"
+	"github.com/MRsummer/ChangeHairStyle/pkg/logger"
+)
+
+func main() {
+	// 初始化日志系统
+	logger.Init()
+
+	runtime, err := scf.NewRuntime()
+	if err != nil {
+		logger.Fatalf("初始化失败: %v", err)
+	}
+
+	svc, err := app.Load()
+	if err != nil {
+		logger.Fatalf("初始化失败: %v", err)
+	}
+
+	err = runtime.Serve(context.Background(), app.NewRouter(svc))
+	svc.Close()
+	if err != nil {
+		logger.WithError(err).Error("运行时退出")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,34 @@
+// 独立 HTTP 服务入口，用于本地或自建服务器运行；云函数部署使用 cmd/scf
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/app"
+	"github.com/MRsummer/ChangeHairStyle/pkg/logger"
+	"github.com/MRsummer/ChangeHairStyle/pkg/server"
+)
+
+func main() {
+	// 初始化日志系统
+	logger.Init()
+
+	svc, err := app.Load()
+	if err != nil {
+		logger.Fatalf("初始化失败: %v", err)
+	}
+
+	srv := server.New(config.GlobalConfig.Server, app.NewRouter(svc))
+
+	// 请求处理完成后再关闭数据库连接
+	srv.OnShutdown(func(ctx context.Context) error {
+		return svc.Close()
+	})
+
+	if err := srv.Run(context.Background()); err != nil {
+		logger.WithError(err).Error("服务器退出")
+		os.Exit(1)
+	}
+}
@@ -1,19 +1,50 @@
 package config
 
 import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // 云函数镜像可能没有时区数据库，内嵌一份
+
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	JWT      JWTConfig     `mapstructure:"jwt"`
-	Wechat   WechatConfig  `mapstructure:"wechat"`
+	App        AppConfig        `mapstructure:"app"`
+	Server     ServerConfig     `mapstructure:"server"`
+	Database   DatabaseConfig   `mapstructure:"database"`
+	JWT        JWTConfig        `mapstructure:"jwt"`
+	Wechat     WechatConfig     `mapstructure:"wechat"`
+	SignIn     SignInConfig     `mapstructure:"sign_in"`
+	Invite     InviteConfig     `mapstructure:"invite"`
+	Pricing    PricingConfig    `mapstructure:"pricing"`
+	RateLimit  RateLimitConfig  `mapstructure:"rate_limit"`
+	Generation GenerationConfig `mapstructure:"generation"`
+}
+
+// AppConfig 业务通用配置
+type AppConfig struct {
+	// Timezone 业务时区，签到、每日额度等按该时区的自然日计算
+	Timezone string `mapstructure:"timezone"`
 }
 
 type ServerConfig struct {
 	Port string `mapstructure:"port"`
 	Mode string `mapstructure:"mode"`
+	// ReadTimeout 读取整个请求（含请求体）的超时时间
+	ReadTimeout time.Duration `mapstructure:"read_timeout"`
+	// WriteTimeout 写响应的超时时间，需大于一次发型生成的耗时
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	// IdleTimeout keep-alive 连接的空闲超时时间
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+	// ShutdownTimeout 收到退出信号后等待进行中请求完成的最长时间
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// TrustedProxies 可信的反向代理网段，只有来自这些地址的 X-Forwarded-For 才会被采信，为空时使用连接的对端地址
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// TrustedPlatform 部署平台写入真实客户端IP的请求头，例如 Vercel 的 X-Real-Ip，为空时不使用
+	TrustedPlatform string `mapstructure:"trusted_platform"`
+	// ValidateRequests 按 /openapi.json 校验请求，不符合文档的请求返回参数错误，用于测试环境
+	ValidateRequests bool `mapstructure:"validate_requests"`
 }
 
 type DatabaseConfig struct {
@@ -26,8 +57,10 @@ type DatabaseConfig struct {
 	Charset  string `mapstructure:"charset"`
 }
 
+// JWTConfig 登录凭证配置，Secret 为空时不签发凭证
 type JWTConfig struct {
 	Secret string `mapstructure:"secret"`
+	// Expire 凭证有效期，例如 720h
 	Expire string `mapstructure:"expire"`
 }
 
@@ -36,20 +69,255 @@ type WechatConfig struct {
 	Secret string `mapstructure:"secret"`
 }
 
+// SignInConfig 签到奖励配置
+type SignInConfig struct {
+	// Rewards 连续签到第N天的奖励，连续天数超过配置长度后按周期循环
+	Rewards []int `mapstructure:"rewards"`
+	// RepairWindowDays 可补签的最早天数
+	RepairWindowDays int `mapstructure:"repair_window_days"`
+	// RepairCardInterval 每连续签到N天奖励一张补签卡，0表示不奖励
+	RepairCardInterval int `mapstructure:"repair_card_interval"`
+	// MaxRepairCards 补签卡持有上限
+	MaxRepairCards int `mapstructure:"max_repair_cards"`
+	// RewardExpireDays 签到奖励的有效天数，0表示永不过期
+	RewardExpireDays int `mapstructure:"reward_expire_days"`
+}
+
+// InviteConfig 邀请奖励配置，奖励在被邀请人完成首次生成后发放
+type InviteConfig struct {
+	// InviteeReward 被邀请人获得的奖励
+	InviteeReward int `mapstructure:"invitee_reward"`
+	// LevelRewards 各级邀请人获得的奖励，第一个为直接邀请人，第二个为邀请人的邀请人，依此类推
+	LevelRewards []int `mapstructure:"level_rewards"`
+	// MaxRewardsPerDay 每个邀请人每天最多获得奖励的次数，0表示不限制
+	MaxRewardsPerDay int `mapstructure:"max_rewards_per_day"`
+	// MaxRewardsTotal 每个邀请人累计最多获得奖励的次数，0表示不限制
+	MaxRewardsTotal int `mapstructure:"max_rewards_total"`
+	// MaxInviteesPerIP 24小时内同一IP最多可绑定的被邀请人数，超过后不发放奖励，0表示不限制
+	MaxInviteesPerIP int `mapstructure:"max_invitees_per_ip"`
+	// RewardExpireDays 邀请奖励的有效天数，0表示永不过期
+	RewardExpireDays int `mapstructure:"reward_expire_days"`
+}
+
+// 会员等级
+const (
+	TierVIP  = "vip"
+	TierSVIP = "svip"
+)
+
+// PricingConfig 生成定价配置
+type PricingConfig struct {
+	// GenerationPrice 非会员每次生成消耗的coin
+	GenerationPrice int `mapstructure:"generation_price"`
+	// VIP 普通会员权益
+	VIP TierConfig `mapstructure:"vip"`
+	// SVIP 高级会员权益
+	SVIP TierConfig `mapstructure:"svip"`
+}
+
+// TierConfig 会员等级权益
+type TierConfig struct {
+	// DailyFreeQuota 每天免费生成次数
+	DailyFreeQuota int `mapstructure:"daily_free_quota"`
+	// DiscountPercent 免费次数用完后按原价的百分比扣除coin，100表示不打折
+	DiscountPercent int `mapstructure:"discount_percent"`
+	// Priority 生成排队时是否优先处理
+	Priority bool `mapstructure:"priority"`
+}
+
+// Tier 返回会员等级对应的权益，未知等级返回 false
+func (c PricingConfig) Tier(name string) (TierConfig, bool) {
+	switch name {
+	case TierVIP:
+		return c.VIP, true
+	case TierSVIP:
+		return c.SVIP, true
+	}
+	return TierConfig{}, false
+}
+
+// TierRank 返回会员等级的高低，等级越高值越大，非会员和未知等级为0
+func TierRank(name string) int {
+	switch name {
+	case TierVIP:
+		return 1
+	case TierSVIP:
+		return 2
+	}
+	return 0
+}
+
+// 限流状态的存储位置
+const (
+	RateLimitStoreMemory = "memory" // 进程内存，只对单个实例生效
+	RateLimitStoreMySQL  = "mysql"  // MySQL，多个实例共享
+)
+
+// RateLimitConfig 接口限流配置，每个接口分别按用户和按IP限流
+type RateLimitConfig struct {
+	// Enabled 是否开启限流
+	Enabled bool `mapstructure:"enabled"`
+	// Store 令牌桶的存储位置：memory 或 mysql
+	Store string `mapstructure:"store"`
+	// Generation 发型生成接口
+	Generation RouteLimit `mapstructure:"generation"`
+	// Login 微信登录接口
+	Login RouteLimit `mapstructure:"login"`
+	// Redeem 兑换码接口，限制暴力猜测兑换码
+	Redeem RouteLimit `mapstructure:"redeem"`
+	// Write 分享、点赞、举报、下单等写操作接口
+	Write RouteLimit `mapstructure:"write"`
+}
+
+// RouteLimit 一组接口的限流规则
+type RouteLimit struct {
+	PerUser BucketLimit `mapstructure:"per_user"`
+	PerIP   BucketLimit `mapstructure:"per_ip"`
+}
+
+// BucketLimit 令牌桶参数
+type BucketLimit struct {
+	// PerMinute 每分钟补充的令牌数，0表示不限制
+	PerMinute int `mapstructure:"per_minute"`
+	// Burst 桶容量，即允许连续发出的请求数
+	Burst int `mapstructure:"burst"`
+}
+
+// GenerationConfig 调用上游生成接口的全局并发控制，所有实例共用 MySQL 中的名额和队列
+type GenerationConfig struct {
+	// MaxConcurrency 所有实例同时调用上游生成接口的最大数量，需按火山引擎账号的 QPS 配置
+	MaxConcurrency int `mapstructure:"max_concurrency"`
+	// LeaseTTL 名额的租约时长，生成期间每隔三分之一租约时长续期一次，实例异常退出未释放的名额在租约过期后回收
+	LeaseTTL time.Duration `mapstructure:"lease_ttl"`
+	// MaxWait 一次请求最多排队等待的时间，超过后返回排队位置，需小于 server.write_timeout 减去生成耗时
+	MaxWait time.Duration `mapstructure:"max_wait"`
+	// QueueTTL 排队票据的保留时间，客户端在此时间内带票据重试可保留排队位置
+	QueueTTL time.Duration `mapstructure:"queue_ttl"`
+	// PollInterval 排队时检查空闲名额的间隔
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	// AvgDuration 一次生成的预估耗时，用于在有实际耗时之前估算排队时间
+	AvgDuration time.Duration `mapstructure:"avg_duration"`
+}
+
 var GlobalConfig Config
 
+// defaultTimezone 默认业务时区，用户主要在中国
+const defaultTimezone = "Asia/Shanghai"
+
+// location 业务时区，Init 之前使用默认时区
+var location = mustLoadLocation(defaultTimezone)
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// Location 返回业务时区
+func Location() *time.Location {
+	return location
+}
+
+// clock 当前时间的来源
+var clock = time.Now
+
+// Now 返回业务时区的当前时间
+func Now() time.Time {
+	return clock().In(location)
+}
+
+// SetClock 替换当前时间的来源，返回恢复函数，用于测试跨零点等场景
+func SetClock(now func() time.Time) (restore func()) {
+	old := clock
+	clock = now
+	return func() { clock = old }
+}
+
+// setDefaults 设置默认配置，配置文件和环境变量可覆盖
+func setDefaults() {
+	viper.SetDefault("app.timezone", defaultTimezone)
+	viper.SetDefault("server.port", "9000")
+	viper.SetDefault("server.read_timeout", "30s")
+	viper.SetDefault("server.write_timeout", "90s")
+	viper.SetDefault("server.idle_timeout", "120s")
+	viper.SetDefault("server.shutdown_timeout", "60s")
+	viper.SetDefault("server.validate_requests", false)
+	viper.SetDefault("server.trusted_proxies", []string{})
+	viper.SetDefault("server.trusted_platform", "")
+	viper.SetDefault("jwt.expire", "720h")
+	viper.SetDefault("sign_in.rewards", []int{20, 20, 20, 20, 20, 20, 50})
+	viper.SetDefault("sign_in.repair_window_days", 7)
+	viper.SetDefault("sign_in.repair_card_interval", 7)
+	viper.SetDefault("sign_in.max_repair_cards", 3)
+	viper.SetDefault("sign_in.reward_expire_days", 30)
+	viper.SetDefault("invite.invitee_reward", 20)
+	viper.SetDefault("invite.level_rewards", []int{20, 5})
+	viper.SetDefault("invite.max_rewards_per_day", 10)
+	viper.SetDefault("invite.max_rewards_total", 100)
+	viper.SetDefault("invite.max_invitees_per_ip", 3)
+	viper.SetDefault("invite.reward_expire_days", 90)
+	viper.SetDefault("pricing.generation_price", 20)
+	viper.SetDefault("pricing.vip.daily_free_quota", 3)
+	viper.SetDefault("pricing.vip.discount_percent", 80)
+	viper.SetDefault("pricing.vip.priority", false)
+	viper.SetDefault("pricing.svip.daily_free_quota", 10)
+	viper.SetDefault("pricing.svip.discount_percent", 50)
+	viper.SetDefault("pricing.svip.priority", true)
+	viper.SetDefault("generation.max_concurrency", 5)
+	viper.SetDefault("generation.lease_ttl", "120s")
+	viper.SetDefault("generation.max_wait", "30s")
+	viper.SetDefault("generation.queue_ttl", "30s")
+	viper.SetDefault("generation.poll_interval", "500ms")
+	viper.SetDefault("generation.avg_duration", "15s")
+	viper.SetDefault("rate_limit.enabled", true)
+	viper.SetDefault("rate_limit.store", RateLimitStoreMemory)
+	viper.SetDefault("rate_limit.generation.per_user.per_minute", 6)
+	viper.SetDefault("rate_limit.generation.per_user.burst", 3)
+	viper.SetDefault("rate_limit.generation.per_ip.per_minute", 20)
+	viper.SetDefault("rate_limit.generation.per_ip.burst", 10)
+	viper.SetDefault("rate_limit.login.per_user.per_minute", 0) // 登录前没有用户ID
+	viper.SetDefault("rate_limit.login.per_user.burst", 0)
+	viper.SetDefault("rate_limit.login.per_ip.per_minute", 30)
+	viper.SetDefault("rate_limit.login.per_ip.burst", 10)
+	viper.SetDefault("rate_limit.redeem.per_user.per_minute", 5)
+	viper.SetDefault("rate_limit.redeem.per_user.burst", 5)
+	viper.SetDefault("rate_limit.redeem.per_ip.per_minute", 10)
+	viper.SetDefault("rate_limit.redeem.per_ip.burst", 10)
+	viper.SetDefault("rate_limit.write.per_user.per_minute", 60)
+	viper.SetDefault("rate_limit.write.per_user.burst", 20)
+	viper.SetDefault("rate_limit.write.per_ip.per_minute", 120)
+	viper.SetDefault("rate_limit.write.per_ip.burst", 40)
+}
+
+// Init 加载配置，优先级：环境变量 > config/config.yaml > 默认值
+// 环境变量名为配置路径的大写形式，例如 sign_in.rewards 对应 SIGN_IN_REWARDS（逗号分隔）
 func Init() error {
+	setDefaults()
+
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath("./config")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
 
+	// 云函数环境通常只通过环境变量配置，没有配置文件时使用默认值
 	if err := viper.ReadInConfig(); err != nil {
-		return err
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return err
+		}
 	}
 
 	if err := viper.Unmarshal(&GlobalConfig); err != nil {
 		return err
 	}
 
+	loc, err := time.LoadLocation(GlobalConfig.App.Timezone)
+	if err != nil {
+		return fmt.Errorf("业务时区无效 %q: %v", GlobalConfig.App.Timezone, err)
+	}
+	location = loc
+
 	return nil
-} 
\ No newline at end of file
+}
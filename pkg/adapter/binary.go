@@ -0,0 +1,35 @@
+// Package adapter 存放各运行环境适配器共用的工具函数
+package adapter
+
+import (
+	"mime"
+	"strings"
+	"unicode/utf8"
+)
+
+// IsBinary 判断响应体是否需要 base64 编码，文本类型且是合法 UTF-8 的内容直接返回
+func IsBinary(contentType string, body []byte) bool {
+	if len(body) == 0 {
+		return false
+	}
+	if !utf8.Valid(body) {
+		return true
+	}
+	if contentType == "" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "json"),
+		strings.HasSuffix(mediaType, "xml"),
+		strings.HasSuffix(mediaType, "javascript"),
+		mediaType == "application/x-www-form-urlencoded":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+// Package lambda 将 AWS Lambda API Gateway v2（HTTP API）事件转换为标准 http 请求，并把响应转换回 Lambda 响应
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/adapter"
+)
+
+// Event API Gateway v2 负载格式的事件
+type Event struct {
+	Version         string            `json:"version"`
+	RawPath         string            `json:"rawPath"`
+	RawQueryString  string            `json:"rawQueryString"`
+	Cookies         []string          `json:"cookies"`
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+	RequestContext  RequestContext    `json:"requestContext"`
+}
+
+// RequestContext 事件中的请求上下文
+type RequestContext struct {
+	RequestID  string      `json:"requestId"`
+	DomainName string      `json:"domainName"`
+	Stage      string      `json:"stage"`
+	HTTP       HTTPContext `json:"http"`
+}
+
+// HTTPContext 事件中的 HTTP 请求信息
+type HTTPContext struct {
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	Protocol string `json:"protocol"`
+	SourceIP string `json:"sourceIp"`
+}
+
+// Response API Gateway v2 负载格式的响应
+type Response struct {
+	StatusCode      int               `json:"statusCode"`
+	Headers         map[string]string `json:"headers"`
+	Cookies         []string          `json:"cookies,omitempty"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+// Handle 处理一次 Lambda 事件，返回序列化后的响应
+func Handle(ctx context.Context, h http.Handler, payload []byte) ([]byte, error) {
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("解析Lambda事件失败: %v", err)
+	}
+
+	req, err := NewRequest(ctx, &event)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return json.Marshal(NewResponse(rec))
+}
+
+// NewRequest 将 Lambda 事件转换为 http 请求
+// v2 事件中重复的请求头已由网关按逗号合并，cookie 单独放在 cookies 字段中
+func NewRequest(ctx context.Context, event *Event) (*http.Request, error) {
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return nil, fmt.Errorf("解码请求体失败: %v", err)
+		}
+		body = decoded
+	}
+
+	path := event.RawPath
+	if path == "" {
+		path = event.RequestContext.HTTP.Path
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	u := &url.URL{Path: path, RawQuery: event.RawQueryString}
+	method := event.RequestContext.HTTP.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("构建请求失败: %v", err)
+	}
+	req.RequestURI = u.RequestURI()
+
+	for k, v := range event.Headers {
+		req.Header.Set(k, v)
+	}
+	if len(event.Cookies) > 0 {
+		req.Header.Set("Cookie", strings.Join(event.Cookies, "; "))
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	} else if event.RequestContext.DomainName != "" {
+		req.Host = event.RequestContext.DomainName
+	}
+
+	if ip := event.RequestContext.HTTP.SourceIP; ip != "" {
+		req.RemoteAddr = net.JoinHostPort(ip, "0")
+	}
+
+	return req, nil
+}
+
+// NewResponse 将记录的响应转换为 Lambda 响应，Set-Cookie 放入 cookies 字段，二进制内容使用 base64 编码
+func NewResponse(rec *httptest.ResponseRecorder) *Response {
+	result := rec.Result()
+	body := rec.Body.Bytes()
+
+	resp := &Response{
+		StatusCode: result.StatusCode,
+		Headers:    make(map[string]string, len(result.Header)),
+	}
+	for k, values := range result.Header {
+		if k == "Set-Cookie" {
+			resp.Cookies = values
+			continue
+		}
+		resp.Headers[k] = strings.Join(values, ", ")
+	}
+
+	if adapter.IsBinary(result.Header.Get("Content-Type"), body) {
+		resp.IsBase64Encoded = true
+		resp.Body = base64.StdEncoding.EncodeToString(body)
+	} else {
+		resp.Body = string(body)
+	}
+	return resp
+}
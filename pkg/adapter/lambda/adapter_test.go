@@ -0,0 +1,168 @@
+package lambda
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// pngBody 图片接口返回的二进制内容
+var pngBody = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// received 处理函数收到的请求
+type received struct {
+	Method     string
+	Path       string
+	Query      url.Values
+	Header     http.Header
+	Host       string
+	RemoteAddr string
+	Body       string
+}
+
+// echoHandler 记录收到的请求，/image 返回图片，其余返回 JSON 并设置 cookie 和同名的多个响应头
+func echoHandler(got *received) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*got = received{
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Query:      r.URL.Query(),
+			Header:     r.Header,
+			Host:       r.Host,
+			RemoteAddr: r.RemoteAddr,
+			Body:       string(body),
+		}
+
+		w.Header().Add("Vary", "Origin")
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.URL.Path == "/image" {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(pngBody)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "new"})
+		http.SetCookie(w, &http.Cookie{Name: "lang", Value: "en"})
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"code":0}`)
+	})
+}
+
+func TestHandleFixtures(t *testing.T) {
+	jsonResponse := Response{
+		StatusCode: http.StatusCreated,
+		Headers:    map[string]string{"Content-Type": "application/json; charset=utf-8", "Vary": "Origin, Accept-Encoding"},
+		Cookies:    []string{"session=new", "lang=en"},
+		Body:       `{"code":0}`,
+	}
+	tests := []struct {
+		fixture    string
+		wantMethod string
+		wantPath   string
+		wantQuery  url.Values
+		wantHeader map[string][]string
+		wantHost   string
+		wantRemote string
+		wantBody   string
+		wantResp   Response
+	}{
+		{
+			fixture:    "get_query.json",
+			wantMethod: http.MethodGet,
+			wantPath:   "/api/square/contents",
+			wantQuery:  url.Values{"user_id": {"u1"}, "tag": {"短发", "卷发"}},
+			wantHeader: map[string][]string{"Accept": {"application/json"}, "Cookie": {"session=abc; lang=zh"}},
+			wantHost:   "abc123.execute-api.ap-east-1.amazonaws.com",
+			wantRemote: "203.0.113.10:0",
+			wantResp:   jsonResponse,
+		},
+		{
+			fixture:    "post_base64.json",
+			wantMethod: http.MethodPost,
+			wantPath:   "/api/user/info",
+			wantQuery:  url.Values{},
+			wantHeader: map[string][]string{"Authorization": {"Bearer token-1"}, "X-Forwarded-For": {"203.0.113.10, 10.0.0.1"}},
+			// 没有 host 请求头时使用请求上下文中的域名
+			wantHost:   "abc123.execute-api.ap-east-1.amazonaws.com",
+			wantRemote: "203.0.113.10:0",
+			wantBody:   `{"user_id":"u1","nickname":"小明"}`,
+			wantResp:   jsonResponse,
+		},
+		{
+			fixture:    "get_image.json",
+			wantMethod: http.MethodGet,
+			wantPath:   "/image",
+			wantQuery:  url.Values{},
+			wantHeader: map[string][]string{"Accept": {"image/png"}},
+			wantHost:   "abc123.execute-api.ap-east-1.amazonaws.com",
+			wantRemote: "203.0.113.10:0",
+			wantResp: Response{
+				StatusCode:      http.StatusOK,
+				Headers:         map[string]string{"Content-Type": "image/png", "Vary": "Origin, Accept-Encoding"},
+				Body:            base64.StdEncoding.EncodeToString(pngBody),
+				IsBase64Encoded: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			payload, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got received
+			out, err := Handle(context.Background(), echoHandler(&got), payload)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if got.Method != tt.wantMethod || got.Path != tt.wantPath {
+				t.Errorf("request = %s %s, want %s %s", got.Method, got.Path, tt.wantMethod, tt.wantPath)
+			}
+			if !reflect.DeepEqual(got.Query, tt.wantQuery) {
+				t.Errorf("query = %v, want %v", got.Query, tt.wantQuery)
+			}
+			for k, want := range tt.wantHeader {
+				if v := got.Header.Values(k); !reflect.DeepEqual(v, want) {
+					t.Errorf("header %s = %v, want %v", k, v, want)
+				}
+			}
+			if got.Host != tt.wantHost || got.RemoteAddr != tt.wantRemote {
+				t.Errorf("host = %q, remote = %q, want %q, %q", got.Host, got.RemoteAddr, tt.wantHost, tt.wantRemote)
+			}
+			if got.Body != tt.wantBody {
+				t.Errorf("body = %q, want %q", got.Body, tt.wantBody)
+			}
+
+			var resp Response
+			if err := json.Unmarshal(out, &resp); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(resp, tt.wantResp) {
+				t.Errorf("response = %+v, want %+v", resp, tt.wantResp)
+			}
+		})
+	}
+}
+
+func TestHandleInvalidEvent(t *testing.T) {
+	var got received
+	payloads := []string{
+		`not json`,
+		`{"requestContext":{"http":{"method":"POST"}},"isBase64Encoded":true,"body":"%%%"}`,
+	}
+	for _, payload := range payloads {
+		if _, err := Handle(context.Background(), echoHandler(&got), []byte(payload)); err == nil {
+			t.Errorf("Handle(%s) error = nil, want error", payload)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package scf
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/adapter"
+)
+
+// Handle 处理一次 API 网关事件，返回序列化后的集成响应
+func Handle(ctx context.Context, h http.Handler, payload []byte) ([]byte, error) {
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, fmt.Errorf("解析网关事件失败: %v", err)
+	}
+
+	req, err := NewRequest(ctx, &event)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return json.Marshal(NewResponse(rec))
+}
+
+// NewRequest 将网关事件转换为 http 请求，保留请求头、多值查询参数、base64 请求体和客户端IP
+func NewRequest(ctx context.Context, event *Event) (*http.Request, error) {
+	body := []byte(event.Body)
+	if event.IsBase64Encoded || event.IsBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return nil, fmt.Errorf("解码请求体失败: %v", err)
+		}
+		body = decoded
+	}
+
+	path := event.Path
+	if path == "" {
+		path = event.RequestContext.Path
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	query := url.Values{}
+	params := event.query()
+	for _, k := range sortedKeys(params) {
+		for _, v := range params[k] {
+			query.Add(k, v)
+		}
+	}
+
+	u := &url.URL{Path: path, RawQuery: query.Encode()}
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(event.method()), u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("构建请求失败: %v", err)
+	}
+	req.RequestURI = u.RequestURI()
+
+	for k, values := range event.headers() {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+
+	// 网关已完成转发，来源IP作为直连地址，便于按IP做风控和限流
+	if ip := event.RequestContext.SourceIP; ip != "" {
+		req.RemoteAddr = net.JoinHostPort(ip, "0")
+	}
+
+	return req, nil
+}
+
+// NewResponse 将记录的响应转换为集成响应，二进制内容使用 base64 编码
+// 同名响应头按逗号合并，网关的集成响应不支持多值响应头
+func NewResponse(rec *httptest.ResponseRecorder) *Response {
+	result := rec.Result()
+	body := rec.Body.Bytes()
+
+	resp := &Response{
+		StatusCode: result.StatusCode,
+		Headers:    make(map[string]string, len(result.Header)),
+	}
+	for k, values := range result.Header {
+		resp.Headers[k] = strings.Join(values, ", ")
+	}
+
+	if adapter.IsBinary(result.Header.Get("Content-Type"), body) {
+		resp.IsBase64Encoded = true
+		resp.Body = base64.StdEncoding.EncodeToString(body)
+	} else {
+		resp.Body = string(body)
+	}
+	return resp
+}
@@ -0,0 +1,133 @@
+// Package scf 将腾讯云 API 网关触发云函数的事件转换为标准 http 请求，并把响应转换回集成响应
+package scf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Event API 网关触发云函数的事件
+// 同时兼容旧版自定义事件中的 method、query、isBase64 字段
+type Event struct {
+	HTTPMethod            string                 `json:"httpMethod"`
+	Path                  string                 `json:"path"`
+	Headers               map[string]interface{} `json:"headers"`
+	MultiValueHeaders     MultiValue             `json:"multiValueHeaders"`
+	QueryString           MultiValue             `json:"queryString"`
+	QueryStringParameters MultiValue             `json:"queryStringParameters"`
+	Body                  string                 `json:"body"`
+	IsBase64Encoded       bool                   `json:"isBase64Encoded"`
+	RequestContext        RequestContext         `json:"requestContext"`
+
+	// 旧版自定义事件字段
+	Method   string     `json:"method"`
+	Query    MultiValue `json:"query"`
+	IsBase64 bool       `json:"isBase64"`
+}
+
+// RequestContext 事件中的请求上下文
+type RequestContext struct {
+	ServiceID  string `json:"serviceId"`
+	RequestID  string `json:"requestId"`
+	HTTPMethod string `json:"httpMethod"`
+	Path       string `json:"path"`
+	SourceIP   string `json:"sourceIp"`
+	Stage      string `json:"stage"`
+}
+
+// Response API 网关集成响应
+type Response struct {
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+	StatusCode      int               `json:"statusCode"`
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+}
+
+// MultiValue 多值参数，API 网关对只出现一次的参数传字符串，重复出现的参数传字符串数组
+type MultiValue map[string][]string
+
+// UnmarshalJSON 同时支持字符串和字符串数组形式的值
+func (m *MultiValue) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	values := make(MultiValue, len(raw))
+	for k, v := range raw {
+		v = bytes.TrimSpace(v)
+		switch {
+		case len(v) > 0 && v[0] == '[':
+			var list []string
+			if err := json.Unmarshal(v, &list); err != nil {
+				return fmt.Errorf("参数 %s 格式错误: %v", k, err)
+			}
+			values[k] = list
+		case bytes.Equal(v, []byte("null")):
+			values[k] = []string{""}
+		default:
+			var s string
+			if err := json.Unmarshal(v, &s); err != nil {
+				// 部分网关会把数字、布尔值直接以原始 JSON 传入
+				s = string(v)
+			}
+			values[k] = []string{s}
+		}
+	}
+	*m = values
+	return nil
+}
+
+// method 返回请求方法，优先使用事件顶层字段
+func (e *Event) method() string {
+	for _, m := range []string{e.HTTPMethod, e.RequestContext.HTTPMethod, e.Method} {
+		if m != "" {
+			return m
+		}
+	}
+	return "GET"
+}
+
+// query 返回查询参数，queryString 包含完整的多值参数，其余字段作为兼容
+func (e *Event) query() MultiValue {
+	for _, q := range []MultiValue{e.QueryString, e.QueryStringParameters, e.Query} {
+		if len(q) > 0 {
+			return q
+		}
+	}
+	return nil
+}
+
+// headers 返回请求头，multiValueHeaders 中的值优先
+func (e *Event) headers() map[string][]string {
+	headers := make(map[string][]string, len(e.Headers))
+	for k, v := range e.Headers {
+		switch v := v.(type) {
+		case string:
+			headers[k] = []string{v}
+		case []interface{}:
+			for _, item := range v {
+				headers[k] = append(headers[k], fmt.Sprint(item))
+			}
+		case nil:
+		default:
+			headers[k] = []string{fmt.Sprint(v)}
+		}
+	}
+	for k, v := range e.MultiValueHeaders {
+		headers[k] = v
+	}
+	return headers
+}
+
+// sortedKeys 返回按字母排序的键，保证生成的查询字符串稳定
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,119 @@
+package scf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Runtime 云函数自定义运行时（Runtime: CustomRuntime）的运行时 API 客户端
+// 函数启动后先通知就绪，之后循环拉取事件、处理并回传结果
+type Runtime struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewRuntime 根据平台注入的 SCF_RUNTIME_API、SCF_RUNTIME_API_PORT 环境变量创建运行时客户端
+func NewRuntime() (*Runtime, error) {
+	host := os.Getenv("SCF_RUNTIME_API")
+	port := os.Getenv("SCF_RUNTIME_API_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("缺少 SCF_RUNTIME_API 或 SCF_RUNTIME_API_PORT 环境变量，需在云函数自定义运行时中启动")
+	}
+	return &Runtime{
+		baseURL: "http://" + host + ":" + port,
+		// 拉取事件是长轮询，不设置超时
+		client: &http.Client{},
+	}, nil
+}
+
+// Serve 通知平台初始化完成，然后逐个处理 API 网关事件，直到 ctx 取消或运行时 API 不可用
+// 单个事件处理失败时上报错误并继续处理下一个事件
+func (r *Runtime) Serve(ctx context.Context, h http.Handler) error {
+	if err := r.post(ctx, "/runtime/init/ready", nil); err != nil {
+		return fmt.Errorf("通知初始化完成失败: %v", err)
+	}
+
+	for {
+		payload, limit, err := r.next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("拉取事件失败: %v", err)
+		}
+
+		resp, handleErr := invoke(ctx, h, payload, limit)
+		if handleErr != nil {
+			err = r.post(ctx, "/runtime/invocation/error", []byte(handleErr.Error()))
+		} else {
+			err = r.post(ctx, "/runtime/invocation/response", resp)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("回传处理结果失败: %v", err)
+		}
+	}
+}
+
+// next 拉取下一个事件，返回事件内容和本次调用的超时时间，平台未提供超时时间时为0
+func (r *Runtime) next(ctx context.Context) ([]byte, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+"/runtime/invocation/next", nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("运行时API返回 %d: %s", resp.StatusCode, payload)
+	}
+
+	var limit time.Duration
+	if ms, err := strconv.Atoi(resp.Header.Get("time_limit_in_ms")); err == nil && ms > 0 {
+		limit = time.Duration(ms) * time.Millisecond
+	}
+	return payload, limit, nil
+}
+
+// invoke 处理一个事件，limit 大于0时请求在超时后取消
+func invoke(ctx context.Context, h http.Handler, payload []byte, limit time.Duration) ([]byte, error) {
+	if limit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limit)
+		defer cancel()
+	}
+	return Handle(ctx, h, payload)
+}
+
+func (r *Runtime) post(ctx context.Context, path string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("运行时API返回 %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package scf
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeRuntimeAPI 模拟云函数运行时API，依次下发 events，全部下发后取消 ctx
+type fakeRuntimeAPI struct {
+	mu        sync.Mutex
+	events    []string
+	ready     bool
+	responses []string
+	errors    []string
+	cancel    context.CancelFunc
+}
+
+func (f *fakeRuntimeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	switch r.Method + " " + r.URL.Path {
+	case "POST /runtime/init/ready":
+		f.ready = true
+	case "GET /runtime/invocation/next":
+		if len(f.events) == 0 {
+			f.cancel()
+			http.Error(w, "no more events", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("request_id", "req-1")
+		w.Header().Set("time_limit_in_ms", "3000")
+		io.WriteString(w, f.events[0])
+		f.events = f.events[1:]
+	case "POST /runtime/invocation/response":
+		f.responses = append(f.responses, string(body))
+	case "POST /runtime/invocation/error":
+		f.errors = append(f.errors, string(body))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestRuntimeServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	api := &fakeRuntimeAPI{
+		events: []string{
+			`{"httpMethod":"GET","path":"/ping","queryString":{"name":"scf"}}`,
+			`not json`,
+		},
+		cancel: cancel,
+	}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("request context has no deadline")
+		}
+		io.WriteString(w, "hello "+r.URL.Query().Get("name"))
+	})
+
+	rt := &Runtime{baseURL: srv.URL, client: srv.Client()}
+	if err := rt.Serve(ctx, h); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	if !api.ready {
+		t.Error("runtime did not report ready")
+	}
+	if len(api.responses) != 1 {
+		t.Fatalf("responses = %v, want 1", api.responses)
+	}
+	var resp Response
+	if err := json.Unmarshal([]byte(api.responses[0]), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Body != "hello scf" {
+		t.Errorf("response = %+v, want 200 hello scf", resp)
+	}
+	if len(api.errors) != 1 {
+		t.Errorf("errors = %v, want the malformed event reported", api.errors)
+	}
+}
+
+func TestNewRuntimeRequiresEnv(t *testing.T) {
+	t.Setenv("SCF_RUNTIME_API", "")
+	t.Setenv("SCF_RUNTIME_API_PORT", "")
+	if _, err := NewRuntime(); err == nil {
+		t.Fatal("NewRuntime() without env: want error")
+	}
+
+	t.Setenv("SCF_RUNTIME_API", "127.0.0.1")
+	t.Setenv("SCF_RUNTIME_API_PORT", "9001")
+	rt, err := NewRuntime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rt.baseURL != "http://127.0.0.1:9001" {
+		t.Errorf("baseURL = %q", rt.baseURL)
+	}
+}
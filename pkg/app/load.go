@@ -0,0 +1,89 @@
+// Package app 负责组装路由和依赖，供各运行环境的入口复用
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/auth"
+	"github.com/MRsummer/ChangeHairStyle/pkg/cos"
+	"github.com/MRsummer/ChangeHairStyle/pkg/db"
+	"github.com/MRsummer/ChangeHairStyle/pkg/governor"
+	"github.com/MRsummer/ChangeHairStyle/pkg/handler"
+	"github.com/MRsummer/ChangeHairStyle/pkg/logger"
+	"github.com/MRsummer/ChangeHairStyle/pkg/moderation"
+	"github.com/MRsummer/ChangeHairStyle/pkg/notify"
+	"github.com/MRsummer/ChangeHairStyle/pkg/ratelimit"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/volcengine"
+	"github.com/MRsummer/ChangeHairStyle/pkg/wechat"
+	"github.com/MRsummer/ChangeHairStyle/pkg/wxpay"
+)
+
+// Load 加载配置并根据环境变量初始化处理请求所需的依赖
+func Load() (*handler.Services, error) {
+	if err := config.Init(); err != nil {
+		return nil, fmt.Errorf("加载配置失败: %v", err)
+	}
+
+	database, err := db.InitDB()
+	if err != nil {
+		return nil, fmt.Errorf("初始化数据库失败: %v", err)
+	}
+
+	storage, err := cos.NewClient(
+		os.Getenv("COS_SECRET_ID"),
+		os.Getenv("COS_SECRET_KEY"),
+		os.Getenv("COS_BUCKET"),
+		os.Getenv("COS_REGION"),
+	)
+	if err != nil {
+		database.Close()
+		return nil, fmt.Errorf("创建腾讯云 COS 客户端失败: %v", err)
+	}
+
+	rateLimitStore, err := ratelimit.NewStore(config.GlobalConfig.RateLimit.Store, database)
+	if err != nil {
+		database.Close()
+		return nil, fmt.Errorf("创建限流存储失败: %v", err)
+	}
+
+	tokens, err := auth.NewSigner(config.GlobalConfig.JWT)
+	if err != nil {
+		database.Close()
+		return nil, fmt.Errorf("创建登录凭证签发器失败: %v", err)
+	}
+	if tokens == nil {
+		logger.Info("未配置 jwt.secret，登录凭证未开启，限流只按客户端IP生效")
+	}
+
+	svc := &handler.Services{
+		DB:     database,
+		Repos:  repo.New(repo.MySQL),
+		Config: &config.GlobalConfig,
+		Generator: volcengine.NewClient(
+			os.Getenv("VOLCENGINE_ACCESS_KEY_ID"),
+			os.Getenv("VOLCENGINE_SECRET_ACCESS_KEY"),
+		),
+		Governor:  governor.New(database, repo.MySQL, config.GlobalConfig.Generation),
+		Storage:   storage,
+		Moderator: moderation.NewFromEnv(),
+		Wechat:    wechat.NewClientFromEnv(),
+		RateLimit: rateLimitStore,
+		Tokens:    tokens,
+	}
+	svc.Notifier = notify.NewSubscribeNotifier(database, svc.Repos.Subscriptions, svc.Wechat, notify.TemplatesFromEnv(), os.Getenv("WX_MINIPROGRAM_STATE"))
+
+	// 未配置商户信息时支付接口不可用
+	svc.Pay, err = wxpay.NewClientFromEnv()
+	if errors.Is(err, wxpay.ErrNotConfigured) {
+		logger.Info("未配置微信支付商户信息，支付功能未开启")
+	} else if err != nil {
+		database.Close()
+		return nil, fmt.Errorf("初始化微信支付失败: %v", err)
+	}
+
+	return svc, nil
+}
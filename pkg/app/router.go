@@ -0,0 +1,145 @@
+package app
+
+import (
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/handler"
+	"github.com/MRsummer/ChangeHairStyle/pkg/i18n"
+	"github.com/MRsummer/ChangeHairStyle/pkg/logger"
+	"github.com/MRsummer/ChangeHairStyle/pkg/middleware"
+	"github.com/MRsummer/ChangeHairStyle/pkg/openapi"
+	"github.com/MRsummer/ChangeHairStyle/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// NewRouter 创建注册了全部路由的 gin 引擎，处理函数共用 svc 中的依赖
+func NewRouter(svc *handler.Services) *gin.Engine {
+	// 设置 Gin 为发布模式
+	gin.SetMode(gin.ReleaseMode)
+
+	// 创建Gin引擎
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	// 只采信可信代理转发的客户端IP，避免伪造 X-Forwarded-For 绕过按IP限流
+	if err := r.SetTrustedProxies(svc.Config.Server.TrustedProxies); err != nil {
+		logger.WithError(err).Warn("可信代理配置错误，不采信任何代理")
+		r.SetTrustedProxies(nil)
+	}
+	r.TrustedPlatform = svc.Config.Server.TrustedPlatform
+
+	// 添加请求追踪中间件
+	r.Use(middleware.TraceMiddleware())
+
+	// 提示信息的语言优先取已登录用户资料中的设置，用户身份由 UserAuthMiddleware 验证
+	r.Use(i18n.Middleware(middleware.GetVerifiedUserID, func(userID string) (string, error) {
+		user, err := svc.Repos.Users.Get(svc.DB, userID)
+		if err != nil || user == nil {
+			return "", err
+		}
+		return user.Language, nil
+	}))
+
+	// 统一输出处理函数记录的错误
+	r.Use(apperr.Middleware(handler.ErrorMappings))
+
+	// 按接口文档校验请求，只在测试环境开启
+	doc := openapi.Default()
+	if svc.Config.Server.ValidateRequests {
+		r.Use(openapi.Validator(doc))
+	}
+
+	// 校验登录凭证，按用户限流等需要已验证的用户ID
+	r.Use(middleware.UserAuthMiddleware(svc.Tokens))
+
+	// 接口限流，各接口组的规则见 config.RateLimitConfig
+	store := svc.RateLimit
+	if store == nil {
+		store = ratelimit.NewMemoryStore()
+	}
+	rl := svc.Config.RateLimit
+	limiter := middleware.NewRateLimiter(store, rl.Enabled)
+	generationLimit := limiter.Limit("generation", rl.Generation)
+	loginLimit := limiter.Limit("login", rl.Login)
+	redeemLimit := limiter.Limit("redeem", rl.Redeem)
+	writeLimit := limiter.Limit("write", rl.Write)
+
+	// 设置路由
+	r.GET("/health", func(c *gin.Context) {
+		apperr.OK(c, nil)
+	})
+	r.GET("/openapi.json", openapi.Handler(doc))
+
+	// 发型生成路由
+	r.POST("/api/hair-style", generationLimit, svc.HandleHairStyle)
+
+	// 获取生成记录路由
+	r.GET("/api/hair-style/records", svc.HandleGetRecords)
+
+	// 生成价格查询路由
+	r.GET("/api/hair-style/quote", svc.HandleGetGenerationQuote)
+
+	// 用户信息路由
+	r.POST("/api/user/info", writeLimit, svc.HandleUpdateUserInfo)
+	r.GET("/api/user/info/get", svc.HandleGetUserInfo)
+	r.POST("/api/user/code/use", writeLimit, svc.HandleUseInviteCode)
+	r.GET("/api/user/invite/stats", svc.HandleGetInviteStats)
+	r.POST("/api/user/redeem", redeemLimit, svc.HandleRedeem)
+	r.POST("/api/user/sign-in", writeLimit, svc.HandleSignIn)
+	r.POST("/api/user/sign-in/repair", writeLimit, svc.HandleRepairSignIn)
+	r.GET("/api/user/sign-in/calendar", svc.HandleGetSignInCalendar)
+	r.POST("/api/user/wx-login", loginLimit, svc.HandleWxLogin)
+	r.POST("/api/user/subscriptions", svc.HandleSaveSubscriptions)
+	r.GET("/api/user/subscriptions", svc.HandleGetSubscriptions)
+
+	// 广场相关路由
+	r.POST("/api/square/share", writeLimit, svc.HandleShareToSquare)
+	r.GET("/api/square/contents", svc.HandleGetSquareContents)
+	r.POST("/api/square/like", writeLimit, svc.HandleLike)
+	r.POST("/api/square/report", writeLimit, svc.HandleReportContent)
+	r.GET("/api/square/tags/suggest", svc.HandleSuggestTags)
+	r.GET("/api/square/tags/trending", svc.HandleGetTrendingTags)
+
+	// 收藏相关路由
+	r.POST("/api/favorites", writeLimit, svc.HandleAddFavorite)
+	r.DELETE("/api/favorites", svc.HandleRemoveFavorite)
+	r.GET("/api/favorites", svc.HandleGetFavorites)
+	r.POST("/api/favorites/collections", writeLimit, svc.HandleCreateCollection)
+	r.GET("/api/favorites/collections", svc.HandleGetCollections)
+	r.DELETE("/api/favorites/collections", svc.HandleDeleteCollection)
+
+	// 通知相关路由
+	r.GET("/api/notifications", svc.HandleGetNotifications)
+	r.GET("/api/notifications/unread-count", svc.HandleGetUnreadNotificationCount)
+	r.POST("/api/notifications/read", svc.HandleMarkNotificationsRead)
+
+	// 支付相关路由
+	r.GET("/api/pay/products", svc.HandleGetCoinProducts)
+	r.POST("/api/pay/orders", writeLimit, svc.HandleCreatePayOrder)
+	r.GET("/api/pay/orders", svc.HandleGetPayOrders)
+	r.GET("/api/pay/orders/status", svc.HandleGetPayOrderStatus)
+	r.POST("/api/pay/notify", svc.HandlePayNotify)
+
+	// 管理后台路由
+	admin := r.Group("/api/admin", middleware.AdminAuthMiddleware())
+	admin.GET("/reports", svc.HandleGetReportedContents)
+	admin.POST("/reports/approve", svc.HandleApproveContent)
+	admin.POST("/reports/remove", svc.HandleRemoveContent)
+	admin.GET("/moderation-logs", svc.HandleGetModerationLogs)
+	admin.GET("/nicknames", svc.HandleGetPendingNicknames)
+	admin.POST("/nicknames/approve", svc.HandleApproveNickname)
+	admin.POST("/nicknames/reject", svc.HandleRejectNickname)
+	admin.POST("/jobs/sign-in-reminder", svc.HandleSendSignInReminders)
+	admin.POST("/jobs/expire-coins", svc.HandleExpireCoins)
+	admin.POST("/invite-codes", svc.HandleSetInviteCode)
+	admin.POST("/pay/refunds", svc.HandleRefundPayOrder)
+	admin.POST("/redeem/campaigns", svc.HandleCreateRedeemCampaign)
+	admin.POST("/redeem/codes", svc.HandleCreateRedeemCodes)
+	admin.GET("/redeem/codes", svc.HandleGetRedeemCodes)
+
+	// 新增路由需同步补充 openapi.Routes
+	for _, route := range doc.Undocumented(r.Routes()) {
+		logger.Warnf("接口文档中缺少路由: %s", route)
+	}
+
+	return r
+}
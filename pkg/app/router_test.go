@@ -0,0 +1,30 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/handler"
+	"github.com/MRsummer/ChangeHairStyle/pkg/openapi"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo/sqlite"
+)
+
+// TestRoutesDocumented 注册的路由与 openapi.Routes 必须一一对应，新增或删除路由时需同步修改文档
+func TestRoutesDocumented(t *testing.T) {
+	r := NewRouter(&handler.Services{Config: &config.Config{}, Repos: sqlite.New()})
+	doc := openapi.Default()
+
+	for _, route := range doc.Undocumented(r.Routes()) {
+		t.Errorf("route %s is not documented in openapi.Routes", route)
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for _, route := range openapi.Routes {
+		if !registered[route.Method+" "+route.Path] {
+			t.Errorf("documented route %s %s is not registered", route.Method, route.Path)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+// Package apperr 业务错误码和统一的响应格式，客户端按 code 区分错误类型
+package apperr
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/i18n"
+)
+
+// Code 响应中的错误码，0 表示成功，已发布的错误码不能修改含义
+type Code int
+
+// 通用错误码，与 HTTP 状态码一致
+const (
+	CodeOK              Code = 0
+	CodeBadRequest      Code = 400
+	CodeUnauthorized    Code = 401
+	CodeForbidden       Code = 403
+	CodeNotFound        Code = 404
+	CodeConflict        Code = 409
+	CodeTooManyRequests Code = 429
+	CodeInternal        Code = 500
+	CodeUnavailable     Code = 503
+)
+
+// 业务错误码，五位数字，前三位表示模块
+const (
+	// 请求参数 400xx
+	CodeMissingParam Code = 40001
+	CodeInvalidParam Code = 40002
+
+	// 用户 100xx
+	CodeUserNotFound       Code = 10001
+	CodeInsufficientCoin   Code = 10002
+	CodeNicknameRejected   Code = 10003
+	CodeNicknameNotPending Code = 10004
+
+	// 邀请 101xx
+	CodeInviteCodeInvalid Code = 10101
+	CodeInviteCodeUsed    Code = 10102
+	CodeInviteCodeOwn     Code = 10103
+	CodeInviteCycle       Code = 10104
+	CodeInviteCodeTaken   Code = 10105
+	CodeVanityCodeFormat  Code = 10106
+	CodeInviteNotNewUser  Code = 10107
+
+	// 签到 102xx
+	CodeAlreadySignedIn     Code = 10201
+	CodeDateAlreadySignedIn Code = 10202
+	CodeNoRepairCard        Code = 10203
+	CodeRepairOutOfWindow   Code = 10204
+
+	// 发型生成 103xx
+	CodeGenerationQueued Code = 10301
+	CodeGenerationFailed Code = 10302
+
+	// 广场 104xx
+	CodeRecordNotFound      Code = 10401
+	CodeRecordNotOwned      Code = 10402
+	CodeRecordAlreadyShared Code = 10403
+	CodeContentNotFound     Code = 10404
+	CodeContentRejected     Code = 10405
+	CodeContentRemoved      Code = 10406
+	CodeAlreadyReported     Code = 10407
+
+	// 收藏 105xx
+	CodeCollectionNotFound Code = 10501
+	CodeCollectionExists   Code = 10502
+	CodeFavoriteNotFound   Code = 10503
+	CodeCollectionLimit    Code = 10504
+
+	// 支付 106xx
+	CodePayNotConfigured    Code = 10601
+	CodeProductNotFound     Code = 10602
+	CodeOrderNotFound       Code = 10603
+	CodeOrderNotRefundable  Code = 10604
+	CodeRefundCoinsSpent    Code = 10605
+	CodePrepayFailed        Code = 10606
+	CodeRefundFailed        Code = 10607
+	CodeAmountMismatch      Code = 10608
+	CodeRefundNotFound      Code = 10609
+	CodeMembershipDowngrade Code = 10610
+
+	// 兑换码 107xx
+	CodeRedeemCodeInvalid   Code = 10701
+	CodeRedeemNotStarted    Code = 10702
+	CodeRedeemExpired       Code = 10703
+	CodeRedeemCodeExhausted Code = 10704
+	CodeRedeemUserLimit     Code = 10705
+	CodeCampaignNotFound    Code = 10706
+	CodeRedeemCodeExists    Code = 10707
+	CodeRedeemBatchSize     Code = 10708
+	CodeRedeemCodeFormat    Code = 10709
+
+	// 通知 108xx
+	CodeReminderNotConfigured Code = 10801
+)
+
+// Error 带错误码的业务错误，提示信息按错误码从文案表中取，Args 为文案中的参数，Err 只写日志
+type Error struct {
+	Status int
+	Code   Code
+	Args   []interface{}
+	Err    error
+}
+
+// New 创建业务错误，需要在文案表中为 code 添加提示信息
+func New(status int, code Code) *Error {
+	return &Error{Status: status, Code: code}
+}
+
+// Error 返回中文提示信息，用于日志
+func (e *Error) Error() string {
+	msg := e.Message(i18n.Default)
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is 错误码相同即视为同一个错误，With 或 Wrap 后仍可用 errors.Is 判断
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Message 返回指定语言的提示信息
+func (e *Error) Message(locale i18n.Locale) string {
+	return Message(locale, e.Code, e.Args...)
+}
+
+// With 返回填入文案参数的副本
+func (e *Error) With(args ...interface{}) *Error {
+	c := *e
+	c.Args = args
+	return &c
+}
+
+// Wrap 返回附带原始错误的副本，原始错误不会返回给用户
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// 通用错误
+var (
+	ErrBadRequest      = New(http.StatusBadRequest, CodeBadRequest)
+	ErrUnauthorized    = New(http.StatusUnauthorized, CodeUnauthorized)
+	ErrNotFound        = New(http.StatusNotFound, CodeNotFound)
+	ErrTooManyRequests = New(http.StatusTooManyRequests, CodeTooManyRequests)
+	ErrInternal        = New(http.StatusInternalServerError, CodeInternal)
+
+	// ErrMissingParam 缺少必填参数，With 参数名
+	ErrMissingParam = New(http.StatusBadRequest, CodeMissingParam)
+	// ErrInvalidParam 参数格式或取值错误，With 参数名
+	ErrInvalidParam = New(http.StatusBadRequest, CodeInvalidParam)
+)
+
+// 业务错误
+var (
+	ErrUserNotFound       = New(http.StatusNotFound, CodeUserNotFound)
+	ErrInsufficientCoin   = New(http.StatusBadRequest, CodeInsufficientCoin)
+	ErrNicknameRejected   = New(http.StatusBadRequest, CodeNicknameRejected)
+	ErrNicknameNotPending = New(http.StatusConflict, CodeNicknameNotPending)
+
+	ErrInviteCodeInvalid = New(http.StatusNotFound, CodeInviteCodeInvalid)
+	ErrInviteCodeUsed    = New(http.StatusBadRequest, CodeInviteCodeUsed)
+	ErrInviteCodeOwn     = New(http.StatusBadRequest, CodeInviteCodeOwn)
+	ErrInviteCycle       = New(http.StatusBadRequest, CodeInviteCycle)
+	ErrInviteCodeTaken   = New(http.StatusConflict, CodeInviteCodeTaken)
+	// ErrVanityCodeFormat With 最短和最长位数
+	ErrVanityCodeFormat = New(http.StatusBadRequest, CodeVanityCodeFormat)
+	ErrInviteNotNewUser = New(http.StatusBadRequest, CodeInviteNotNewUser)
+
+	ErrAlreadySignedIn     = New(http.StatusBadRequest, CodeAlreadySignedIn)
+	ErrDateAlreadySignedIn = New(http.StatusBadRequest, CodeDateAlreadySignedIn)
+	ErrNoRepairCard        = New(http.StatusBadRequest, CodeNoRepairCard)
+	ErrRepairOutOfWindow   = New(http.StatusBadRequest, CodeRepairOutOfWindow)
+
+	ErrGenerationFailed = New(http.StatusBadGateway, CodeGenerationFailed)
+
+	ErrRecordNotFound      = New(http.StatusNotFound, CodeRecordNotFound)
+	ErrRecordNotOwned      = New(http.StatusForbidden, CodeRecordNotOwned)
+	ErrRecordAlreadyShared = New(http.StatusConflict, CodeRecordAlreadyShared)
+	ErrContentNotFound     = New(http.StatusNotFound, CodeContentNotFound)
+	ErrContentRejected     = New(http.StatusBadRequest, CodeContentRejected)
+	ErrContentRemoved      = New(http.StatusBadRequest, CodeContentRemoved)
+	ErrAlreadyReported     = New(http.StatusConflict, CodeAlreadyReported)
+
+	ErrCollectionNotFound = New(http.StatusNotFound, CodeCollectionNotFound)
+	ErrCollectionExists   = New(http.StatusConflict, CodeCollectionExists)
+	ErrFavoriteNotFound   = New(http.StatusNotFound, CodeFavoriteNotFound)
+	// ErrCollectionLimit With 收藏夹数量上限
+	ErrCollectionLimit = New(http.StatusBadRequest, CodeCollectionLimit)
+
+	ErrPayNotConfigured    = New(http.StatusServiceUnavailable, CodePayNotConfigured)
+	ErrProductNotFound     = New(http.StatusNotFound, CodeProductNotFound)
+	ErrOrderNotFound       = New(http.StatusNotFound, CodeOrderNotFound)
+	ErrOrderNotRefundable  = New(http.StatusConflict, CodeOrderNotRefundable)
+	ErrRefundCoinsSpent    = New(http.StatusConflict, CodeRefundCoinsSpent)
+	ErrPrepayFailed        = New(http.StatusBadGateway, CodePrepayFailed)
+	ErrRefundFailed        = New(http.StatusBadGateway, CodeRefundFailed)
+	ErrAmountMismatch      = New(http.StatusConflict, CodeAmountMismatch)
+	ErrRefundNotFound      = New(http.StatusNotFound, CodeRefundNotFound)
+	ErrMembershipDowngrade = New(http.StatusConflict, CodeMembershipDowngrade)
+
+	ErrRedeemCodeInvalid   = New(http.StatusNotFound, CodeRedeemCodeInvalid)
+	ErrRedeemNotStarted    = New(http.StatusBadRequest, CodeRedeemNotStarted)
+	ErrRedeemExpired       = New(http.StatusBadRequest, CodeRedeemExpired)
+	ErrRedeemCodeExhausted = New(http.StatusConflict, CodeRedeemCodeExhausted)
+	ErrRedeemUserLimit     = New(http.StatusConflict, CodeRedeemUserLimit)
+	ErrCampaignNotFound    = New(http.StatusNotFound, CodeCampaignNotFound)
+	ErrRedeemCodeExists    = New(http.StatusConflict, CodeRedeemCodeExists)
+	// ErrRedeemBatchSize With 每批数量上限
+	ErrRedeemBatchSize = New(http.StatusBadRequest, CodeRedeemBatchSize)
+	// ErrRedeemCodeFormat With 最短和最长位数
+	ErrRedeemCodeFormat = New(http.StatusBadRequest, CodeRedeemCodeFormat)
+
+	ErrReminderNotConfigured = New(http.StatusBadRequest, CodeReminderNotConfigured)
+)
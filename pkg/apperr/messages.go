@@ -0,0 +1,169 @@
+package apperr
+
+import (
+	"fmt"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/i18n"
+)
+
+// messages 各语言的提示信息，按错误码索引，缺少翻译时使用中文
+// 新增错误码时需要同时添加所有语言的文案
+var messages = map[i18n.Locale]map[Code]string{
+	i18n.Chinese: {
+		CodeOK:              "success",
+		CodeBadRequest:      "请求参数错误",
+		CodeUnauthorized:    "无权访问",
+		CodeForbidden:       "禁止访问",
+		CodeNotFound:        "资源不存在",
+		CodeConflict:        "资源冲突",
+		CodeTooManyRequests: "请求过于频繁，请稍后再试",
+		CodeInternal:        "服务器繁忙，请稍后重试",
+		CodeUnavailable:     "服务暂不可用，请稍后重试",
+
+		CodeMissingParam: "缺少参数：%s",
+		CodeInvalidParam: "参数错误：%s",
+
+		CodeUserNotFound:       "用户不存在",
+		CodeInsufficientCoin:   "造型币不足",
+		CodeNicknameRejected:   "昵称包含违规内容，请修改后重试",
+		CodeNicknameNotPending: "该昵称不在待审核状态",
+
+		CodeInviteCodeInvalid: "邀请码无效",
+		CodeInviteCodeUsed:    "您已使用过邀请码",
+		CodeInviteCodeOwn:     "不能使用自己的邀请码",
+		CodeInviteCycle:       "不能使用自己邀请的用户的邀请码",
+		CodeInviteCodeTaken:   "邀请码已被占用",
+		CodeVanityCodeFormat:  "自定义邀请码需为%d-%d位字母或数字",
+		CodeInviteNotNewUser:  "邀请码仅限还未生成过发型的新用户使用",
+
+		CodeAlreadySignedIn:     "今日已签到",
+		CodeDateAlreadySignedIn: "该日期已签到",
+		CodeNoRepairCard:        "补签卡不足",
+		CodeRepairOutOfWindow:   "超出可补签的日期范围",
+
+		CodeGenerationQueued: "排队中，请稍候",
+		CodeGenerationFailed: "发型生成失败，请稍后重试",
+
+		CodeRecordNotFound:      "记录不存在",
+		CodeRecordNotOwned:      "不能分享他人的记录",
+		CodeRecordAlreadyShared: "该记录已分享到广场",
+		CodeContentNotFound:     "内容不存在",
+		CodeContentRejected:     "内容未通过审核，无法分享",
+		CodeContentRemoved:      "内容已下架",
+		CodeAlreadyReported:     "您已举报过该内容",
+
+		CodeCollectionNotFound: "收藏夹不存在",
+		CodeCollectionExists:   "收藏夹名称已存在",
+		CodeFavoriteNotFound:   "未收藏该内容",
+		CodeCollectionLimit:    "最多只能创建%d个收藏夹",
+
+		CodePayNotConfigured:    "支付功能未开启",
+		CodeProductNotFound:     "商品不存在",
+		CodeOrderNotFound:       "订单不存在",
+		CodeOrderNotRefundable:  "订单未支付或已退款",
+		CodeRefundCoinsSpent:    "用户金币已使用，无法退款",
+		CodePrepayFailed:        "微信支付下单失败，请稍后重试",
+		CodeRefundFailed:        "申请退款失败，请稍后重试",
+		CodeAmountMismatch:      "支付金额与订单金额不一致",
+		CodeRefundNotFound:      "退款单不存在",
+		CodeMembershipDowngrade: "当前会员等级更高，暂不能购买低等级会员",
+
+		CodeRedeemCodeInvalid:   "兑换码无效",
+		CodeRedeemNotStarted:    "兑换活动尚未开始",
+		CodeRedeemExpired:       "兑换码已过期",
+		CodeRedeemCodeExhausted: "兑换码已被兑换完",
+		CodeRedeemUserLimit:     "您已达到该活动的兑换次数上限",
+		CodeCampaignNotFound:    "兑换活动不存在",
+		CodeRedeemCodeExists:    "兑换码已存在",
+		CodeRedeemBatchSize:     "每批兑换码数量需在1-%d之间",
+		CodeRedeemCodeFormat:    "兑换码需为%d-%d位字母或数字",
+
+		CodeReminderNotConfigured: "未配置签到提醒模板",
+	},
+	i18n.English: {
+		CodeOK:              "success",
+		CodeBadRequest:      "Invalid request",
+		CodeUnauthorized:    "Unauthorized",
+		CodeForbidden:       "Forbidden",
+		CodeNotFound:        "Not found",
+		CodeConflict:        "Conflict",
+		CodeTooManyRequests: "Too many requests, please try again later",
+		CodeInternal:        "The server is busy, please try again later",
+		CodeUnavailable:     "Service unavailable, please try again later",
+
+		CodeMissingParam: "Missing parameter: %s",
+		CodeInvalidParam: "Invalid parameter: %s",
+
+		CodeUserNotFound:       "User not found",
+		CodeInsufficientCoin:   "Not enough coins",
+		CodeNicknameRejected:   "The nickname contains inappropriate content, please change it",
+		CodeNicknameNotPending: "This nickname is not awaiting review",
+
+		CodeInviteCodeInvalid: "Invalid invite code",
+		CodeInviteCodeUsed:    "You have already used an invite code",
+		CodeInviteCodeOwn:     "You cannot use your own invite code",
+		CodeInviteCycle:       "You cannot use the invite code of someone you invited",
+		CodeInviteCodeTaken:   "This invite code is already taken",
+		CodeVanityCodeFormat:  "Custom invite codes must be %d-%d letters or digits",
+		CodeInviteNotNewUser:  "Invite codes are only for new users who have not generated a hairstyle yet",
+
+		CodeAlreadySignedIn:     "You have already signed in today",
+		CodeDateAlreadySignedIn: "You have already signed in on this date",
+		CodeNoRepairCard:        "Not enough make-up cards",
+		CodeRepairOutOfWindow:   "This date can no longer be made up",
+
+		CodeGenerationQueued: "In queue, please wait",
+		CodeGenerationFailed: "Failed to generate the hairstyle, please try again later",
+
+		CodeRecordNotFound:      "Record not found",
+		CodeRecordNotOwned:      "You cannot share someone else's record",
+		CodeRecordAlreadyShared: "This record has already been shared",
+		CodeContentNotFound:     "Content not found",
+		CodeContentRejected:     "The content did not pass review and cannot be shared",
+		CodeContentRemoved:      "The content has been removed",
+		CodeAlreadyReported:     "You have already reported this content",
+
+		CodeCollectionNotFound: "Collection not found",
+		CodeCollectionExists:   "A collection with this name already exists",
+		CodeFavoriteNotFound:   "This content is not in your favorites",
+		CodeCollectionLimit:    "You can create at most %d collections",
+
+		CodePayNotConfigured:    "Payment is not available",
+		CodeProductNotFound:     "Product not found",
+		CodeOrderNotFound:       "Order not found",
+		CodeOrderNotRefundable:  "The order is unpaid or already refunded",
+		CodeRefundCoinsSpent:    "The coins have been spent and cannot be refunded",
+		CodePrepayFailed:        "Failed to create the WeChat Pay order, please try again later",
+		CodeRefundFailed:        "Failed to request the refund, please try again later",
+		CodeAmountMismatch:      "The paid amount does not match the order amount",
+		CodeRefundNotFound:      "Refund not found",
+		CodeMembershipDowngrade: "Your current membership tier is higher, a lower tier cannot be purchased now",
+
+		CodeRedeemCodeInvalid:   "Invalid redeem code",
+		CodeRedeemNotStarted:    "This campaign has not started yet",
+		CodeRedeemExpired:       "This redeem code has expired",
+		CodeRedeemCodeExhausted: "This redeem code has been fully redeemed",
+		CodeRedeemUserLimit:     "You have reached the redemption limit for this campaign",
+		CodeCampaignNotFound:    "Campaign not found",
+		CodeRedeemCodeExists:    "This redeem code already exists",
+		CodeRedeemBatchSize:     "Each batch must contain 1-%d redeem codes",
+		CodeRedeemCodeFormat:    "Redeem codes must be %d-%d letters or digits",
+
+		CodeReminderNotConfigured: "The sign-in reminder template is not configured",
+	},
+}
+
+// Message 返回错误码在指定语言下的提示信息，args 填入文案中的参数
+func Message(locale i18n.Locale, code Code, args ...interface{}) string {
+	msg, ok := messages[locale][code]
+	if !ok {
+		msg, ok = messages[i18n.Default][code]
+	}
+	if !ok {
+		return fmt.Sprintf("error %d", code)
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
@@ -0,0 +1,42 @@
+package apperr
+
+import (
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/i18n"
+)
+
+// TestMessagesComplete 所有语言的文案表需要包含相同的错误码
+func TestMessagesComplete(t *testing.T) {
+	zh := messages[i18n.Chinese]
+	for locale, catalog := range messages {
+		for code := range zh {
+			if _, ok := catalog[code]; !ok {
+				t.Errorf("%s: missing message for code %d", locale, code)
+			}
+		}
+		for code := range catalog {
+			if _, ok := zh[code]; !ok {
+				t.Errorf("%s: code %d has no Chinese message", locale, code)
+			}
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		locale i18n.Locale
+		err    *Error
+		want   string
+	}{
+		{i18n.Chinese, ErrRefundNotFound, "退款单不存在"},
+		{i18n.English, ErrAmountMismatch, "The paid amount does not match the order amount"},
+		{i18n.English, ErrMissingParam.With("user_id"), "Missing parameter: user_id"},
+		{"fr", ErrRefundNotFound, "退款单不存在"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Message(tt.locale); got != tt.want {
+			t.Errorf("Message(%s, %d) = %q, want %q", tt.locale, tt.err.Code, got, tt.want)
+		}
+	}
+}
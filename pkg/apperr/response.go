@@ -0,0 +1,66 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// Response 统一的响应格式
+type Response struct {
+	Code    Code        `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// OK 返回成功响应，data 为 nil 时不输出 data 字段
+func OK(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusOK, Response{Code: CodeOK, Message: "success", Data: data})
+}
+
+// Respond 返回非错误的业务状态，例如排队中，提示信息按请求的语言输出
+func Respond(c *gin.Context, status int, code Code, data interface{}) {
+	c.JSON(status, Response{Code: code, Message: Message(i18n.FromContext(c), code), Data: data})
+}
+
+// Abort 记录错误并中止请求，错误响应由 Middleware 统一输出
+func Abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+// Mapping 将其他包的哨兵错误映射为业务错误
+type Mapping struct {
+	Err error
+	To  *Error
+}
+
+// From 将错误转换为业务错误，依次识别 *Error 和 mappings，都不匹配时为 ErrInternal
+func From(err error, mappings []Mapping) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	for _, m := range mappings {
+		if errors.Is(err, m.Err) {
+			return m.To.Wrap(err)
+		}
+	}
+	return ErrInternal.Wrap(err)
+}
+
+// Middleware 将 Abort 记录的错误输出为统一的错误响应，提示信息使用 i18n.FromContext 确定的语言
+// 未识别的错误只返回通用提示，原始错误保留在 c.Errors 中由请求日志记录
+func Middleware(mappings []Mapping) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		appErr := From(c.Errors.Last().Err, mappings)
+		c.JSON(appErr.Status, Response{Code: appErr.Code, Message: appErr.Message(i18n.FromContext(c))})
+	}
+}
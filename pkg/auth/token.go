@@ -0,0 +1,95 @@
+// Package auth 签发和校验用户登录凭证
+// 凭证为 HS256 签名的 JWT，微信登录成功后签发，之后的请求放在 Authorization: Bearer 请求头中
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+)
+
+// ErrInvalidToken 凭证格式错误、签名不匹配或已过期
+var ErrInvalidToken = errors.New("登录凭证无效")
+
+// defaultTTL 未配置 jwt.expire 时凭证的有效期
+const defaultTTL = 30 * 24 * time.Hour
+
+// header 固定的 JWT 头部
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// claims 凭证中保存的信息
+type claims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Signer 签发和校验凭证
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewSigner 根据配置创建签发器，未配置 jwt.secret 时返回 nil，表示不签发凭证
+func NewSigner(cfg config.JWTConfig) (*Signer, error) {
+	if cfg.Secret == "" {
+		return nil, nil
+	}
+	ttl := defaultTTL
+	if cfg.Expire != "" {
+		d, err := time.ParseDuration(cfg.Expire)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("jwt.expire 格式错误: %s", cfg.Expire)
+		}
+		ttl = d
+	}
+	return &Signer{secret: []byte(cfg.Secret), ttl: ttl}, nil
+}
+
+// Issue 为用户签发凭证，返回凭证和过期时间
+func (s *Signer) Issue(userID string, now time.Time) (string, time.Time, error) {
+	expiresAt := now.Add(s.ttl)
+	payload, err := json.Marshal(claims{Subject: userID, IssuedAt: now.Unix(), ExpiresAt: expiresAt.Unix()})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("生成登录凭证失败: %v", err)
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + s.sign(unsigned), expiresAt, nil
+}
+
+// Verify 校验凭证，返回凭证所属的用户ID
+func (s *Signer) Verify(token string, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != header {
+		return "", ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(s.sign(parts[0]+"."+parts[1]))) {
+		return "", ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil || c.Subject == "" {
+		return "", ErrInvalidToken
+	}
+	if now.Unix() >= c.ExpiresAt {
+		return "", ErrInvalidToken
+	}
+	return c.Subject, nil
+}
+
+func (s *Signer) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+)
+
+func TestNewSignerWithoutSecret(t *testing.T) {
+	s, err := NewSigner(config.JWTConfig{})
+	if err != nil || s != nil {
+		t.Fatalf("NewSigner() = %v, %v, want nil, nil", s, err)
+	}
+	if _, err := NewSigner(config.JWTConfig{Secret: "k", Expire: "soon"}); err == nil {
+		t.Fatal("NewSigner() with bad expire: want error")
+	}
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	s, err := NewSigner(config.JWTConfig{Secret: "secret", Expire: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	token, expiresAt, err := s.Issue("user-1", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("expiresAt = %v, want %v", expiresAt, now.Add(time.Hour))
+	}
+
+	userID, err := s.Verify(token, now.Add(59*time.Minute))
+	if err != nil || userID != "user-1" {
+		t.Fatalf("Verify() = %q, %v, want user-1", userID, err)
+	}
+
+	other, _ := NewSigner(config.JWTConfig{Secret: "other"})
+	parts := strings.Split(token, ".")
+	tests := []struct {
+		name   string
+		signer *Signer
+		token  string
+		now    time.Time
+	}{
+		{"expired", s, token, now.Add(time.Hour)},
+		{"wrong secret", other, token, now},
+		{"tampered payload", s, parts[0] + "." + parts[1] + "x." + parts[2], now},
+		{"missing signature", s, parts[0] + "." + parts[1], now},
+		{"empty", s, "", now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.signer.Verify(tt.token, tt.now); err != ErrInvalidToken {
+				t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
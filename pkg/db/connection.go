@@ -3,6 +3,7 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
 	"os"
 	"time"
 
@@ -19,14 +20,8 @@ func InitDB() (*sql.DB, error) {
 	password := os.Getenv("DB_PASSWORD")
 	dbname := os.Getenv("DB_NAME")
 
-	// 构建连接字符串
-	connStr := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		user, password, host, port, dbname,
-	)
-
 	// 连接数据库
-	db, err := sql.Open("mysql", connStr)
+	db, err := sql.Open("mysql", dataSourceName(user, password, host, port, dbname))
 	if err != nil {
 		logger.WithError(err).Error("打开数据库连接失败")
 		return nil, fmt.Errorf("连接数据库失败: %v", err)
@@ -45,3 +40,13 @@ func InitDB() (*sql.DB, error) {
 
 	return db, nil
 }
+
+// dataSourceName 构建连接字符串
+// 连接时区与会话时区都固定为 UTC，TIMESTAMP 字段按绝对时间读写，不随夏令时变化；
+// 签到日期、每日额度等业务日期由 signin.Today 按业务时区在 Go 中计算，以 2006-01-02 字符串作为参数传入
+func dataSourceName(user, password, host, port, dbname string) string {
+	return fmt.Sprintf(
+		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC&time_zone=%s",
+		user, password, host, port, dbname, url.QueryEscape("'+00:00'"),
+	)
+}
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDataSourceNameUsesUTC(t *testing.T) {
+	dsn := dataSourceName("u", "p", "127.0.0.1", "3306", "hair")
+	_, rawQuery, _ := strings.Cut(dsn, "?")
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 会话时区固定为 UTC，不能是启动时按业务时区算出的偏移，否则夏令时切换后会错位
+	if got := params.Get("loc"); got != "UTC" {
+		t.Errorf("loc = %q, want UTC", got)
+	}
+	if got := params.Get("time_zone"); got != "'+00:00'" {
+		t.Errorf("time_zone = %q, want '+00:00'", got)
+	}
+	if got := params.Get("parseTime"); got != "True" {
+		t.Errorf("parseTime = %q, want True", got)
+	}
+}
@@ -0,0 +1,342 @@
+// Package governor 限制所有实例同时调用上游生成接口的数量
+// 名额和排队信息保存在 MySQL 中，名额带租约，生成期间定期续期，实例异常退出后未释放的名额在租约过期后自动回收
+package governor
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/logger"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+)
+
+// ErrBusy 上游接口仍然限流，Run 收到后释放名额重新排队
+var ErrBusy = errors.New("上游生成接口繁忙")
+
+// releaseTimeout 释放名额的超时时间，请求已取消时也要释放
+const releaseTimeout = 5 * time.Second
+
+// maxBusyBackoffShift 上游连续限流时退避时间最多为 PollInterval 的 2^maxBusyBackoffShift 倍
+const maxBusyBackoffShift = 4
+
+// Request 一次生成的排队信息
+type Request struct {
+	// Ticket 上次排队超时返回的票据，带上后保留原来的排队位置，为空时重新排队
+	Ticket string
+	UserID string
+	// Priority 是否优先处理，优先的请求排在所有普通请求之前
+	Priority bool
+}
+
+// QueuedError 排队超过 MaxWait 仍未轮到，客户端应带上 Ticket 重试
+type QueuedError struct {
+	Ticket string
+	// Position 前面还在排队的请求数
+	Position int
+	// EstimatedWait 预计还要等待的时间
+	EstimatedWait time.Duration
+}
+
+func (e *QueuedError) Error() string {
+	return fmt.Sprintf("排队中，前面还有%d个请求", e.Position)
+}
+
+// Governor 基于 MySQL 的分布式信号量，名额保存在 generation_slot 表，排队信息保存在 generation_queue 表
+// 租约和票据的过期时间由实例按本机时钟计算，各实例需保持时钟同步
+type Governor struct {
+	db  *sql.DB
+	d   repo.Dialect
+	cfg config.GenerationConfig
+	now func() time.Time
+
+	mu         sync.Mutex
+	slotsReady bool          // 名额行是否已创建
+	avg        time.Duration // 最近生成耗时的移动平均，用于估算排队时间
+}
+
+// New 创建并发控制
+func New(db *sql.DB, d repo.Dialect, cfg config.GenerationConfig) *Governor {
+	return &Governor{db: db, d: d, cfg: cfg, now: time.Now, avg: cfg.AvgDuration}
+}
+
+// Run 排队获取名额后执行 fn，执行期间定期续期名额的租约，执行完立即释放名额
+// fn 返回 ErrBusy 时释放名额并重新排到队尾，按指数退避等待后再试；超过 MaxWait 仍未轮到时返回 *QueuedError
+func (g *Governor) Run(ctx context.Context, req Request, fn func() error) error {
+	deadline := time.Now().Add(g.cfg.MaxWait)
+
+	if err := g.ensureSlots(ctx); err != nil {
+		return err
+	}
+	if _, err := g.db.ExecContext(ctx, "DELETE FROM generation_queue WHERE expires_at < ?", g.timestamp(0)); err != nil {
+		return fmt.Errorf("清理过期排队失败: %v", err)
+	}
+
+	ticket := req.Ticket
+	if !validTicket(ticket) {
+		ticket = newToken()
+	}
+
+	busy := 0
+	for {
+		if err := g.join(ctx, ticket, req); err != nil {
+			return err
+		}
+
+		holder, position, err := g.tryAcquire(ctx, ticket)
+		if err != nil {
+			return err
+		}
+		if holder != "" {
+			start := time.Now()
+			stop := g.heartbeat(holder)
+			err = fn()
+			stop()
+			g.release(holder)
+			if !errors.Is(err, ErrBusy) {
+				if err == nil {
+					g.observe(time.Since(start))
+				}
+				return err
+			}
+
+			// 上游仍然限流，换一张票据排到队尾，退避一段时间再试，避免连续请求上游
+			busy++
+			ticket = newToken()
+			if err := g.join(ctx, ticket, req); err != nil {
+				return err
+			}
+			wait := g.backoff(busy)
+			if !time.Now().Add(wait).Before(deadline) {
+				position, err := g.position(ctx, ticket)
+				if err != nil {
+					return err
+				}
+				return &QueuedError{Ticket: ticket, Position: position, EstimatedWait: g.estimate(position) + wait}
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		if !time.Now().Before(deadline) {
+			return &QueuedError{Ticket: ticket, Position: position, EstimatedWait: g.estimate(position)}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(g.cfg.PollInterval):
+		}
+	}
+}
+
+// ensureSlots 创建 MaxConcurrency 个名额行，调小配置后多出的行不再使用
+func (g *Governor) ensureSlots(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.slotsReady {
+		return nil
+	}
+
+	for slot := 0; slot < g.cfg.MaxConcurrency; slot++ {
+		_, err := g.db.ExecContext(ctx, "INSERT INTO generation_slot (slot) VALUES (?)", slot)
+		if err != nil && !g.d.IsDuplicateKey(err, "") {
+			return fmt.Errorf("创建生成名额失败: %v", err)
+		}
+	}
+	g.slotsReady = true
+	return nil
+}
+
+// join 加入队列，已在队列中时只延长票据的有效期
+func (g *Governor) join(ctx context.Context, ticket string, req Request) error {
+	expiresAt := g.timestamp(g.cfg.QueueTTL)
+	_, err := g.db.ExecContext(ctx, `
+		INSERT INTO generation_queue (ticket, user_id, priority, expires_at)
+		VALUES (?, ?, ?, ?)`,
+		ticket, req.UserID, req.Priority, expiresAt)
+	if g.d.IsDuplicateKey(err, "ticket") {
+		_, err = g.db.ExecContext(ctx,
+			"UPDATE generation_queue SET expires_at = ? WHERE ticket = ?", expiresAt, ticket)
+	}
+	if err != nil {
+		return fmt.Errorf("加入排队失败: %v", err)
+	}
+	return nil
+}
+
+// tryAcquire 轮到票据时占用一个空闲名额，返回租约持有者标识；未轮到时返回排队位置
+// 前面排队的请求数小于空闲名额数即视为轮到，名额被其他实例抢先占用时下次再试
+func (g *Governor) tryAcquire(ctx context.Context, ticket string) (string, int, error) {
+	position, err := g.position(ctx, ticket)
+	if err != nil {
+		return "", 0, err
+	}
+
+	now := g.timestamp(0)
+	var free int
+	err = g.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM generation_slot WHERE slot < ? AND (holder IS NULL OR expires_at < ?)",
+		g.cfg.MaxConcurrency, now).Scan(&free)
+	if err != nil {
+		return "", 0, fmt.Errorf("查询空闲名额失败: %v", err)
+	}
+	if position >= free {
+		return "", position, nil
+	}
+
+	var slot int
+	err = g.db.QueryRowContext(ctx, `
+		SELECT slot FROM generation_slot
+		WHERE slot < ? AND (holder IS NULL OR expires_at < ?)
+		ORDER BY slot LIMIT 1`,
+		g.cfg.MaxConcurrency, now).Scan(&slot)
+	if err == sql.ErrNoRows {
+		return "", position, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("查询空闲名额失败: %v", err)
+	}
+
+	// 按查询时的条件占用，名额在查询后被其他实例占用时不会覆盖
+	holder := newToken()
+	result, err := g.db.ExecContext(ctx, `
+		UPDATE generation_slot SET holder = ?, expires_at = ?
+		WHERE slot = ? AND (holder IS NULL OR expires_at < ?)`,
+		holder, g.timestamp(g.cfg.LeaseTTL), slot, now)
+	if err != nil {
+		return "", 0, fmt.Errorf("占用生成名额失败: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return "", position, nil
+	}
+
+	if _, err := g.db.ExecContext(ctx, "DELETE FROM generation_queue WHERE ticket = ?", ticket); err != nil {
+		g.release(holder)
+		return "", 0, fmt.Errorf("退出排队失败: %v", err)
+	}
+	return holder, 0, nil
+}
+
+// position 查询排在票据前面的请求数
+func (g *Governor) position(ctx context.Context, ticket string) (int, error) {
+	var position int
+	err := g.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM generation_queue q
+		JOIN generation_queue me ON me.ticket = ?
+		WHERE q.expires_at >= ?
+		AND (q.priority > me.priority OR (q.priority = me.priority AND q.id < me.id))`,
+		ticket, g.timestamp(0)).Scan(&position)
+	if err != nil {
+		return 0, fmt.Errorf("查询排队位置失败: %v", err)
+	}
+	return position, nil
+}
+
+// backoff 上游第 busy 次连续限流后的等待时间，从 PollInterval 开始每次翻倍
+func (g *Governor) backoff(busy int) time.Duration {
+	shift := min(busy-1, maxBusyBackoffShift)
+	return g.cfg.PollInterval << max(shift, 0)
+}
+
+// heartbeat 每隔三分之一租约时长续期一次名额，避免生成耗时超过 LeaseTTL 时名额被其他请求回收，返回停止续期的函数
+func (g *Governor) heartbeat(holder string) func() {
+	interval := g.cfg.LeaseTTL / 3
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				g.renew(holder)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// renew 续期名额的租约，失败时等下次续期，租约已过期被回收时只记录日志
+func (g *Governor) renew(holder string) {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	result, err := g.db.ExecContext(ctx,
+		"UPDATE generation_slot SET expires_at = ? WHERE holder = ?", g.timestamp(g.cfg.LeaseTTL), holder)
+	if err != nil {
+		logger.WithError(err).Warn("续期生成名额失败")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		logger.Warn("生成名额的租约已过期并被回收")
+	}
+}
+
+// release 释放名额，失败时名额在租约过期后回收
+func (g *Governor) release(holder string) {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	_, err := g.db.ExecContext(ctx,
+		"UPDATE generation_slot SET holder = NULL, expires_at = NULL WHERE holder = ?", holder)
+	if err != nil {
+		logger.WithError(err).Warn("释放生成名额失败")
+	}
+}
+
+// observe 记录一次生成的耗时
+func (g *Governor) observe(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.avg = (g.avg*4 + d) / 5
+}
+
+// estimate 估算排在 position 之后还要等待的时间，每轮可同时处理 MaxConcurrency 个请求
+func (g *Governor) estimate(position int) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	rounds := 1
+	if g.cfg.MaxConcurrency > 0 {
+		rounds = position/g.cfg.MaxConcurrency + 1
+	}
+	return time.Duration(rounds) * g.avg
+}
+
+// timestamp 返回 d 之后的时间，截断到毫秒与 DATETIME(3) 的精度一致
+func (g *Governor) timestamp(d time.Duration) time.Time {
+	return g.now().Add(d).UTC().Truncate(time.Millisecond)
+}
+
+// newToken 生成随机的票据或租约持有者标识
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validTicket 客户端传入的票据必须是 newToken 生成的格式
+func validTicket(ticket string) bool {
+	if len(ticket) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(ticket)
+	return err == nil
+}
@@ -0,0 +1,192 @@
+package governor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo/sqlite"
+)
+
+// testConfig MaxWait 为0，未轮到时立即返回排队位置
+var testConfig = config.GenerationConfig{
+	MaxConcurrency: 1,
+	LeaseTTL:       2 * time.Minute,
+	QueueTTL:       10 * time.Minute,
+	PollInterval:   10 * time.Millisecond,
+	AvgDuration:    10 * time.Second,
+}
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// acquire 排队并占用一个名额，返回租约持有者标识
+func acquire(t *testing.T, g *Governor) string {
+	t.Helper()
+	ctx := context.Background()
+	if err := g.ensureSlots(ctx); err != nil {
+		t.Fatal(err)
+	}
+	ticket := newToken()
+	if err := g.join(ctx, ticket, Request{UserID: "holder"}); err != nil {
+		t.Fatal(err)
+	}
+	holder, position, err := g.tryAcquire(ctx, ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if holder == "" {
+		t.Fatalf("tryAcquire() position = %d, want a free slot", position)
+	}
+	return holder
+}
+
+// run 执行一次 Run，返回 fn 是否执行以及排队信息
+func run(t *testing.T, g *Governor, req Request) (bool, *QueuedError) {
+	t.Helper()
+	ran := false
+	err := g.Run(context.Background(), req, func() error {
+		ran = true
+		return nil
+	})
+	var queued *QueuedError
+	if err != nil && !errors.As(err, &queued) {
+		t.Fatal(err)
+	}
+	return ran, queued
+}
+
+func TestAcquireAtCap(t *testing.T) {
+	db := openDB(t)
+	cfg := testConfig
+	cfg.MaxConcurrency = 2
+	g := New(db, sqlite.Dialect, cfg)
+
+	first := acquire(t, g)
+	acquire(t, g)
+	ran, queued := run(t, g, Request{UserID: "u1"})
+	if ran || queued == nil {
+		t.Fatalf("Run() at cap ran = %v, queued = %v, want queued", ran, queued)
+	}
+
+	// 释放一个名额后轮到排队的请求；其他实例重复创建名额行不报错
+	g.release(first)
+	other := New(db, sqlite.Dialect, cfg)
+	if ran, queued := run(t, other, Request{Ticket: queued.Ticket, UserID: "u1"}); !ran {
+		t.Fatalf("Run() after release queued = %+v, want ran", queued)
+	}
+	if ran, queued := run(t, other, Request{UserID: "u2"}); !ran {
+		t.Fatalf("Run() after the queue is empty queued = %+v, want ran", queued)
+	}
+}
+
+func TestQueuePositionAndPriority(t *testing.T) {
+	db := openDB(t)
+	g := New(db, sqlite.Dialect, testConfig)
+	holder := acquire(t, g)
+
+	tests := []struct {
+		name         string
+		req          Request
+		wantPosition int
+		wantWait     time.Duration
+	}{
+		{"first", Request{UserID: "u1"}, 0, 10 * time.Second},
+		{"second", Request{UserID: "u2"}, 1, 20 * time.Second},
+		// 优先的请求排在所有普通请求之前
+		{"priority", Request{UserID: "u3", Priority: true}, 0, 10 * time.Second},
+	}
+	tickets := make(map[string]string)
+	for _, tt := range tests {
+		_, queued := run(t, g, tt.req)
+		if queued == nil {
+			t.Fatalf("%s: Run() ran, want queued", tt.name)
+		}
+		if queued.Position != tt.wantPosition || queued.EstimatedWait != tt.wantWait {
+			t.Errorf("%s: queued = %+v, want position %d wait %v", tt.name, queued, tt.wantPosition, tt.wantWait)
+		}
+		tickets[tt.name] = queued.Ticket
+	}
+
+	position, err := g.position(context.Background(), tickets["second"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if position != 2 {
+		t.Errorf("second position = %d, want 2", position)
+	}
+
+	// 名额释放后只有排在最前面的优先请求能拿到，带票据重试的普通请求保留原位置继续排队
+	g.release(holder)
+	if ran, queued := run(t, g, Request{Ticket: tickets["second"], UserID: "u2"}); ran || queued.Position != 2 {
+		t.Errorf("second retry ran = %v, queued = %+v, want position 2", ran, queued)
+	}
+	if ran, _ := run(t, g, Request{Ticket: tickets["priority"], UserID: "u3", Priority: true}); !ran {
+		t.Error("priority retry queued, want ran")
+	}
+	if ran, _ := run(t, g, Request{Ticket: tickets["first"], UserID: "u1"}); !ran {
+		t.Error("first retry queued, want ran")
+	}
+}
+
+func TestReclaimExpiredLease(t *testing.T) {
+	db := openDB(t)
+	g := New(db, sqlite.Dialect, testConfig)
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	g.now = func() time.Time { return now }
+
+	// 持有名额的实例异常退出没有释放，租约过期前其他请求只能排队
+	acquire(t, g)
+	_, queued := run(t, g, Request{UserID: "u1"})
+	if queued == nil {
+		t.Fatal("Run() ran before the lease expired, want queued")
+	}
+
+	now = now.Add(testConfig.LeaseTTL + time.Millisecond)
+	if ran, _ := run(t, g, Request{Ticket: queued.Ticket, UserID: "u1"}); !ran {
+		t.Error("Run() queued after the lease expired, want ran")
+	}
+}
+
+func TestHeartbeatRenewsLease(t *testing.T) {
+	db := openDB(t)
+	cfg := testConfig
+	cfg.LeaseTTL = 90 * time.Millisecond
+	g := New(db, sqlite.Dialect, cfg)
+
+	// 生成耗时超过租约时长，续期后名额仍然有效，不会被其他请求回收
+	err := g.Run(context.Background(), Request{UserID: "u1"}, func() error {
+		time.Sleep(3 * cfg.LeaseTTL)
+		var held int
+		err := db.QueryRow("SELECT COUNT(*) FROM generation_slot WHERE holder IS NOT NULL AND expires_at > ?",
+			g.timestamp(0)).Scan(&held)
+		if err != nil {
+			return err
+		}
+		if held != 1 {
+			t.Errorf("held slots = %d, want 1", held)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var held int
+	if err := db.QueryRow("SELECT COUNT(*) FROM generation_slot WHERE holder IS NOT NULL").Scan(&held); err != nil {
+		t.Fatal(err)
+	}
+	if held != 0 {
+		t.Errorf("held slots after Run = %d, want 0", held)
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/signin"
+	"github.com/gin-gonic/gin"
+)
+
+// expireCoinBatchSize 过期任务每批处理的用户数
+const expireCoinBatchSize = 100
+
+// expireUserCoins 作废用户已过期的促销coin，读取余额前调用，保证展示和校验的余额不含过期coin
+// 过期批次不依赖定时任务，在用户下次读取余额或扣除coin时作废
+func (s *Services) expireUserCoins(userID string) error {
+	return repo.WithTx(s.DB, func(tx repo.Querier) error {
+		_, err := s.Repos.Ledger.ExpireLots(tx, userID, signin.Today())
+		return err
+	})
+}
+
+// HandleExpireCoins 处理批量作废过期促销coin请求，由管理员按需调用，用于清理长期未访问用户的过期批次
+func (s *Services) HandleExpireCoins(c *gin.Context) {
+	dbConn := s.DB
+	today := signin.Today()
+
+	var users, coins int
+	for {
+		userIDs, err := s.Repos.Ledger.ExpiredUsers(dbConn, today, expireCoinBatchSize)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+
+		// 每个用户在单独的事务中处理，避免长时间持有大量行锁
+		for _, userID := range userIDs {
+			err := repo.WithTx(dbConn, func(tx repo.Querier) error {
+				expired, err := s.Repos.Ledger.ExpireLots(tx, userID, today)
+				coins += expired
+				return err
+			})
+			if err != nil {
+				apperr.Abort(c, err)
+				return
+			}
+		}
+		users += len(userIDs)
+		if len(userIDs) < expireCoinBatchSize {
+			break
+		}
+	}
+
+	apperr.OK(c, gin.H{
+		"users": users,
+		"coins": coins,
+	})
+}
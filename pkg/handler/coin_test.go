@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/signin"
+)
+
+func TestHandleGetUserInfoExpiresCoins(t *testing.T) {
+	s := newTestServices(t)
+	createUser(t, s, "u1", 0)
+
+	// 昨天过期的批次在读取用户信息时作废，今天过期的批次仍然有效
+	today := signin.Today()
+	expired, expiring := today.AddDays(-1), today
+	if _, err := s.Repos.Ledger.Change(s.DB, "u1", 30, model.CoinSourceRedeem, "old", &expired); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Repos.Ledger.Change(s.DB, "u1", 20, model.CoinSourceRedeem, "new", &expiring); err != nil {
+		t.Fatal(err)
+	}
+
+	status, resp := serve(t, s.HandleGetUserInfo, http.MethodGet, "?user_id=u1", nil)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, code = %d", status, resp.Code)
+	}
+	var info model.GetUserInfoResponse
+	if err := json.Unmarshal(resp.Data, &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Coin != 20 || info.ExpiringCoin == nil || info.ExpiringCoin.Amount != 20 {
+		t.Errorf("user info coin = %d, expiring = %+v, want 20 coins expiring today", info.Coin, info.ExpiringCoin)
+	}
+
+	balance, err := s.Repos.Ledger.Balance(s.DB, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance != 20 {
+		t.Errorf("balance = %d, want 20", balance)
+	}
+}
@@ -0,0 +1,12 @@
+package handler
+
+import (
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/wxpay"
+)
+
+// ErrorMappings 其他包的哨兵错误对应的业务错误，由 apperr.Middleware 在输出响应时转换
+// 数据层直接返回 apperr 定义的业务错误，无需在此映射
+var ErrorMappings = []apperr.Mapping{
+	{Err: wxpay.ErrNotConfigured, To: apperr.ErrPayNotConfigured},
+}
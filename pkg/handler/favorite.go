@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleAddFavorite 处理收藏请求
+func (s *Services) HandleAddFavorite(c *gin.Context) {
+	var req model.AddFavoriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	favorite := &model.Favorite{
+		UserID:       req.UserID,
+		ContentID:    req.ContentID,
+		CollectionID: req.CollectionID,
+	}
+
+	dbConn := s.DB
+	if err := s.Repos.Favorites.Add(dbConn, favorite); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, gin.H{
+		"favorite_id":   favorite.ID,
+		"collection_id": favorite.CollectionID,
+		"is_favorited":  true,
+	})
+}
+
+// HandleRemoveFavorite 处理取消收藏请求
+func (s *Services) HandleRemoveFavorite(c *gin.Context) {
+	var req model.RemoveFavoriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	dbConn := s.DB
+	if err := s.Repos.Favorites.Remove(dbConn, req.UserID, req.ContentID); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, gin.H{
+		"is_favorited": false,
+	})
+}
+
+// HandleGetFavorites 处理获取收藏列表请求
+func (s *Services) HandleGetFavorites(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		apperr.Abort(c, apperr.ErrMissingParam.With("user_id"))
+		return
+	}
+
+	// 获取筛选和分页参数，collection_id 为空时返回全部收藏
+	collectionID := int64(-1)
+	cursor := int64(0)
+	pageSize := 10
+	if collectionIDStr := c.Query("collection_id"); collectionIDStr != "" {
+		id, err := strconv.ParseInt(collectionIDStr, 10, 64)
+		if err != nil {
+			apperr.Abort(c, apperr.ErrInvalidParam.With("collection_id"))
+			return
+		}
+		collectionID = id
+	}
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		if c, err := strconv.ParseInt(cursorStr, 10, 64); err == nil {
+			cursor = c
+		}
+	}
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
+			pageSize = ps
+		}
+	}
+
+	dbConn := s.DB
+	response, err := s.Repos.Favorites.List(dbConn, userID, collectionID, cursor, pageSize)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, response)
+}
+
+// HandleCreateCollection 处理创建收藏夹请求
+func (s *Services) HandleCreateCollection(c *gin.Context) {
+	var req model.CreateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > 32 {
+		apperr.Abort(c, apperr.ErrInvalidParam.With("name"))
+		return
+	}
+
+	collection := &model.FavoriteCollection{
+		UserID: req.UserID,
+		Name:   name,
+	}
+
+	dbConn := s.DB
+	if err := s.Repos.Favorites.CreateCollection(dbConn, collection); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, collection)
+}
+
+// HandleGetCollections 处理获取收藏夹列表请求
+func (s *Services) HandleGetCollections(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		apperr.Abort(c, apperr.ErrMissingParam.With("user_id"))
+		return
+	}
+
+	dbConn := s.DB
+	collections, err := s.Repos.Favorites.Collections(dbConn, userID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, collections)
+}
+
+// HandleDeleteCollection 处理删除收藏夹请求
+func (s *Services) HandleDeleteCollection(c *gin.Context) {
+	var req model.DeleteCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	dbConn := s.DB
+	err := repo.WithTx(dbConn, func(tx repo.Querier) error {
+		return s.Repos.Favorites.DeleteCollection(tx, req.UserID, req.CollectionID)
+	})
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, nil)
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleFavoriteToggle(t *testing.T) {
+	s := newTestServices(t)
+	record := &model.HairStyleRecord{UserID: "author", ImageURL: "https://img/1", Prompt: "短发"}
+	if err := s.Repos.Records.Save(s.DB, record); err != nil {
+		t.Fatal(err)
+	}
+	content := &model.SquareContent{UserID: "author", RecordID: record.ID}
+	if err := s.Repos.Square.Share(s.DB, content); err != nil {
+		t.Fatal(err)
+	}
+
+	// 收藏后出现在列表中，取消后列表为空，重复取消返回未收藏
+	body := map[string]interface{}{"user_id": "u1", "content_id": content.ID}
+	tests := []struct {
+		name       string
+		h          gin.HandlerFunc
+		wantStatus int
+		wantCode   apperr.Code
+		wantListed bool
+	}{
+		{"add", s.HandleAddFavorite, http.StatusOK, apperr.CodeOK, true},
+		{"add again", s.HandleAddFavorite, http.StatusOK, apperr.CodeOK, true},
+		{"remove", s.HandleRemoveFavorite, http.StatusOK, apperr.CodeOK, false},
+		{"remove again", s.HandleRemoveFavorite, http.StatusNotFound, apperr.CodeFavoriteNotFound, false},
+	}
+	for _, tt := range tests {
+		status, resp := serve(t, tt.h, http.MethodPost, "", body)
+		if status != tt.wantStatus || resp.Code != tt.wantCode {
+			t.Fatalf("%s: status = %d, code = %d, want %d, %d", tt.name, status, resp.Code, tt.wantStatus, tt.wantCode)
+		}
+
+		status, resp = serve(t, s.HandleGetFavorites, http.MethodGet, "?user_id=u1", nil)
+		if status != http.StatusOK {
+			t.Fatalf("%s: list status = %d, code = %d", tt.name, status, resp.Code)
+		}
+		var list model.FavoriteListResponse
+		if err := json.Unmarshal(resp.Data, &list); err != nil {
+			t.Fatal(err)
+		}
+		if listed := len(list.Records) == 1 && list.Records[0].Content.ID == content.ID; listed != tt.wantListed {
+			t.Errorf("%s: records = %+v, want listed %v", tt.name, list.Records, tt.wantListed)
+		}
+	}
+}
+
+func TestHandleGetFavoritesInvalidCollection(t *testing.T) {
+	s := newTestServices(t)
+
+	tests := []struct {
+		target     string
+		wantStatus int
+	}{
+		{"", http.StatusBadRequest},
+		{"?user_id=u1&collection_id=abc", http.StatusBadRequest},
+		{"?user_id=u1&collection_id=0", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if status, resp := serve(t, s.HandleGetFavorites, http.MethodGet, tt.target, nil); status != tt.wantStatus {
+			t.Errorf("%q: status = %d, code = %d, want %d", tt.target, status, resp.Code, tt.wantStatus)
+		}
+	}
+}
@@ -1,17 +1,22 @@
 package handler
 
 import (
-	"database/sql"
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
-	"os"
-	"strings"
+	"strconv"
+	"time"
 
-	"github.com/MRsummer/ChangeHairStyle/pkg/cos"
-	"github.com/MRsummer/ChangeHairStyle/pkg/db"
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/governor"
 	"github.com/MRsummer/ChangeHairStyle/pkg/logger"
 	"github.com/MRsummer/ChangeHairStyle/pkg/middleware"
 	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/notify"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
 	"github.com/MRsummer/ChangeHairStyle/pkg/volcengine"
 	"github.com/gin-gonic/gin"
 )
@@ -22,6 +27,8 @@ type HairStyleRequest struct {
 	Base64Image string `json:"base64_image"`
 	Prompt      string `json:"prompt" binding:"required"`
 	UserID      string `json:"user_id" binding:"required"`
+	// QueueTicket 上次排队超时返回的票据，带上后保留排队位置
+	QueueTicket string `json:"queue_ticket"`
 }
 
 // HairStyleResponse 换发型响应
@@ -35,109 +42,83 @@ type HairStyleResponse struct {
 }
 
 // HandleHairStyle 处理换发型请求
-func HandleHairStyle(c *gin.Context) {
+func (s *Services) HandleHairStyle(c *gin.Context) {
 	var req HairStyleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "请求参数错误",
-		})
+		apperr.Abort(c, apperr.ErrBadRequest)
 		return
 	}
 
-	// 检查coin是否足够
-	dbConn := c.MustGet("db").(*sql.DB)
-	enough, err := db.CheckCoin(dbConn, req.UserID, 20)
+	// 计算本次生成的价格，使用会员免费额度时无需检查coin
+	dbConn := s.DB
+	quote, err := s.quoteGeneration(req.UserID, config.Now())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": err.Error(),
-		})
+		apperr.Abort(c, err)
 		return
 	}
+	enough := quote.Free
 	if !enough {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    400,
-			"message": "造型币不足",
-		})
+		if err := s.expireUserCoins(req.UserID); err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		balance, err := s.Repos.Ledger.Balance(dbConn, req.UserID)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		enough = balance >= quote.Price
+	}
+	if !enough {
+		apperr.Abort(c, apperr.ErrInsufficientCoin)
 		return
 	}
 
-	// 调用火山引擎API
-	client := volcengine.NewClient(
-		os.Getenv("VOLCENGINE_ACCESS_KEY_ID"),
-		os.Getenv("VOLCENGINE_SECRET_ACCESS_KEY"),
-	)
+	if req.ImageURL == "" && req.Base64Image == "" {
+		apperr.Abort(c, apperr.ErrMissingParam.With("image_url/base64_image"))
+		return
+	}
 
+	// 排队获取上游生成名额后调用火山引擎API，所有实例共享并发上限，会员按报价中的优先级排队
 	var imageURL string
-	if req.ImageURL != "" {
-		// 直接使用图片URL调用API
-		imageURL, err = client.GenerateHairStyle(req.ImageURL, req.Prompt)
-		if err != nil {
-			// 检查错误信息是否包含429
-			errorMsg := err.Error()
-			if strings.Contains(errorMsg, "429") {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"code":    500,
-					"message": "当前使用人数较多、请过5秒后尝试",
-				})
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"code":    500,
-					"message": fmt.Sprintf("调用火山引擎API失败: %v", err),
-				})
-			}
-			return
+	err = s.Governor.Run(c.Request.Context(), governor.Request{
+		Ticket:   req.QueueTicket,
+		UserID:   req.UserID,
+		Priority: quote.Priority,
+	}, func() error {
+		var genErr error
+		if req.ImageURL != "" {
+			// 直接使用图片URL调用API
+			imageURL, genErr = s.Generator.GenerateHairStyle(req.ImageURL, req.Prompt)
+		} else {
+			// 使用base64图片数据调用API
+			imageURL, genErr = s.Generator.GenerateHairStyleWithBase64(req.Base64Image, req.Prompt)
 		}
-	} else if req.Base64Image != "" {
-		// 使用base64图片数据调用API
-		imageURL, err = client.GenerateHairStyleWithBase64(req.Base64Image, req.Prompt)
-		if err != nil {
-			// 检查错误信息是否包含429
-			errorMsg := err.Error()
-			if strings.Contains(errorMsg, "429") {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"code":    500,
-					"message": "当前使用人数较多、请过5秒后尝试",
-				})
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"code":    500,
-					"message": fmt.Sprintf("调用火山引擎API失败: %v", err),
-				})
-			}
-			return
+		// 上游限流时重新排队
+		if errors.Is(genErr, volcengine.ErrRateLimited) {
+			return governor.ErrBusy
 		}
-	} else {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "请提供图片URL或Base64数据",
+		return genErr
+	})
+	var queued *governor.QueuedError
+	if errors.As(err, &queued) {
+		// 排队超时，返回排队位置，客户端带上 queue_ticket 重试可保留位置
+		apperr.Respond(c, http.StatusAccepted, apperr.CodeGenerationQueued, gin.H{
+			"queue_ticket":   queued.Ticket,
+			"queue_position": queued.Position,
+			"estimated_wait": int(math.Ceil(queued.EstimatedWait.Seconds())),
 		})
 		return
 	}
-
-	// 创建腾讯云 COS 客户端
-	cosClient, err := cos.NewClient(
-		os.Getenv("COS_SECRET_ID"),
-		os.Getenv("COS_SECRET_KEY"),
-		os.Getenv("COS_BUCKET"),
-		os.Getenv("COS_REGION"),
-	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": fmt.Sprintf("创建腾讯云 COS 客户端失败: %v", err),
-		})
+		apperr.Abort(c, apperr.ErrGenerationFailed.Wrap(err))
 		return
 	}
 
 	// 上传到腾讯云 COS
-	permanentURL, err := cosClient.FetchImage(imageURL)
+	permanentURL, err := s.Storage.FetchImage(imageURL)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": fmt.Sprintf("上传到腾讯云 COS 失败: %v", err),
-		})
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -147,16 +128,55 @@ func HandleHairStyle(c *gin.Context) {
 		ImageURL: permanentURL,
 		Prompt:   req.Prompt,
 	}
-	if err := db.SaveHairStyleRecord(dbConn, record); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": fmt.Sprintf("保存生成记录失败: %v", err),
-		})
+	if err := s.Repos.Records.Save(dbConn, record); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
-	// 扣除coin
-	if err := db.DeductCoin(dbConn, req.UserID, 20); err != nil {
+	// 发送生成完成通知，用户生成期间离开页面也能找到结果
+	err = repo.WithTx(dbConn, func(tx repo.Querier) error {
+		return s.Repos.Notifications.Create(tx, req.UserID, model.NotificationTypeGenerationDone, record.ID, "")
+	})
+	if err != nil {
+		logger.WithContext(map[string]interface{}{
+			"request_id": middleware.GetRequestID(c),
+			"user_id":    req.UserID,
+			"record_id":  record.ID,
+		}).WithError(err).Warn("发送生成完成通知失败")
+	}
+
+	// 被邀请人完成生成后发放邀请奖励，没有待发放的邀请关系时不做处理
+	now := config.Now()
+	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	err = repo.WithTx(dbConn, func(tx repo.Querier) error {
+		return s.Repos.Invites.GrantRewards(tx, req.UserID, todayStart, s.Config.Invite)
+	})
+	if err != nil {
+		logger.WithContext(map[string]interface{}{
+			"request_id": middleware.GetRequestID(c),
+			"user_id":    req.UserID,
+		}).WithError(err).Warn("发放邀请奖励失败")
+	}
+
+	// 发送订阅消息，用户未授权时不发送
+	notifier := s.Notifier
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	_, err = notifier.Notify(ctx, req.UserID, model.SubscribeSceneGenerationDone, "", map[string]string{
+		"thing1": notify.Truncate(req.Prompt, 20),
+		"time2":  now.Format("2006-01-02 15:04"),
+	})
+	cancel()
+	if err != nil {
+		logger.WithContext(map[string]interface{}{
+			"request_id": middleware.GetRequestID(c),
+			"user_id":    req.UserID,
+			"record_id":  record.ID,
+		}).WithError(err).Warn("发送生成完成订阅消息失败")
+	}
+
+	// 扣除coin或会员免费次数
+	quote, err = s.chargeGeneration(req.UserID, quote, now, strconv.FormatInt(record.ID, 10))
+	if err != nil {
 		// 记录保存成功但扣除coin失败，记录错误但不影响返回结果
 		requestID := middleware.GetRequestID(c)
 
@@ -168,24 +188,19 @@ func HandleHairStyle(c *gin.Context) {
 	}
 
 	// 返回结果
-	c.JSON(http.StatusOK, gin.H{
-		"code":    0,
-		"message": "success",
-		"data": gin.H{
-			"image_url": permanentURL,
-			"record_id": record.ID,
-		},
+	apperr.OK(c, gin.H{
+		"image_url": permanentURL,
+		"record_id": record.ID,
+		"cost":      quote.Price,
+		"free":      quote.Free,
 	})
 }
 
 // HandleGetRecords 获取用户的生成记录
-func HandleGetRecords(c *gin.Context) {
+func (s *Services) HandleGetRecords(c *gin.Context) {
 	userID := c.Query("user_id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "请提供用户ID",
-		})
+		apperr.Abort(c, apperr.ErrMissingParam.With("user_id"))
 		return
 	}
 
@@ -194,38 +209,25 @@ func HandleGetRecords(c *gin.Context) {
 	pageSize := 10
 	if pageStr := c.Query("page"); pageStr != "" {
 		if _, err := fmt.Sscanf(pageStr, "%d", &page); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    400,
-				"message": "页码参数错误",
-			})
+			apperr.Abort(c, apperr.ErrInvalidParam.With("page"))
 			return
 		}
 	}
 	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
 		if _, err := fmt.Sscanf(pageSizeStr, "%d", &pageSize); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    400,
-				"message": "每页数量参数错误",
-			})
+			apperr.Abort(c, apperr.ErrInvalidParam.With("page_size"))
 			return
 		}
 	}
 
 	// 获取记录
-	dbConn := c.MustGet("db").(*sql.DB)
-	response, err := db.GetHairStyleRecords(dbConn, userID, page, pageSize)
+	dbConn := s.DB
+	response, err := s.Repos.Records.List(dbConn, userID, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": fmt.Sprintf("获取记录失败: %v", err),
-		})
+		apperr.Abort(c, err)
 		return
 	}
 
 	// 返回结果
-	c.JSON(http.StatusOK, gin.H{
-		"code":    0,
-		"message": "success",
-		"data":    response,
-	})
+	apperr.OK(c, response)
 }
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/middleware"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/moderation"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo/sqlite"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestServices 创建使用内存 SQLite 的 Services，昵称中包含 "违禁" 时拒绝，包含 "待审" 时需要人工审核
+func newTestServices(t *testing.T) *Services {
+	t.Helper()
+	conn, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	text, err := moderation.NewKeywordFilter([]string{"违禁"}, []string{"待审"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Services{
+		DB:        conn,
+		Repos:     sqlite.New(),
+		Config:    &config.Config{},
+		Moderator: moderation.New(text, &moderation.StubImageModerator{Result: moderation.ResultPass}),
+	}
+}
+
+// createUser 创建测试用户
+func createUser(t *testing.T, s *Services, userID string, coin int) {
+	t.Helper()
+	if err := s.Repos.Users.Create(s.DB, &model.UserInfo{UserID: userID, Coin: coin, InviteCode: "C" + userID}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// envelope 统一响应格式，data 按接口再解析
+type envelope struct {
+	Code apperr.Code     `json:"code"`
+	Data json.RawMessage `json:"data"`
+}
+
+// serve 以 method 和 target 请求单个处理函数，body 不为 nil 时序列化为 JSON 请求体
+func serve(t *testing.T, h gin.HandlerFunc, method, target string, body interface{}) (int, envelope) {
+	t.Helper()
+	return serveAs(t, "", h, method, target, body)
+}
+
+// serveAs 以已验证身份的 userID 请求单个处理函数，userID 为空时按匿名请求处理
+func serveAs(t *testing.T, userID string, h gin.HandlerFunc, method, target string, body interface{}) (int, envelope) {
+	t.Helper()
+	r := gin.New()
+	r.Use(apperr.Middleware(ErrorMappings))
+	if userID != "" {
+		r.Use(func(c *gin.Context) {
+			middleware.SetVerifiedUserID(c, userID)
+		})
+	}
+	r.Handle(method, "/", h)
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, "/"+target, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+func TestHandleGetRecords(t *testing.T) {
+	s := newTestServices(t)
+	for _, prompt := range []string{"短发", "卷发", "寸头"} {
+		if err := s.Repos.Records.Save(s.DB, &model.HairStyleRecord{UserID: "u1", ImageURL: "https://img/" + prompt, Prompt: prompt}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantCode   apperr.Code
+		wantCount  int
+	}{
+		{"first page", "?user_id=u1&page=1&page_size=2", http.StatusOK, apperr.CodeOK, 2},
+		{"last page", "?user_id=u1&page=2&page_size=2", http.StatusOK, apperr.CodeOK, 1},
+		{"other user", "?user_id=u2", http.StatusOK, apperr.CodeOK, 0},
+		{"missing user", "", http.StatusBadRequest, apperr.CodeMissingParam, 0},
+		{"invalid page", "?user_id=u1&page=x", http.StatusBadRequest, apperr.CodeInvalidParam, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, s.HandleGetRecords, http.MethodGet, tt.target, nil)
+			if status != tt.wantStatus || resp.Code != tt.wantCode {
+				t.Fatalf("status = %d, code = %d, want %d, %d", status, resp.Code, tt.wantStatus, tt.wantCode)
+			}
+			if tt.wantCode != apperr.CodeOK {
+				return
+			}
+			var data model.RecordResponse
+			if err := json.Unmarshal(resp.Data, &data); err != nil {
+				t.Fatal(err)
+			}
+			if len(data.Records) != tt.wantCount {
+				t.Errorf("records = %d, want %d", len(data.Records), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestHandleUpdateAndGetUserInfo(t *testing.T) {
+	s := newTestServices(t)
+	createUser(t, s, "u1", 60)
+
+	tests := []struct {
+		name         string
+		req          model.UpdateUserInfoRequest
+		wantStatus   int
+		wantCode     apperr.Code
+		wantNickname string
+		wantLanguage string
+	}{
+		{"update", model.UpdateUserInfoRequest{UserID: "u1", Nickname: "小明", Language: "en-US"}, http.StatusOK, apperr.CodeOK, "小明", "en"},
+		{"keep language", model.UpdateUserInfoRequest{UserID: "u1", Nickname: "小红"}, http.StatusOK, apperr.CodeOK, "小红", "en"},
+		{"rejected nickname", model.UpdateUserInfoRequest{UserID: "u1", Nickname: "违禁词"}, http.StatusBadRequest, apperr.CodeNicknameRejected, "小红", "en"},
+		{"unsupported language", model.UpdateUserInfoRequest{UserID: "u1", Language: "fr"}, http.StatusBadRequest, apperr.CodeInvalidParam, "小红", "en"},
+		{"unknown user", model.UpdateUserInfoRequest{UserID: "nobody", Nickname: "小明"}, http.StatusNotFound, apperr.CodeUserNotFound, "小红", "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, s.HandleUpdateUserInfo, http.MethodPost, "", tt.req)
+			if status != tt.wantStatus || resp.Code != tt.wantCode {
+				t.Fatalf("status = %d, code = %d, want %d, %d", status, resp.Code, tt.wantStatus, tt.wantCode)
+			}
+
+			status, resp = serve(t, s.HandleGetUserInfo, http.MethodGet, "?user_id=u1", nil)
+			if status != http.StatusOK {
+				t.Fatalf("get user info status = %d, code = %d", status, resp.Code)
+			}
+			var info model.GetUserInfoResponse
+			if err := json.Unmarshal(resp.Data, &info); err != nil {
+				t.Fatal(err)
+			}
+			if info.Nickname != tt.wantNickname || info.Language != tt.wantLanguage || info.Coin != 60 {
+				t.Errorf("user info = %+v, want nickname %q, language %q, coin 60", info, tt.wantNickname, tt.wantLanguage)
+			}
+		})
+	}
+}
+
+func TestHandleGetUserInfoNotFound(t *testing.T) {
+	s := newTestServices(t)
+	status, resp := serve(t, s.HandleGetUserInfo, http.MethodGet, "?user_id=nobody", nil)
+	if status != http.StatusNotFound || resp.Code != apperr.CodeUserNotFound {
+		t.Fatalf("status = %d, code = %d, want 404, %d", status, resp.Code, apperr.CodeUserNotFound)
+	}
+}
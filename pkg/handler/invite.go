@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/invitecode"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleGetInviteStats 处理获取邀请统计请求
+func (s *Services) HandleGetInviteStats(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		apperr.Abort(c, apperr.ErrMissingParam.With("user_id"))
+		return
+	}
+
+	// 获取分页参数
+	cursor := int64(0)
+	pageSize := 10
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		if c, err := strconv.ParseInt(cursorStr, 10, 64); err == nil {
+			cursor = c
+		}
+	}
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
+			pageSize = ps
+		}
+	}
+
+	dbConn := s.DB
+	stats, err := s.Repos.Invites.Stats(dbConn, userID, cursor, pageSize)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, stats)
+}
+
+// HandleSetInviteCode 处理设置自定义邀请码请求，用于运营为合作用户分配专属邀请码
+func (s *Services) HandleSetInviteCode(c *gin.Context) {
+	var req model.SetInviteCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	code, err := invitecode.ValidateVanity(req.Code)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrVanityCodeFormat.With(invitecode.MinVanityLength, invitecode.MaxVanityLength))
+		return
+	}
+
+	dbConn := s.DB
+	if err := s.Repos.Users.SetInviteCode(dbConn, req.UserID, code); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, gin.H{
+		"invite_code": code,
+	})
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/invitecode"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+)
+
+func TestHandleUseInviteCodeDevice(t *testing.T) {
+	s := newTestServices(t)
+	for _, userID := range []string{"A", "B", "C"} {
+		createUser(t, s, userID, 0)
+	}
+
+	// 设备标识由服务端计算，请求体中换一个 device_id 仍然视为同一设备
+	tests := []struct {
+		inviteeID  string
+		deviceID   string
+		wantStatus int
+	}{
+		{"B", "device-1", model.InviteStatusPending},
+		{"C", "device-2", model.InviteStatusBlocked},
+	}
+	for _, tt := range tests {
+		body := map[string]string{"user_id": tt.inviteeID, "code": "CA", "device_id": tt.deviceID}
+		status, resp := serve(t, s.HandleUseInviteCode, http.MethodPost, "", body)
+		if status != http.StatusOK || resp.Code != apperr.CodeOK {
+			t.Fatalf("%s: status = %d, code = %d", tt.inviteeID, status, resp.Code)
+		}
+
+		var relationStatus int
+		err := s.DB.QueryRow("SELECT status FROM invite_relation WHERE invitee_id = ?", tt.inviteeID).Scan(&relationStatus)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if relationStatus != tt.wantStatus {
+			t.Errorf("%s: relation status = %d, want %d", tt.inviteeID, relationStatus, tt.wantStatus)
+		}
+	}
+}
+
+// collidingUsers 前 collisions 次创建用户时改用已被占用的邀请码
+type collidingUsers struct {
+	repo.UserRepo
+	taken      string
+	collisions int
+	attempts   int
+}
+
+func (r *collidingUsers) Create(q repo.Querier, user *model.UserInfo) error {
+	r.attempts++
+	if r.attempts <= r.collisions {
+		user.InviteCode = r.taken
+	}
+	return r.UserRepo.Create(q, user)
+}
+
+func TestCreateUserInviteCodeRetry(t *testing.T) {
+	// 邀请码重复时重新生成，连续重复达到最大重试次数后返回错误
+	tests := []struct {
+		collisions int
+		wantErr    error
+	}{
+		{0, nil},
+		{maxInviteCodeAttempts - 1, nil},
+		{maxInviteCodeAttempts, repo.ErrInviteCodeTaken},
+	}
+	for _, tt := range tests {
+		s := newTestServices(t)
+		createUser(t, s, "A", 0)
+		users := &collidingUsers{UserRepo: s.Repos.Users, taken: "CA", collisions: tt.collisions}
+		s.Repos.Users = users
+
+		user := &model.UserInfo{UserID: "B"}
+		err := s.createUser(user)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%d collisions: createUser() error = %v, want %v", tt.collisions, err, tt.wantErr)
+		}
+		if want := min(tt.collisions+1, maxInviteCodeAttempts); users.attempts != want {
+			t.Errorf("%d collisions: attempts = %d, want %d", tt.collisions, users.attempts, want)
+		}
+		if err != nil {
+			if user.InviteCode != "" {
+				t.Errorf("%d collisions: invite code = %q, want cleared", tt.collisions, user.InviteCode)
+			}
+			continue
+		}
+		if user.InviteCode == "CA" || len(user.InviteCode) != invitecode.Length {
+			t.Errorf("%d collisions: invite code = %q, want a fresh generated code", tt.collisions, user.InviteCode)
+		}
+	}
+}
+
+func TestHandleSetInviteCode(t *testing.T) {
+	s := newTestServices(t)
+	createUser(t, s, "A", 0)
+	createUser(t, s, "B", 0)
+
+	// 自定义邀请码规范化为大写，格式错误和已被占用时拒绝
+	tests := []struct {
+		name       string
+		userID     string
+		code       string
+		wantStatus int
+		wantCode   apperr.Code
+	}{
+		{"invalid", "A", "ab", http.StatusBadRequest, apperr.CodeVanityCodeFormat},
+		{"ok", "A", " summer ", http.StatusOK, apperr.CodeOK},
+		{"taken", "B", "Summer", http.StatusConflict, apperr.CodeInviteCodeTaken},
+		{"unknown user", "Z", "WINTER", http.StatusNotFound, apperr.CodeUserNotFound},
+	}
+	for _, tt := range tests {
+		body := map[string]string{"user_id": tt.userID, "code": tt.code}
+		status, resp := serve(t, s.HandleSetInviteCode, http.MethodPost, "", body)
+		if status != tt.wantStatus || resp.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, code = %d, want %d, %d", tt.name, status, resp.Code, tt.wantStatus, tt.wantCode)
+		}
+	}
+
+	user, err := s.Repos.Users.Get(s.DB, "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.InviteCode != "SUMMER" {
+		t.Errorf("invite code = %q, want SUMMER", user.InviteCode)
+	}
+}
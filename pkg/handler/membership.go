@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/pricing"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/gin-gonic/gin"
+)
+
+// quoteGeneration 根据用户会员状态和今日免费额度计算一次生成的报价
+func (s *Services) quoteGeneration(userID string, now time.Time) (pricing.Quote, error) {
+	membership, err := s.Repos.Memberships.Active(s.DB, userID, now)
+	if err != nil {
+		return pricing.Quote{}, err
+	}
+	if membership == nil {
+		return pricing.QuoteGeneration(s.Config.Pricing, "", 0), nil
+	}
+
+	used, err := s.Repos.Memberships.FreeUsage(s.DB, userID, now.Format("2006-01-02"))
+	if err != nil {
+		return pricing.Quote{}, err
+	}
+	return pricing.QuoteGeneration(s.Config.Pricing, membership.Tier, used), nil
+}
+
+// chargeGeneration 按报价扣费，返回实际的扣费结果
+// 并发请求导致免费额度已用完时改为按会员折扣价扣除coin
+func (s *Services) chargeGeneration(userID string, quote pricing.Quote, now time.Time, refID string) (pricing.Quote, error) {
+	if quote.Free {
+		tierCfg, _ := s.Config.Pricing.Tier(quote.Tier)
+		ok, err := s.Repos.Memberships.ConsumeFreeQuota(s.DB, userID, now.Format("2006-01-02"), tierCfg.DailyFreeQuota)
+		if err != nil || ok {
+			return quote, err
+		}
+		quote = pricing.QuoteGeneration(s.Config.Pricing, quote.Tier, tierCfg.DailyFreeQuota)
+	}
+
+	if quote.Price <= 0 {
+		return quote, nil
+	}
+	return quote, repo.WithTx(s.DB, func(tx repo.Querier) error {
+		_, err := s.Repos.Ledger.Change(tx, userID, -quote.Price, model.CoinSourceGeneration, refID, nil)
+		return err
+	})
+}
+
+// getMembershipInfo 获取用户信息中展示的会员状态，非会员时返回 nil
+func (s *Services) getMembershipInfo(userID string, now time.Time) (*model.MembershipInfo, error) {
+	membership, err := s.Repos.Memberships.Active(s.DB, userID, now)
+	if err != nil || membership == nil {
+		return nil, err
+	}
+
+	quote, err := s.quoteGeneration(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	tierCfg, _ := s.Config.Pricing.Tier(membership.Tier)
+	return &model.MembershipInfo{
+		Tier:            membership.Tier,
+		ExpiresAt:       membership.ExpiresAt,
+		DailyFreeQuota:  tierCfg.DailyFreeQuota,
+		FreeQuotaLeft:   quote.FreeQuotaLeft,
+		DiscountPercent: tierCfg.DiscountPercent,
+		Priority:        tierCfg.Priority,
+	}, nil
+}
+
+// HandleGetGenerationQuote 处理查询生成价格请求，用于前端展示本次生成是否免费及扣除的coin
+func (s *Services) HandleGetGenerationQuote(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		apperr.Abort(c, apperr.ErrMissingParam.With("user_id"))
+		return
+	}
+
+	quote, err := s.quoteGeneration(userID, config.Now())
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, quote)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/logger"
+	"github.com/MRsummer/ChangeHairStyle/pkg/middleware"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleGetPendingNicknames 处理获取待审核昵称列表请求
+func (s *Services) HandleGetPendingNicknames(c *gin.Context) {
+	cursor := int64(0)
+	pageSize := 20
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		if c, err := strconv.ParseInt(cursorStr, 10, 64); err == nil {
+			cursor = c
+		}
+	}
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
+			pageSize = ps
+		}
+	}
+
+	dbConn := s.DB
+	response, err := s.Repos.Users.ListPendingNicknames(dbConn, cursor, pageSize)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, response)
+}
+
+// HandleApproveNickname 处理昵称审核通过请求，替换为新昵称
+func (s *Services) HandleApproveNickname(c *gin.Context) {
+	s.handleReviewNickname(c, true)
+}
+
+// HandleRejectNickname 处理昵称审核拒绝请求，保留原昵称
+func (s *Services) HandleRejectNickname(c *gin.Context) {
+	s.handleReviewNickname(c, false)
+}
+
+// handleReviewNickname 执行昵称审核
+func (s *Services) handleReviewNickname(c *gin.Context, approve bool) {
+	var req model.ReviewNicknameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	dbConn := s.DB
+	if err := s.Repos.Users.ResolvePendingNickname(dbConn, req.UserID, req.Nickname, approve); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	logger.WithContext(map[string]interface{}{
+		"request_id": middleware.GetRequestID(c),
+		"operator":   middleware.GetAdminOperator(c),
+		"user_id":    req.UserID,
+		"nickname":   req.Nickname,
+		"approve":    approve,
+	}).Info("昵称审核完成")
+
+	apperr.OK(c, nil)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+// getUserInfo 获取用户信息
+func getUserInfo(t *testing.T, s *Services, userID string) model.GetUserInfoResponse {
+	t.Helper()
+	status, resp := serve(t, s.HandleGetUserInfo, http.MethodGet, "?user_id="+userID, nil)
+	if status != http.StatusOK {
+		t.Fatalf("get user info status = %d, code = %d", status, resp.Code)
+	}
+	var info model.GetUserInfoResponse
+	if err := json.Unmarshal(resp.Data, &info); err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+// updateNickname 修改昵称，要求请求成功
+func updateNickname(t *testing.T, s *Services, userID, nickname string) {
+	t.Helper()
+	status, resp := serve(t, s.HandleUpdateUserInfo, http.MethodPost, "", model.UpdateUserInfoRequest{UserID: userID, Nickname: nickname})
+	if status != http.StatusOK {
+		t.Fatalf("update nickname %q status = %d, code = %d", nickname, status, resp.Code)
+	}
+}
+
+func TestNicknameUnderReviewIsNotPublished(t *testing.T) {
+	s := newTestServices(t)
+	createUser(t, s, "u1", 60)
+	updateNickname(t, s, "u1", "小明")
+
+	// 需要人工审核的昵称不替换原昵称
+	updateNickname(t, s, "u1", "待审的昵称")
+	info := getUserInfo(t, s, "u1")
+	if info.Nickname != "小明" || info.PendingNickname != "待审的昵称" {
+		t.Fatalf("nickname = %q, pending = %q, want 小明, 待审的昵称", info.Nickname, info.PendingNickname)
+	}
+
+	status, resp := serve(t, s.HandleGetPendingNicknames, http.MethodGet, "", nil)
+	if status != http.StatusOK {
+		t.Fatalf("list pending status = %d, code = %d", status, resp.Code)
+	}
+	var list model.PendingNicknameListResponse
+	if err := json.Unmarshal(resp.Data, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Records) != 1 || list.Records[0].UserID != "u1" || list.Records[0].PendingNickname != "待审的昵称" {
+		t.Fatalf("pending list = %+v", list.Records)
+	}
+
+	// 审核的昵称与待审核的不一致时不处理
+	status, resp = serve(t, s.HandleApproveNickname, http.MethodPost, "", model.ReviewNicknameRequest{UserID: "u1", Nickname: "别的昵称"})
+	if status != http.StatusConflict || resp.Code != apperr.CodeNicknameNotPending {
+		t.Fatalf("approve mismatched status = %d, code = %d, want 409, %d", status, resp.Code, apperr.CodeNicknameNotPending)
+	}
+
+	status, resp = serve(t, s.HandleApproveNickname, http.MethodPost, "", model.ReviewNicknameRequest{UserID: "u1", Nickname: "待审的昵称"})
+	if status != http.StatusOK {
+		t.Fatalf("approve status = %d, code = %d", status, resp.Code)
+	}
+	info = getUserInfo(t, s, "u1")
+	if info.Nickname != "待审的昵称" || info.PendingNickname != "" {
+		t.Fatalf("after approve nickname = %q, pending = %q", info.Nickname, info.PendingNickname)
+	}
+}
+
+func TestRejectPendingNickname(t *testing.T) {
+	s := newTestServices(t)
+	createUser(t, s, "u1", 60)
+	updateNickname(t, s, "u1", "小明")
+	updateNickname(t, s, "u1", "待审的昵称")
+
+	status, resp := serve(t, s.HandleRejectNickname, http.MethodPost, "", model.ReviewNicknameRequest{UserID: "u1", Nickname: "待审的昵称"})
+	if status != http.StatusOK {
+		t.Fatalf("reject status = %d, code = %d", status, resp.Code)
+	}
+	info := getUserInfo(t, s, "u1")
+	if info.Nickname != "小明" || info.PendingNickname != "" {
+		t.Fatalf("after reject nickname = %q, pending = %q", info.Nickname, info.PendingNickname)
+	}
+
+	// 已处理的昵称不能再次审核
+	status, resp = serve(t, s.HandleApproveNickname, http.MethodPost, "", model.ReviewNicknameRequest{UserID: "u1", Nickname: "待审的昵称"})
+	if status != http.StatusConflict || resp.Code != apperr.CodeNicknameNotPending {
+		t.Fatalf("approve resolved status = %d, code = %d", status, resp.Code)
+	}
+}
+
+func TestPassingNicknameReplacesPending(t *testing.T) {
+	s := newTestServices(t)
+	createUser(t, s, "u1", 60)
+	updateNickname(t, s, "u1", "待审的昵称")
+	updateNickname(t, s, "u1", "小红")
+
+	info := getUserInfo(t, s, "u1")
+	if info.Nickname != "小红" || info.PendingNickname != "" {
+		t.Fatalf("nickname = %q, pending = %q, want 小红 and no pending", info.Nickname, info.PendingNickname)
+	}
+}
+
+func TestAvatarUpdateKeepsPendingNickname(t *testing.T) {
+	s := newTestServices(t)
+	createUser(t, s, "u1", 60)
+	updateNickname(t, s, "u1", "小明")
+	updateNickname(t, s, "u1", "待审的昵称")
+
+	// 只修改头像时不影响昵称和待审核的昵称
+	req := model.UpdateUserInfoRequest{UserID: "u1", AvatarURL: "https://img/avatar.png"}
+	status, resp := serve(t, s.HandleUpdateUserInfo, http.MethodPost, "", req)
+	if status != http.StatusOK {
+		t.Fatalf("update avatar status = %d, code = %d", status, resp.Code)
+	}
+	info := getUserInfo(t, s, "u1")
+	if info.Nickname != "小明" || info.PendingNickname != "待审的昵称" || info.AvatarURL != req.AvatarURL {
+		t.Fatalf("user info = %+v, want nickname 小明 pending 待审的昵称 and the new avatar", info)
+	}
+}
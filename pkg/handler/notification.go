@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/i18n"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleGetNotifications 处理获取通知列表请求
+func (s *Services) HandleGetNotifications(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		apperr.Abort(c, apperr.ErrMissingParam.With("user_id"))
+		return
+	}
+
+	// 获取分页参数
+	cursor := int64(0)
+	pageSize := 20
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		if c, err := strconv.ParseInt(cursorStr, 10, 64); err == nil {
+			cursor = c
+		}
+	}
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
+			pageSize = ps
+		}
+	}
+
+	dbConn := s.DB
+	response, err := s.Repos.Notifications.List(dbConn, userID, cursor, pageSize)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	// 展示文案按请求的语言生成
+	locale := i18n.FromContext(c)
+	for i := range response.Records {
+		response.Records[i].Message = response.Records[i].BuildMessage(locale)
+	}
+
+	apperr.OK(c, response)
+}
+
+// HandleGetUnreadNotificationCount 处理获取未读通知数请求
+func (s *Services) HandleGetUnreadNotificationCount(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		apperr.Abort(c, apperr.ErrMissingParam.With("user_id"))
+		return
+	}
+
+	dbConn := s.DB
+	count, err := s.Repos.Notifications.UnreadCount(dbConn, userID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, gin.H{
+		"unread_count": count,
+	})
+}
+
+// HandleMarkNotificationsRead 处理标记通知已读请求
+func (s *Services) HandleMarkNotificationsRead(c *gin.Context) {
+	var req model.MarkNotificationsReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	dbConn := s.DB
+	if err := s.Repos.Notifications.MarkRead(dbConn, req.UserID, req.IDs); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, nil)
+}
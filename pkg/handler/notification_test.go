@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+func TestHandleLikeNotifiesAuthor(t *testing.T) {
+	s := newTestServices(t)
+	record := &model.HairStyleRecord{UserID: "author", ImageURL: "https://img/1", Prompt: "短发"}
+	if err := s.Repos.Records.Save(s.DB, record); err != nil {
+		t.Fatal(err)
+	}
+	content := &model.SquareContent{UserID: "author", RecordID: record.ID}
+	if err := s.Repos.Square.Share(s.DB, content); err != nil {
+		t.Fatal(err)
+	}
+
+	// 点赞通知作者并按人数聚合，取消点赞和作者给自己点赞不产生通知
+	tests := []struct {
+		userID     string
+		wantActors int
+	}{
+		{"u1", 1},
+		{"u2", 2},
+		{"u2", 2},
+		{"u2", 2},
+		{"author", 2},
+	}
+	for i, tt := range tests {
+		body := map[string]interface{}{"user_id": tt.userID, "content_id": content.ID}
+		if status, resp := serve(t, s.HandleLike, http.MethodPost, "", body); status != http.StatusOK {
+			t.Fatalf("like %d: status = %d, code = %d", i, status, resp.Code)
+		}
+
+		list := getNotifications(t, s, "author")
+		if list.UnreadCount != 1 || len(list.Records) != 1 {
+			t.Fatalf("like %d: notifications = %+v, want 1 unread", i, list)
+		}
+		if n := list.Records[0]; n.ActorCount != tt.wantActors || n.Message == "" {
+			t.Errorf("like %d: notification = %+v, want %d actors with a message", i, n, tt.wantActors)
+		}
+	}
+
+	// 标记全部已读后未读数清零
+	body := map[string]interface{}{"user_id": "author"}
+	if status, resp := serve(t, s.HandleMarkNotificationsRead, http.MethodPost, "", body); status != http.StatusOK {
+		t.Fatalf("mark read: status = %d, code = %d", status, resp.Code)
+	}
+	if list := getNotifications(t, s, "author"); list.UnreadCount != 0 || !list.Records[0].IsRead {
+		t.Errorf("notifications after mark read = %+v, want all read", list)
+	}
+}
+
+// getNotifications 请求用户的通知列表
+func getNotifications(t *testing.T, s *Services, userID string) model.NotificationListResponse {
+	t.Helper()
+	status, resp := serve(t, s.HandleGetNotifications, http.MethodGet, "?user_id="+userID, nil)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, code = %d", status, resp.Code)
+	}
+	var list model.NotificationListResponse
+	if err := json.Unmarshal(resp.Data, &list); err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
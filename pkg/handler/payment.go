@@ -0,0 +1,383 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/logger"
+	"github.com/MRsummer/ChangeHairStyle/pkg/middleware"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/wxpay"
+	"github.com/gin-gonic/gin"
+)
+
+// payOrderExpire 支付订单有效期，超时未支付由微信支付关闭
+const payOrderExpire = 30 * time.Minute
+
+// getPayClient 获取微信支付客户端，未配置商户信息时返回 503
+func (s *Services) getPayClient(c *gin.Context) (*wxpay.Client, bool) {
+	client := s.Pay
+	if client == nil {
+		apperr.Abort(c, apperr.ErrPayNotConfigured)
+		return nil, false
+	}
+	return client, true
+}
+
+// HandleGetCoinProducts 处理获取coin充值商品请求
+func (s *Services) HandleGetCoinProducts(c *gin.Context) {
+	dbConn := s.DB
+	products, err := s.Repos.PayOrders.Products(dbConn)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, products)
+}
+
+// HandleCreatePayOrder 处理创建支付订单请求，返回小程序调起支付的参数
+func (s *Services) HandleCreatePayOrder(c *gin.Context) {
+	var req model.CreatePayOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	client, ok := s.getPayClient(c)
+	if !ok {
+		return
+	}
+
+	dbConn := s.DB
+	product, err := s.Repos.PayOrders.Product(dbConn, req.ProductID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	userInfo, err := s.Repos.Users.Get(dbConn, req.UserID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if userInfo == nil {
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	// 当前会员等级更高时不能购买低等级会员，避免支付后无法发放
+	now := config.Now()
+	if product.MembershipDays > 0 {
+		membership, err := s.Repos.Memberships.Active(dbConn, req.UserID, now)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		if membership != nil && config.TierRank(product.MembershipTier) < config.TierRank(membership.Tier) {
+			apperr.Abort(c, apperr.ErrMembershipDowngrade)
+			return
+		}
+	}
+
+	outTradeNo, err := wxpay.NewOutTradeNo("HS", now)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	order := &model.PayOrder{
+		OutTradeNo:     outTradeNo,
+		UserID:         req.UserID,
+		ProductID:      product.ID,
+		Description:    "换发型-" + product.Name,
+		Coins:          product.Coins + product.BonusCoins,
+		Amount:         product.Price,
+		MembershipTier: product.MembershipTier,
+		MembershipDays: product.MembershipDays,
+	}
+	if err := s.Repos.PayOrders.Create(dbConn, order); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	// 用户ID即小程序openid
+	params, err := client.Prepay(c.Request.Context(), order.OutTradeNo, order.Description, order.UserID, order.Amount, now.Add(payOrderExpire))
+	if err != nil {
+		logger.WithContext(map[string]interface{}{
+			"request_id":   middleware.GetRequestID(c),
+			"out_trade_no": order.OutTradeNo,
+		}).WithError(err).Error("微信支付下单失败")
+		if err := s.Repos.PayOrders.Close(dbConn, order.OutTradeNo); err != nil {
+			logger.WithError(err).Warn("关闭支付订单失败")
+		}
+		apperr.Abort(c, apperr.ErrPrepayFailed.Wrap(err))
+		return
+	}
+	if err := s.Repos.PayOrders.SetPrepayID(dbConn, order.OutTradeNo, strings.TrimPrefix(params.Package, "prepay_id=")); err != nil {
+		logger.WithError(err).Warn("保存预支付交易会话标识失败")
+	}
+
+	apperr.OK(c, gin.H{
+		"order":      order,
+		"pay_params": params,
+	})
+}
+
+// HandleGetPayOrders 处理获取支付订单列表请求
+func (s *Services) HandleGetPayOrders(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		apperr.Abort(c, apperr.ErrMissingParam.With("user_id"))
+		return
+	}
+
+	// 获取分页参数
+	cursor := int64(0)
+	pageSize := 10
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		if c, err := strconv.ParseInt(cursorStr, 10, 64); err == nil {
+			cursor = c
+		}
+	}
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
+			pageSize = ps
+		}
+	}
+
+	dbConn := s.DB
+	response, err := s.Repos.PayOrders.List(dbConn, userID, cursor, pageSize)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, response)
+}
+
+// HandleGetPayOrderStatus 处理查询支付订单状态请求
+// 订单仍为待支付时主动向微信支付查询，避免回调延迟导致用户看不到到账
+func (s *Services) HandleGetPayOrderStatus(c *gin.Context) {
+	userID := c.Query("user_id")
+	outTradeNo := c.Query("out_trade_no")
+	if userID == "" || outTradeNo == "" {
+		apperr.Abort(c, apperr.ErrMissingParam.With("user_id, out_trade_no"))
+		return
+	}
+
+	dbConn := s.DB
+	order, err := s.Repos.PayOrders.Get(dbConn, outTradeNo)
+	if err == nil && order.UserID != userID {
+		err = repo.ErrOrderNotFound
+	}
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	if order.Status == model.PayOrderStatusPending {
+		if client := s.Pay; client != nil {
+			order = s.syncPayOrder(c, client, order)
+		}
+	}
+
+	apperr.OK(c, order)
+}
+
+// syncPayOrder 按微信支付的订单状态更新本地订单，查询失败时返回原订单
+func (s *Services) syncPayOrder(c *gin.Context, client *wxpay.Client, order *model.PayOrder) *model.PayOrder {
+	log := logger.WithContext(map[string]interface{}{
+		"request_id":   middleware.GetRequestID(c),
+		"out_trade_no": order.OutTradeNo,
+	})
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+	transaction, err := client.QueryOrder(ctx, order.OutTradeNo)
+	cancel()
+	if err != nil {
+		log.WithError(err).Warn("查询微信支付订单失败")
+		return order
+	}
+
+	switch transaction.TradeState {
+	case wxpay.TradeStateSuccess:
+		paidAt := wxpay.ParseTime(transaction.SuccessTime, time.Now())
+		if _, err := s.markPayOrderPaid(order.OutTradeNo, transaction.TransactionID, transaction.Amount.Total, paidAt); err != nil {
+			log.WithError(err).Error("更新订单支付状态失败")
+			return order
+		}
+	case wxpay.TradeStateClosed, wxpay.TradeStateRevoked, wxpay.TradeStatePayError:
+		if err := s.Repos.PayOrders.Close(s.DB, order.OutTradeNo); err != nil {
+			log.WithError(err).Warn("关闭支付订单失败")
+			return order
+		}
+	default:
+		return order
+	}
+
+	updated, err := s.Repos.PayOrders.Get(s.DB, order.OutTradeNo)
+	if err != nil {
+		log.WithError(err).Warn("查询支付订单失败")
+		return order
+	}
+	return updated
+}
+
+// markPayOrderPaid 在事务中标记订单已支付并发放coin和会员，重复通知时不会重复发放，返回本次是否发放
+func (s *Services) markPayOrderPaid(outTradeNo, transactionID string, amount int, paidAt time.Time) (bool, error) {
+	var credited bool
+	err := repo.WithTx(s.DB, func(tx repo.Querier) error {
+		var err error
+		credited, err = s.Repos.PayOrders.MarkPaid(tx, outTradeNo, transactionID, amount, paidAt)
+		return err
+	})
+	return credited, err
+}
+
+// completePayRefund 在事务中标记退款成功，重复通知时不做处理
+func (s *Services) completePayRefund(outRefundNo, refundID string) error {
+	return repo.WithTx(s.DB, func(tx repo.Querier) error {
+		return s.Repos.PayOrders.CompleteRefund(tx, outRefundNo, refundID)
+	})
+}
+
+// failPayRefund 在事务中标记退款失败，退回扣除的coin和会员时长，重复通知时不做处理
+func (s *Services) failPayRefund(outRefundNo string) error {
+	return repo.WithTx(s.DB, func(tx repo.Querier) error {
+		return s.Repos.PayOrders.FailRefund(tx, outRefundNo, config.Now())
+	})
+}
+
+// HandlePayNotify 处理微信支付的支付和退款结果回调
+// 处理失败时返回非2xx状态码，微信支付会按策略重试通知
+func (s *Services) HandlePayNotify(c *gin.Context) {
+	client, ok := s.getPayClient(c)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, wxpay.NotifyResponse{Code: "FAIL", Message: "读取通知失败"})
+		return
+	}
+
+	notification, err := client.ParseNotification(c.Request.Header, body)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, wxpay.NotifyResponse{Code: "FAIL", Message: err.Error()})
+		return
+	}
+
+	log := logger.WithContext(map[string]interface{}{
+		"request_id":      middleware.GetRequestID(c),
+		"notification_id": notification.ID,
+		"event_type":      notification.EventType,
+	})
+
+	switch notification.EventType {
+	case wxpay.EventTransactionSuccess:
+		var transaction wxpay.Transaction
+		if err = client.DecodeResource(notification, &transaction); err == nil {
+			paidAt := wxpay.ParseTime(transaction.SuccessTime, time.Now())
+			_, err = s.markPayOrderPaid(transaction.OutTradeNo, transaction.TransactionID, transaction.Amount.Total, paidAt)
+		}
+	case wxpay.EventRefundSuccess:
+		var refund wxpay.RefundNotification
+		if err = client.DecodeResource(notification, &refund); err == nil {
+			err = s.completePayRefund(refund.OutRefundNo, refund.RefundID)
+		}
+	case wxpay.EventRefundAbnormal, wxpay.EventRefundClosed:
+		var refund wxpay.RefundNotification
+		if err = client.DecodeResource(notification, &refund); err == nil {
+			err = s.failPayRefund(refund.OutRefundNo)
+		}
+	default:
+		log.Info("忽略未处理的微信支付通知")
+	}
+
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, wxpay.NotifyResponse{Code: "SUCCESS"})
+	case errors.Is(err, repo.ErrOrderNotFound),
+		errors.Is(err, repo.ErrRefundNotFound),
+		errors.Is(err, repo.ErrOrderAmountMismatch):
+		// 重试也无法处理，记录后应答成功避免微信支付重复通知
+		log.WithError(err).Error("微信支付通知与本地订单不匹配")
+		c.JSON(http.StatusOK, wxpay.NotifyResponse{Code: "SUCCESS"})
+	default:
+		log.WithError(err).Error("处理微信支付通知失败")
+		c.JSON(http.StatusInternalServerError, wxpay.NotifyResponse{Code: "FAIL", Message: "处理失败"})
+	}
+}
+
+// HandleRefundPayOrder 处理退款请求，全额退款并扣回订单发放的coin
+func (s *Services) HandleRefundPayOrder(c *gin.Context) {
+	var req model.RefundPayOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	client, ok := s.getPayClient(c)
+	if !ok {
+		return
+	}
+
+	outRefundNo, err := wxpay.NewOutTradeNo("RF", config.Now())
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	refund := &model.PayRefund{
+		OutRefundNo: outRefundNo,
+		OutTradeNo:  req.OutTradeNo,
+		Reason:      req.Reason,
+	}
+
+	err = repo.WithTx(s.DB, func(tx repo.Querier) error {
+		return s.Repos.PayOrders.CreateRefund(tx, refund, config.Now())
+	})
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	log := logger.WithContext(map[string]interface{}{
+		"request_id":    middleware.GetRequestID(c),
+		"out_trade_no":  refund.OutTradeNo,
+		"out_refund_no": refund.OutRefundNo,
+	})
+
+	result, err := client.Refund(c.Request.Context(), refund.OutTradeNo, refund.OutRefundNo, refund.Reason, refund.Amount, refund.Amount)
+	if err != nil {
+		log.WithError(err).Error("申请微信支付退款失败")
+		if err := s.failPayRefund(refund.OutRefundNo); err != nil {
+			log.WithError(err).Error("退回退款扣除的金币失败")
+		}
+		apperr.Abort(c, apperr.ErrRefundFailed.Wrap(err))
+		return
+	}
+
+	// 退款结果通常通过回调通知，同步返回最终状态时直接处理
+	refund.RefundID = result.RefundID
+	switch result.Status {
+	case wxpay.RefundStatusSuccess:
+		err = s.completePayRefund(refund.OutRefundNo, result.RefundID)
+		refund.Status = model.PayRefundStatusSuccess
+	case wxpay.RefundStatusClosed, wxpay.RefundStatusAbnormal:
+		err = s.failPayRefund(refund.OutRefundNo)
+		refund.Status = model.PayRefundStatusFailed
+	}
+	if err != nil {
+		log.WithError(err).Error("更新退款状态失败")
+	}
+
+	apperr.OK(c, refund)
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/wxpay"
+	"github.com/MRsummer/ChangeHairStyle/pkg/wxpay/wxpaytest"
+	"github.com/gin-gonic/gin"
+)
+
+// postNotify 将回调通知原样发送给 HandlePayNotify
+func postNotify(t *testing.T, s *Services, header http.Header, body []byte) (int, wxpay.NotifyResponse) {
+	t.Helper()
+	r := gin.New()
+	r.Use(apperr.Middleware(ErrorMappings))
+	r.POST("/", s.HandlePayNotify)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header = header.Clone()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp wxpay.NotifyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+// newPayServices 创建接入假微信支付服务器的 Services，并为 u1 创建一笔已在微信支付下单的订单
+func newPayServices(t *testing.T, outTradeNo string) (*Services, *wxpaytest.Server) {
+	t.Helper()
+	srv, err := wxpaytest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+
+	s := newTestServices(t)
+	s.Pay = srv.Client()
+	createUser(t, s, "u1", 0)
+
+	order := &model.PayOrder{OutTradeNo: outTradeNo, UserID: "u1", ProductID: 1, Description: "100金币", Coins: 100, Amount: 600}
+	if err := s.Repos.PayOrders.Create(s.DB, order); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Pay.Prepay(context.Background(), outTradeNo, order.Description, "openid-1", order.Amount, time.Now().Add(payOrderExpire)); err != nil {
+		t.Fatal(err)
+	}
+	return s, srv
+}
+
+// balance 查询用户coin余额
+func balance(t *testing.T, s *Services, userID string) int {
+	t.Helper()
+	coin, err := s.Repos.Ledger.Balance(s.DB, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return coin
+}
+
+func TestHandlePayNotifyCreditsOnce(t *testing.T) {
+	s, srv := newPayServices(t, "PAY1")
+	header, body, err := srv.Pay("PAY1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 微信支付会重复发送同一通知，只有第一次发放coin
+	for i := 0; i < 2; i++ {
+		status, resp := postNotify(t, s, header, body)
+		if status != http.StatusOK || resp.Code != "SUCCESS" {
+			t.Fatalf("notification %d = %d %+v, want 200 SUCCESS", i, status, resp)
+		}
+	}
+	if coin := balance(t, s, "u1"); coin != 100 {
+		t.Errorf("balance = %d, want 100", coin)
+	}
+
+	order, err := s.Repos.PayOrders.Get(s.DB, "PAY1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != model.PayOrderStatusPaid || order.TransactionID == "" || order.PaidAt == nil {
+		t.Errorf("order = %+v, want paid", order)
+	}
+}
+
+func TestHandlePayNotifyRejectsBadSignature(t *testing.T) {
+	s, srv := newPayServices(t, "PAY1")
+	header, body, err := srv.Pay("PAY1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged := header.Clone()
+	forged.Set(wxpay.HeaderSignature, "Zm9yZ2Vk")
+	status, resp := postNotify(t, s, forged, body)
+	if status != http.StatusUnauthorized || resp.Code != "FAIL" {
+		t.Fatalf("forged notification = %d %+v, want 401 FAIL", status, resp)
+	}
+	if coin := balance(t, s, "u1"); coin != 0 {
+		t.Errorf("balance = %d, want 0", coin)
+	}
+}
+
+func TestHandlePayNotifyUnknownOrder(t *testing.T) {
+	s, srv := newPayServices(t, "PAY1")
+	if _, err := s.Pay.Prepay(context.Background(), "OTHER", "100金币", "openid-1", 600, time.Now().Add(payOrderExpire)); err != nil {
+		t.Fatal(err)
+	}
+	header, body, err := srv.Pay("OTHER")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 本地没有的订单重试也无法处理，应答成功避免重复通知
+	status, resp := postNotify(t, s, header, body)
+	if status != http.StatusOK || resp.Code != "SUCCESS" {
+		t.Fatalf("unknown order notification = %d %+v, want 200 SUCCESS", status, resp)
+	}
+	if coin := balance(t, s, "u1"); coin != 0 {
+		t.Errorf("balance = %d, want 0", coin)
+	}
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/invitecode"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleRedeem 处理兑换码兑换请求
+func (s *Services) HandleRedeem(c *gin.Context) {
+	var req model.RedeemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	dbConn := s.DB
+	var result *model.RedeemResult
+	err := repo.WithTx(dbConn, func(tx repo.Querier) error {
+		var err error
+		result, err = s.Repos.Redeems.Redeem(tx, req.UserID, invitecode.Normalize(req.Code), config.Now())
+		return err
+	})
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, result)
+}
+
+// HandleCreateRedeemCampaign 处理创建兑换活动请求
+func (s *Services) HandleCreateRedeemCampaign(c *gin.Context) {
+	var req model.CreateRedeemCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	// 校验奖励和有效期
+	var invalid *apperr.Error
+	if req.Coins < 0 || req.MembershipDays < 0 {
+		invalid = apperr.ErrInvalidParam.With("coins/membership_days")
+	} else if req.Coins == 0 && req.MembershipDays == 0 {
+		invalid = apperr.ErrMissingParam.With("coins/membership_days")
+	} else if _, ok := s.Config.Pricing.Tier(req.MembershipTier); req.MembershipDays > 0 && !ok {
+		invalid = apperr.ErrInvalidParam.With("membership_tier")
+	} else if !req.EndsAt.After(req.StartsAt) {
+		invalid = apperr.ErrInvalidParam.With("ends_at")
+	}
+	if invalid != nil {
+		apperr.Abort(c, invalid)
+		return
+	}
+
+	campaign := &model.RedeemCampaign{
+		Name:           req.Name,
+		Coins:          req.Coins,
+		MembershipTier: req.MembershipTier,
+		MembershipDays: req.MembershipDays,
+		PerUserLimit:   1,
+		StartsAt:       req.StartsAt,
+		EndsAt:         req.EndsAt,
+	}
+	if req.PerUserLimit != nil {
+		campaign.PerUserLimit = max(*req.PerUserLimit, 0)
+	}
+
+	dbConn := s.DB
+	if err := s.Repos.Redeems.CreateCampaign(dbConn, campaign); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, campaign)
+}
+
+// HandleCreateRedeemCodes 处理生成兑换码请求
+func (s *Services) HandleCreateRedeemCodes(c *gin.Context) {
+	var req model.CreateRedeemCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	code := ""
+	if req.Code != "" {
+		var err error
+		code, err = invitecode.ValidateVanity(req.Code)
+		if err != nil {
+			apperr.Abort(c, apperr.ErrRedeemCodeFormat.With(invitecode.MinVanityLength, invitecode.MaxVanityLength))
+			return
+		}
+	}
+	if req.MaxUses < 0 {
+		apperr.Abort(c, apperr.ErrInvalidParam.With("max_uses"))
+		return
+	}
+
+	dbConn := s.DB
+	var codes []model.RedeemCode
+	err := repo.WithTx(dbConn, func(tx repo.Querier) error {
+		var err error
+		codes, err = s.Repos.Redeems.CreateCodes(tx, req.CampaignID, code, req.Count, req.MaxUses)
+		return err
+	})
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, codes)
+}
+
+// HandleGetRedeemCodes 处理获取兑换码列表请求
+func (s *Services) HandleGetRedeemCodes(c *gin.Context) {
+	campaignID, err := strconv.ParseInt(c.Query("campaign_id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrMissingParam.With("campaign_id"))
+		return
+	}
+
+	// 获取分页参数
+	cursor := int64(0)
+	pageSize := 50
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		if c, err := strconv.ParseInt(cursorStr, 10, 64); err == nil {
+			cursor = c
+		}
+	}
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
+			pageSize = ps
+		}
+	}
+
+	dbConn := s.DB
+	response, err := s.Repos.Redeems.ListCodes(dbConn, campaignID, cursor, pageSize)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, response)
+}
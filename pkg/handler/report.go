@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/middleware"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/gin-gonic/gin"
+)
+
+// getReportHideThresholdFromEnv 从环境变量获取自动隐藏的举报数阈值
+func getReportHideThresholdFromEnv() int {
+	thresholdStr := os.Getenv("REPORT_HIDE_THRESHOLD")
+	if thresholdStr == "" {
+		return 5 // 默认值
+	}
+
+	threshold, err := strconv.Atoi(thresholdStr)
+	if err != nil || threshold <= 0 {
+		return 5 // 解析失败时返回默认值
+	}
+
+	return threshold
+}
+
+// HandleReportContent 处理举报广场内容请求
+func (s *Services) HandleReportContent(c *gin.Context) {
+	var req model.ReportContentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	if !model.IsValidReportReason(req.Reason) {
+		apperr.Abort(c, apperr.ErrInvalidParam.With("reason"))
+		return
+	}
+
+	// 举报数达到阈值会自动隐藏内容，举报人必须是已验证身份的用户，请求参数中的 user_id 可以随意填写
+	reporterID := middleware.GetVerifiedUserID(c)
+	if reporterID == "" {
+		apperr.Abort(c, apperr.ErrUnauthorized)
+		return
+	}
+
+	report := &model.ContentReport{
+		ContentID:  req.ContentID,
+		ReporterID: reporterID,
+		Reason:     req.Reason,
+		Detail:     req.Detail,
+	}
+
+	dbConn := s.DB
+	err := repo.WithTx(dbConn, func(tx repo.Querier) error {
+		_, err := s.Repos.Moderation.Report(tx, report, getReportHideThresholdFromEnv())
+		return err
+	})
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, gin.H{
+		"report_id": report.ID,
+	})
+}
+
+// HandleGetReportedContents 处理获取审核队列请求
+func (s *Services) HandleGetReportedContents(c *gin.Context) {
+	// 获取过滤和分页参数，status 为空时返回所有状态
+	status := -1
+	cursor := int64(0)
+	pageSize := 20
+	if statusStr := c.Query("status"); statusStr != "" {
+		s, err := strconv.Atoi(statusStr)
+		if err != nil {
+			apperr.Abort(c, apperr.ErrInvalidParam.With("status"))
+			return
+		}
+		status = s
+	}
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		if c, err := strconv.ParseInt(cursorStr, 10, 64); err == nil {
+			cursor = c
+		}
+	}
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 {
+			pageSize = ps
+		}
+	}
+
+	dbConn := s.DB
+	response, err := s.Repos.Moderation.Queue(dbConn, status, cursor, pageSize)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, response)
+}
+
+// HandleApproveContent 处理审核通过请求，恢复内容展示
+func (s *Services) HandleApproveContent(c *gin.Context) {
+	s.handleModerateContent(c, model.ModerationActionApprove)
+}
+
+// HandleRemoveContent 处理审核下架请求
+func (s *Services) HandleRemoveContent(c *gin.Context) {
+	s.handleModerateContent(c, model.ModerationActionRemove)
+}
+
+// handleModerateContent 执行审核操作
+func (s *Services) handleModerateContent(c *gin.Context, action string) {
+	var req model.ModerateContentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	dbConn := s.DB
+	operator := middleware.GetAdminOperator(c)
+	err := repo.WithTx(dbConn, func(tx repo.Querier) error {
+		return s.Repos.Moderation.Moderate(tx, req.ContentID, operator, action, req.Note)
+	})
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, nil)
+}
+
+// HandleGetModerationLogs 处理获取审核日志请求
+func (s *Services) HandleGetModerationLogs(c *gin.Context) {
+	contentID, err := strconv.ParseInt(c.Query("content_id"), 10, 64)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrMissingParam.With("content_id"))
+		return
+	}
+
+	dbConn := s.DB
+	logs, err := s.Repos.Moderation.Logs(dbConn, contentID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, logs)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+func TestHandleReportContent(t *testing.T) {
+	t.Setenv("REPORT_HIDE_THRESHOLD", "2")
+	s := newTestServices(t)
+	record := &model.HairStyleRecord{UserID: "author", ImageURL: "https://img/1", Prompt: "短发"}
+	if err := s.Repos.Records.Save(s.DB, record); err != nil {
+		t.Fatal(err)
+	}
+	content := &model.SquareContent{UserID: "author", RecordID: record.ID}
+	if err := s.Repos.Square.Share(s.DB, content); err != nil {
+		t.Fatal(err)
+	}
+
+	// 请求体中的 user_id 不参与去重，同一登录用户换不同 user_id 也只能举报一次
+	body := map[string]interface{}{"user_id": "u1", "content_id": content.ID, "reason": model.ReportReasonSpam}
+	forged := map[string]interface{}{"user_id": "u9", "content_id": content.ID, "reason": model.ReportReasonSpam}
+	tests := []struct {
+		name       string
+		userID     string
+		body       interface{}
+		wantStatus int
+		wantCode   apperr.Code
+		wantHidden bool
+	}{
+		{"anonymous", "", body, http.StatusUnauthorized, apperr.CodeUnauthorized, false},
+		{"first report", "u1", body, http.StatusOK, apperr.CodeOK, false},
+		{"same reporter", "u1", forged, http.StatusConflict, apperr.CodeAlreadyReported, false},
+		{"second reporter", "u2", body, http.StatusOK, apperr.CodeOK, true},
+	}
+	for _, tt := range tests {
+		status, resp := serveAs(t, tt.userID, s.HandleReportContent, http.MethodPost, "", tt.body)
+		if status != tt.wantStatus || resp.Code != tt.wantCode {
+			t.Fatalf("%s: status = %d, code = %d, want %d, %d", tt.name, status, resp.Code, tt.wantStatus, tt.wantCode)
+		}
+
+		var contentStatus int
+		if err := s.DB.QueryRow("SELECT status FROM square_content WHERE id = ?", content.ID).Scan(&contentStatus); err != nil {
+			t.Fatal(err)
+		}
+		if hidden := contentStatus == model.SquareStatusHidden; hidden != tt.wantHidden {
+			t.Errorf("%s: content status = %d, want hidden %v", tt.name, contentStatus, tt.wantHidden)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/auth"
+	"github.com/MRsummer/ChangeHairStyle/pkg/governor"
+	"github.com/MRsummer/ChangeHairStyle/pkg/moderation"
+	"github.com/MRsummer/ChangeHairStyle/pkg/notify"
+	"github.com/MRsummer/ChangeHairStyle/pkg/ratelimit"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/wechat"
+	"github.com/MRsummer/ChangeHairStyle/pkg/wxpay"
+)
+
+// Generator 发型生成服务，由火山引擎客户端实现
+// 上游限流时返回 volcengine.ErrRateLimited
+type Generator interface {
+	GenerateHairStyle(imageURL string, prompt string) (string, error)
+	GenerateHairStyleWithBase64(base64Image string, prompt string) (string, error)
+}
+
+// Governor 限制所有实例同时调用上游生成接口的数量，由 *governor.Governor 实现
+type Governor interface {
+	Run(ctx context.Context, req governor.Request, fn func() error) error
+}
+
+// Storage 图片存储，将生成结果转存为永久地址，由腾讯云 COS 客户端实现
+type Storage interface {
+	FetchImage(imageURL string) (string, error)
+}
+
+// Services 处理请求所需的全部依赖，各接口的处理函数是它的方法
+// 依赖在启动时创建一次，所有请求共用同一个连接池和 HTTP 客户端，测试时可替换为假实现
+type Services struct {
+	DB        *sql.DB
+	Repos     *repo.Repos // 数据访问实现，线上为 MySQL，测试时换成内存 SQLite
+	Config    *config.Config
+	Generator Generator
+	Governor  Governor
+	Storage   Storage
+	Moderator moderation.Moderator
+	Wechat    *wechat.Client
+	Notifier  *notify.SubscribeNotifier
+	Pay       *wxpay.Client   // 未配置微信支付商户信息时为 nil
+	RateLimit ratelimit.Store // 限流令牌桶存储，为 nil 时使用进程内存
+	Tokens    *auth.Signer    // 登录凭证签发器，未配置 jwt.secret 时为 nil
+}
+
+// Close 释放依赖持有的资源
+func (s *Services) Close() error {
+	if s.DB == nil {
+		return nil
+	}
+	return s.DB.Close()
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/signin"
+	"github.com/gin-gonic/gin"
+)
+
+// upcomingRewardDays 签到日历中展示的未来奖励天数
+const upcomingRewardDays = 7
+
+// HandleSignIn 处理签到请求
+func (s *Services) HandleSignIn(c *gin.Context) {
+	var req model.SignInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	dbConn := s.DB
+	today := signin.Today()
+	var result *model.SignInResult
+	err := repo.WithTx(dbConn, func(tx repo.Querier) error {
+		var err error
+		result, err = s.Repos.SignIns.SignIn(tx, req.UserID, today, s.Config.SignIn)
+		return err
+	})
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, result)
+}
+
+// HandleRepairSignIn 处理补签请求
+func (s *Services) HandleRepairSignIn(c *gin.Context) {
+	var req model.RepairSignInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	date, err := signin.ParseDate(req.Date)
+	if err != nil {
+		apperr.Abort(c, apperr.ErrInvalidParam.With("date"))
+		return
+	}
+
+	dbConn := s.DB
+	today := signin.Today()
+	var result *model.SignInResult
+	err = repo.WithTx(dbConn, func(tx repo.Querier) error {
+		var err error
+		result, err = s.Repos.SignIns.Repair(tx, req.UserID, date, today, s.Config.SignIn)
+		return err
+	})
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, result)
+}
+
+// HandleGetSignInCalendar 处理获取签到日历请求，返回当月签到日期和未来几天的奖励
+func (s *Services) HandleGetSignInCalendar(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		apperr.Abort(c, apperr.ErrMissingParam.With("user_id"))
+		return
+	}
+
+	today := signin.Today()
+	monthStart := signin.Date{Year: today.Year, Month: today.Month, Day: 1}
+	if monthStr := c.Query("month"); monthStr != "" {
+		d, err := signin.ParseDate(monthStr + "-01")
+		if err != nil {
+			apperr.Abort(c, apperr.ErrInvalidParam.With("month"))
+			return
+		}
+		monthStart = d
+	}
+	monthEnd := signin.DateOf(monthStart.Time().AddDate(0, 1, -1))
+
+	dbConn := s.DB
+	calendar, err := s.Repos.SignIns.Calendar(dbConn, userID, monthStart, monthEnd, today)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	calendar.Month = monthStart.String()[:7]
+
+	// 今日已签到时从明天开始计算，否则从今天开始
+	cfg := s.Config.SignIn
+	if calendar.SignedToday {
+		calendar.Upcoming = signin.Upcoming(cfg, today.AddDays(1), calendar.Streak+1, upcomingRewardDays)
+	} else {
+		calendar.Upcoming = signin.Upcoming(cfg, today, calendar.Streak+1, upcomingRewardDays)
+	}
+
+	apperr.OK(c, calendar)
+}
@@ -1,59 +1,123 @@
 package handler
 
 import (
-	"database/sql"
-	"fmt"
-	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/MRsummer/ChangeHairStyle/pkg/db"
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/logger"
+	"github.com/MRsummer/ChangeHairStyle/pkg/middleware"
 	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/moderation"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/tag"
 	"github.com/gin-gonic/gin"
 )
 
 // HandleShareToSquare 处理分享到广场请求
-func HandleShareToSquare(c *gin.Context) {
+func (s *Services) HandleShareToSquare(c *gin.Context) {
 	var req model.ShareToSquareRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "请求参数错误",
-		})
+		apperr.Abort(c, apperr.ErrBadRequest)
 		return
 	}
 
-	// 分享到广场
+	dbConn := s.DB
+	record, err := s.Repos.Square.GetShareableRecord(dbConn, req.UserID, req.RecordID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	// 用户未填写标签时根据提示词推荐
+	tags := tag.Normalize(req.Tags)
+	if len(tags) == 0 {
+		tags = tag.Suggest(record.Prompt)
+	}
+
+	// 发布前审核提示词、标签和图片
+	moderator := s.Moderator
+	decision, err := moderateRecord(moderator, record, tags)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	if decision.Result == moderation.ResultReject {
+		logger.WithContext(map[string]interface{}{
+			"request_id": middleware.GetRequestID(c),
+			"user_id":    req.UserID,
+			"record_id":  req.RecordID,
+			"reason":     decision.Reason,
+		}).Warn("分享内容未通过审核")
+		apperr.Abort(c, apperr.ErrContentRejected)
+		return
+	}
+
+	// 分享到广场，需要人工审核的内容暂不公开
 	content := &model.SquareContent{
 		UserID:   req.UserID,
 		RecordID: req.RecordID,
+		Status:   model.SquareStatusNormal,
+		Tags:     tags,
 	}
+	if decision.Result == moderation.ResultReview {
+		content.Status = model.SquareStatusReviewing
+	}
+
+	err = repo.WithTx(dbConn, func(tx repo.Querier) error {
+		if err := s.Repos.Square.Share(tx, content); err != nil {
+			return err
+		}
+		if err := s.Repos.Tags.Attach(tx, content.ID, content.Tags); err != nil {
+			return err
+		}
 
-	dbConn := c.MustGet("db").(*sql.DB)
-	if err := db.ShareToSquare(dbConn, content); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": err.Error(),
-		})
+		// 待人工审核的内容记录审核日志，便于追溯
+		if content.Status == model.SquareStatusReviewing {
+			return s.Repos.Moderation.Log(tx, content.ID, "system", model.ModerationActionAutoReview, "发布前审核需人工复核")
+		}
+		return nil
+	})
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    0,
-		"message": "success",
-		"data": gin.H{
-			"content_id": content.ID,
-		},
+	apperr.OK(c, gin.H{
+		"content_id": content.ID,
+		"status":     content.Status,
+		"tags":       content.Tags,
 	})
 }
 
+// moderateRecord 审核发型记录的提示词、标签和图片，返回最严格的结论
+func moderateRecord(moderator moderation.Moderator, record *model.HairStyleRecord, tags []string) (moderation.Decision, error) {
+	text := record.Prompt
+	if len(tags) > 0 {
+		text += " " + strings.Join(tags, " ")
+	}
+	textDecision, err := moderator.ModerateText(text)
+	if err != nil {
+		return moderation.Decision{}, err
+	}
+	if textDecision.Result == moderation.ResultReject {
+		return textDecision, nil
+	}
+
+	imageDecision, err := moderator.ModerateImage(record.ImageURL)
+	if err != nil {
+		return moderation.Decision{}, err
+	}
+
+	return moderation.Merge(textDecision, imageDecision), nil
+}
+
 // HandleGetSquareContents 处理获取广场内容列表请求
-func HandleGetSquareContents(c *gin.Context) {
+func (s *Services) HandleGetSquareContents(c *gin.Context) {
 	userID := c.Query("user_id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "请提供用户ID",
-		})
+		apperr.Abort(c, apperr.ErrMissingParam.With("user_id"))
 		return
 	}
 
@@ -71,66 +135,116 @@ func HandleGetSquareContents(c *gin.Context) {
 		}
 	}
 
+	// 按标签筛选，兼容带 # 的写法
+	var tagName string
+	if tags := tag.Normalize([]string{c.Query("tag")}); len(tags) > 0 {
+		tagName = tags[0]
+	}
+
 	// 获取广场内容列表
-	dbConn := c.MustGet("db").(*sql.DB)
-	response, err := db.GetSquareContents(dbConn, userID, cursor, pageSize)
+	dbConn := s.DB
+	response, err := s.Repos.Square.List(dbConn, userID, tagName, cursor, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": err.Error(),
-		})
+		apperr.Abort(c, err)
+		return
+	}
+	if err := s.Repos.Tags.Fill(dbConn, response.Records); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    0,
-		"message": "success",
-		"data":    response,
-	})
+	apperr.OK(c, response)
 }
 
 // HandleLike 处理点赞请求
-func HandleLike(c *gin.Context) {
+func (s *Services) HandleLike(c *gin.Context) {
 	var req struct {
 		UserID    string `json:"user_id" binding:"required"`
 		ContentID int64  `json:"content_id" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "请求参数错误",
-		})
+		apperr.Abort(c, apperr.ErrBadRequest)
 		return
 	}
 
-	dbConn := c.MustGet("db").(*sql.DB)
-	err := db.LikeContent(dbConn, req.UserID, req.ContentID)
+	dbConn := s.DB
+	var isLiked bool
+	err := repo.WithTx(dbConn, func(tx repo.Querier) error {
+		liked, ownerID, err := s.Repos.Square.ToggleLike(tx, req.UserID, req.ContentID)
+		if err != nil {
+			return err
+		}
+		isLiked = liked
+
+		// 点赞时通知内容作者
+		if liked && ownerID != "" {
+			return s.Repos.Notifications.Create(tx, ownerID, model.NotificationTypeLike, req.ContentID, req.UserID)
+		}
+		return nil
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": fmt.Sprintf("点赞操作失败: %v", err),
-		})
+		apperr.Abort(c, err)
 		return
 	}
 
-	// 获取最新的点赞状态
-	var isLiked bool
-	err = dbConn.QueryRow("SELECT EXISTS(SELECT 1 FROM like_record WHERE user_id = ? AND content_id = ?)",
-		req.UserID, req.ContentID).Scan(&isLiked)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": fmt.Sprintf("获取点赞状态失败: %v", err),
-		})
+	apperr.OK(c, gin.H{
+		"is_liked": isLiked,
+	})
+}
+
+// HandleSuggestTags 处理标签推荐请求，根据记录的提示词推荐标签
+func (s *Services) HandleSuggestTags(c *gin.Context) {
+	prompt := c.Query("prompt")
+	if recordIDStr := c.Query("record_id"); recordIDStr != "" {
+		recordID, err := strconv.ParseInt(recordIDStr, 10, 64)
+		if err != nil {
+			apperr.Abort(c, apperr.ErrInvalidParam.With("record_id"))
+			return
+		}
+
+		dbConn := s.DB
+		record, err := s.Repos.Square.GetShareableRecord(dbConn, c.Query("user_id"), recordID)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		prompt = record.Prompt
+	}
+
+	if prompt == "" {
+		apperr.Abort(c, apperr.ErrMissingParam.With("record_id/prompt"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    0,
-		"message": "success",
-		"data": gin.H{
-			"is_liked": isLiked,
-		},
+	apperr.OK(c, gin.H{
+		"tags": tag.Suggest(prompt),
 	})
 }
+
+// HandleGetTrendingTags 处理获取热门标签请求
+func (s *Services) HandleGetTrendingTags(c *gin.Context) {
+	// 默认统计最近7天的前10个标签
+	days := 7
+	limit := 10
+	if daysStr := c.Query("days"); daysStr != "" {
+		if d, err := strconv.Atoi(daysStr); err == nil && d > 0 && d <= 90 {
+			days = d
+		}
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 50 {
+			limit = l
+		}
+	}
+
+	dbConn := s.DB
+	since := time.Now().AddDate(0, 0, -days)
+	tags, err := s.Repos.Tags.Trending(dbConn, since, limit)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	apperr.OK(c, tags)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/logger"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/signin"
+	"github.com/gin-gonic/gin"
+)
+
+// HandleSaveSubscriptions 处理上报订阅消息授权结果请求
+func (s *Services) HandleSaveSubscriptions(c *gin.Context) {
+	var req model.SaveSubscriptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperr.Abort(c, apperr.ErrBadRequest)
+		return
+	}
+
+	dbConn := s.DB
+	for templateID, result := range req.Results {
+		// 忽略 filter 等非授权结果
+		if result != model.SubscribeResultAccept && result != model.SubscribeResultReject && result != model.SubscribeResultBan {
+			continue
+		}
+		err := repo.WithTx(dbConn, func(tx repo.Querier) error {
+			return s.Repos.Subscriptions.SaveResult(tx, req.UserID, templateID, result)
+		})
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+	}
+
+	apperr.OK(c, nil)
+}
+
+// HandleGetSubscriptions 处理获取订阅消息授权请求，同时返回各场景的模板ID供前端申请授权
+func (s *Services) HandleGetSubscriptions(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID == "" {
+		apperr.Abort(c, apperr.ErrMissingParam.With("user_id"))
+		return
+	}
+
+	dbConn := s.DB
+	subscriptions, err := s.Repos.Subscriptions.List(dbConn, userID)
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	notifier := s.Notifier
+	apperr.OK(c, gin.H{
+		"templates": gin.H{
+			model.SubscribeSceneGenerationDone: notifier.TemplateID(model.SubscribeSceneGenerationDone),
+			model.SubscribeSceneSignInReminder: notifier.TemplateID(model.SubscribeSceneSignInReminder),
+		},
+		"subscriptions": subscriptions,
+	})
+}
+
+// HandleSendSignInReminders 处理发送每日签到提醒请求，由定时触发器调用
+func (s *Services) HandleSendSignInReminders(c *gin.Context) {
+	dbConn := s.DB
+	notifier := s.Notifier
+
+	templateID := notifier.TemplateID(model.SubscribeSceneSignInReminder)
+	if templateID == "" {
+		apperr.Abort(c, apperr.ErrReminderNotConfigured)
+		return
+	}
+
+	today := signin.Today().String()
+	data := map[string]string{
+		"thing1": "今日签到可领取造型币奖励",
+		"thing2": "快来试试新发型吧",
+	}
+
+	var sent, failed int
+	afterUserID := ""
+	for {
+		userIDs, err := s.Repos.Subscriptions.SignInReminderTargets(dbConn, templateID, today, afterUserID, 100)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+
+		for _, userID := range userIDs {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+			ok, err := notifier.Notify(ctx, userID, model.SubscribeSceneSignInReminder, "", data)
+			cancel()
+			if err != nil {
+				failed++
+				logger.WithContext(map[string]interface{}{
+					"user_id": userID,
+				}).WithError(err).Warn("发送签到提醒失败")
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+		afterUserID = userIDs[len(userIDs)-1]
+	}
+
+	apperr.OK(c, gin.H{
+		"sent":   sent,
+		"failed": failed,
+	})
+}
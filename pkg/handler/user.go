@@ -1,15 +1,24 @@
 package handler
 
 import (
-	"database/sql"
-	"encoding/json"
-	"fmt"
-	"net/http"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"os"
+	"regexp"
 	"strconv"
+	"time"
 
-	"github.com/MRsummer/ChangeHairStyle/pkg/db"
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/i18n"
+	"github.com/MRsummer/ChangeHairStyle/pkg/invitecode"
+	"github.com/MRsummer/ChangeHairStyle/pkg/logger"
+	"github.com/MRsummer/ChangeHairStyle/pkg/middleware"
 	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/moderation"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/signin"
 	"github.com/gin-gonic/gin"
 )
 
@@ -29,90 +38,101 @@ func getStatusFromEnv() int {
 }
 
 // HandleUpdateUserInfo 处理更新用户信息请求
-func HandleUpdateUserInfo(c *gin.Context) {
+func (s *Services) HandleUpdateUserInfo(c *gin.Context) {
 	var req model.UpdateUserInfoRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "请求参数错误",
-		})
+		apperr.Abort(c, apperr.ErrBadRequest)
 		return
 	}
 
+	// 语言统一保存为支持的语言代码
+	if req.Language != "" {
+		locale, ok := i18n.Match(req.Language)
+		if !ok {
+			apperr.Abort(c, apperr.ErrInvalidParam.With("language"))
+			return
+		}
+		req.Language = string(locale)
+	}
+
 	// 更新用户信息
 	userInfo := &model.UserInfo{
 		UserID:    req.UserID,
 		Nickname:  req.Nickname,
 		AvatarURL: req.AvatarURL,
+		Language:  req.Language,
 	}
 
-	dbConn := c.MustGet("db").(*sql.DB)
-	if err := db.UpdateUserInfo(dbConn, userInfo); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": err.Error(),
-		})
+	// 审核昵称，需要人工审核的昵称在审核通过前不公开，继续展示原昵称
+	if req.Nickname != "" {
+		moderator := s.Moderator
+		decision, err := moderator.ModerateText(req.Nickname)
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		logCtx := map[string]interface{}{
+			"request_id": middleware.GetRequestID(c),
+			"user_id":    req.UserID,
+			"nickname":   req.Nickname,
+			"reason":     decision.Reason,
+		}
+		switch decision.Result {
+		case moderation.ResultReject:
+			logger.WithContext(logCtx).Warn("昵称未通过审核")
+			apperr.Abort(c, apperr.ErrNicknameRejected)
+			return
+		case moderation.ResultReview:
+			logger.WithContext(logCtx).Warn("昵称需要人工审核")
+			userInfo.PendingNickname = req.Nickname
+		}
+	}
+
+	dbConn := s.DB
+	if err := s.Repos.Users.UpdateProfile(dbConn, userInfo); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    0,
-		"message": "success",
-	})
+	apperr.OK(c, nil)
 }
 
 // HandleUseInviteCode 处理使用邀请码请求
-func HandleUseInviteCode(c *gin.Context) {
+func (s *Services) HandleUseInviteCode(c *gin.Context) {
 	var req model.UseInviteCodeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "请求参数错误",
-		})
+		apperr.Abort(c, apperr.ErrBadRequest)
 		return
 	}
 
-	dbConn := c.MustGet("db").(*sql.DB)
-	err := db.UseInviteCode(dbConn, req.UserID, req.Code)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": err.Error(),
-		})
-		return
+	relation := &model.InviteRelation{
+		InviteeID:  req.UserID,
+		InviteCode: invitecode.Normalize(req.Code),
+		DeviceID:   deviceFingerprint(c),
+		IP:         c.ClientIP(),
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    0,
-		"message": "success",
+	dbConn := s.DB
+	err := repo.WithTx(dbConn, func(tx repo.Querier) error {
+		return s.Repos.Invites.Bind(tx, relation, s.Config.Invite)
 	})
-}
-
-// HandleSignIn 处理签到请求
-func HandleSignIn(c *gin.Context) {
-	var req model.SignInRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "请求参数错误",
-		})
-		return
-	}
-
-	dbConn := c.MustGet("db").(*sql.DB)
-	err := db.SignIn(dbConn, req.UserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": err.Error(),
-		})
+		apperr.Abort(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    0,
-		"message": "success",
-	})
+	apperr.OK(c, nil)
+}
+
+// netTypePattern 微信 User-Agent 中的网络类型，切换网络时会变化，不参与设备指纹
+var netTypePattern = regexp.MustCompile(`\s*NetType/\S+`)
+
+// deviceFingerprint 根据 User-Agent（机型、系统和微信版本）和客户端IP计算设备指纹，用于邀请防刷
+// 设备标识不能取自请求参数，否则客户端每次换一个值即可绕过同设备限制
+func deviceFingerprint(c *gin.Context) string {
+	userAgent := netTypePattern.ReplaceAllString(c.GetHeader("User-Agent"), "")
+	sum := sha256.Sum256([]byte(userAgent + "|" + c.ClientIP()))
+	return hex.EncodeToString(sum[:])
 }
 
 // WxLoginRequest 微信登录请求
@@ -123,66 +143,33 @@ type WxLoginRequest struct {
 }
 
 // HandleWxLogin 处理微信登录请求
-func HandleWxLogin(c *gin.Context) {
+func (s *Services) HandleWxLogin(c *gin.Context) {
 	var req model.WxLoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "请求参数错误",
-		})
+		apperr.Abort(c, apperr.ErrBadRequest)
 		return
 	}
 
 	// 调用微信登录接口获取openid
-	appID := os.Getenv("WX_APP_ID")
-	appSecret := os.Getenv("WX_APP_SECRET")
-	url := fmt.Sprintf("https://api.weixin.qq.com/sns/jscode2session?appid=%s&secret=%s&js_code=%s&grant_type=authorization_code",
-		appID, appSecret, req.Code)
-
-	resp, err := http.Get(url)
+	wxClient := s.Wechat
+	session, err := wxClient.Code2Session(c.Request.Context(), req.Code)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": fmt.Sprintf("调用微信登录接口失败: %v", err),
-		})
+		apperr.Abort(c, err)
 		return
 	}
-	defer resp.Body.Close()
 
-	var wxResp struct {
-		OpenID     string `json:"openid"`
-		SessionKey string `json:"session_key"`
-		ErrCode    int    `json:"errcode"`
-		ErrMsg     string `json:"errmsg"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&wxResp); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": fmt.Sprintf("解析微信登录响应失败: %v", err),
-		})
-		return
-	}
+	// 生成用户ID（使用openid作为用户ID）
+	userID := session.OpenID
 
-	if wxResp.ErrCode != 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": fmt.Sprintf("微信登录失败: %s", wxResp.ErrMsg),
-		})
+	// 查询用户信息，返回的余额不含已过期的coin
+	dbConn := s.DB
+	if err := s.expireUserCoins(userID); err != nil {
+		apperr.Abort(c, err)
 		return
 	}
-
-	// 生成用户ID（使用openid作为用户ID）
-	userID := wxResp.OpenID
-
-	// 查询用户信息
-	dbConn := c.MustGet("db").(*sql.DB)
-	userInfo, err := db.GetUserInfo(dbConn, userID)
+	userInfo, err := s.Repos.Users.Get(dbConn, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": fmt.Sprintf("获取用户信息失败: %v", err),
-		})
+		apperr.Abort(c, err)
 		return
 	}
 
@@ -192,73 +179,108 @@ func HandleWxLogin(c *gin.Context) {
 			UserID: userID,
 			Coin:   60, // 初始金币
 		}
-		if err := db.CreateUser(dbConn, userInfo); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": fmt.Sprintf("创建用户失败: %v", err),
-			})
+		if err := s.createUser(userInfo); err != nil {
+			apperr.Abort(c, err)
 			return
 		}
 	}
 
-	// 返回登录响应
-	c.JSON(http.StatusOK, gin.H{
-		"code":    0,
-		"message": "success",
-		"data": model.WxLoginResponse{
-			UserID:         userInfo.UserID,
-			Nickname:       userInfo.Nickname,
-			AvatarURL:      userInfo.AvatarURL,
-			Coin:           userInfo.Coin,
-			Code:           userInfo.InviteCode,
-			UsedCode:       userInfo.UsedInviteCode,
-			LastSignInDate: userInfo.LastSignInDate,
-			Status:         getStatusFromEnv(),
-		},
-	})
+	// 签发登录凭证
+	response := model.WxLoginResponse{
+		UserID:         userInfo.UserID,
+		Nickname:       userInfo.Nickname,
+		AvatarURL:      userInfo.AvatarURL,
+		Coin:           userInfo.Coin,
+		Code:           userInfo.InviteCode,
+		UsedCode:       userInfo.UsedInviteCode,
+		LastSignInDate: userInfo.LastSignInDate,
+		Status:         getStatusFromEnv(),
+	}
+	if s.Tokens != nil {
+		token, expiresAt, err := s.Tokens.Issue(userInfo.UserID, time.Now())
+		if err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		response.Token = token
+		response.TokenExpiresAt = &expiresAt
+	}
+
+	apperr.OK(c, response)
+}
+
+// maxInviteCodeAttempts 生成邀请码时遇到重复的最大重试次数
+const maxInviteCodeAttempts = 5
+
+// createUser 创建新用户，邀请码重复时重新生成
+func (s *Services) createUser(userInfo *model.UserInfo) error {
+	for attempt := 1; ; attempt++ {
+		inviteCode, err := invitecode.Generate()
+		if err != nil {
+			return err
+		}
+
+		userInfo.InviteCode = inviteCode
+		err = s.Repos.Users.Create(s.DB, userInfo)
+		if errors.Is(err, repo.ErrInviteCodeTaken) && attempt < maxInviteCodeAttempts {
+			continue
+		}
+		if err != nil {
+			userInfo.InviteCode = ""
+		}
+		return err
+	}
 }
 
 // HandleGetUserInfo 处理获取用户信息请求
-func HandleGetUserInfo(c *gin.Context) {
+func (s *Services) HandleGetUserInfo(c *gin.Context) {
 	userID := c.Query("user_id")
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "请求参数错误：user_id不能为空",
-		})
+		apperr.Abort(c, apperr.ErrMissingParam.With("user_id"))
 		return
 	}
 
-	dbConn := c.MustGet("db").(*sql.DB)
-	userInfo, err := db.GetUserInfo(dbConn, userID)
+	// 先作废已过期的coin，余额和即将过期的coin都只统计有效批次
+	dbConn := s.DB
+	if err := s.expireUserCoins(userID); err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+	userInfo, err := s.Repos.Users.Get(dbConn, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": fmt.Sprintf("获取用户信息失败: %v", err),
-		})
+		apperr.Abort(c, err)
 		return
 	}
 
 	if userInfo == nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "用户不存在",
-		})
+		apperr.Abort(c, apperr.ErrUserNotFound)
+		return
+	}
+
+	membership, err := s.getMembershipInfo(userID, config.Now())
+	if err != nil {
+		apperr.Abort(c, err)
+		return
+	}
+
+	expiringCoin, err := s.Repos.Ledger.Expiring(dbConn, userID, signin.Today())
+	if err != nil {
+		apperr.Abort(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    0,
-		"message": "success",
-		"data": model.GetUserInfoResponse{
-			UserID:         userInfo.UserID,
-			Nickname:       userInfo.Nickname,
-			AvatarURL:      userInfo.AvatarURL,
-			Coin:           userInfo.Coin,
-			Code:           userInfo.InviteCode,
-			UsedCode:       userInfo.UsedInviteCode,
-			LastSignInDate: userInfo.LastSignInDate,
-			Status:         getStatusFromEnv(),
-		},
+	apperr.OK(c, model.GetUserInfoResponse{
+		UserID:          userInfo.UserID,
+		Nickname:        userInfo.Nickname,
+		PendingNickname: userInfo.PendingNickname,
+		AvatarURL:       userInfo.AvatarURL,
+		Coin:            userInfo.Coin,
+		Code:            userInfo.InviteCode,
+		UsedCode:        userInfo.UsedInviteCode,
+		LastSignInDate:  userInfo.LastSignInDate,
+		Status:          getStatusFromEnv(),
+		Language:        userInfo.Language,
+		Membership:      membership,
+		ExpiringCoin:    expiringCoin,
 	})
 }
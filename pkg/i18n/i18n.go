@@ -0,0 +1,131 @@
+// Package i18n 确定响应使用的语言，提示信息的翻译见 apperr 中按错误码划分的文案表
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale 支持的语言
+type Locale string
+
+const (
+	Chinese Locale = "zh"
+	English Locale = "en"
+)
+
+// Default 无法确定语言时使用中文
+const Default = Chinese
+
+// Match 将 zh-CN、zh_CN、en-US 等语言标签匹配到支持的语言，只比较主语言
+func Match(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	switch Locale(tag) {
+	case Chinese, English:
+		return Locale(tag), true
+	}
+	return "", false
+}
+
+// FromAcceptLanguage 按权重从高到低选择 Accept-Language 中第一个支持的语言
+func FromAcceptLanguage(header string) (Locale, bool) {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	for _, c := range candidates {
+		if locale, ok := Match(c.tag); ok {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// ProfileLanguage 查询用户资料中设置的语言，未设置时返回空字符串
+type ProfileLanguage func(userID string) (string, error)
+
+// UserID 返回请求已验证的用户ID，未登录时返回空字符串
+type UserID func(c *gin.Context) string
+
+// source 查询用户资料语言所需的依赖
+type source struct {
+	userID  UserID
+	profile ProfileLanguage
+}
+
+const (
+	localeKey = "i18n_locale"
+	sourceKey = "i18n_source"
+)
+
+// Middleware 记录请求可用的语言来源，实际的语言在第一次调用 FromContext 时确定
+// 只有返回错误等需要提示信息的请求才会查询用户资料；
+// 用户ID只取已验证的身份，请求参数中的 user_id 可以随意填写，不能用来查询他人的资料
+func Middleware(userID UserID, profile ProfileLanguage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID != nil && profile != nil {
+			c.Set(sourceKey, source{userID: userID, profile: profile})
+		}
+		c.Next()
+	}
+}
+
+// FromContext 返回请求使用的语言
+// 优先使用已登录用户资料中设置的语言，其次是 Accept-Language，都没有时为 Default
+func FromContext(c *gin.Context) Locale {
+	if v, ok := c.Get(localeKey); ok {
+		return v.(Locale)
+	}
+
+	locale := Default
+	if l, ok := fromProfile(c); ok {
+		locale = l
+	} else if l, ok := FromAcceptLanguage(c.GetHeader("Accept-Language")); ok {
+		locale = l
+	}
+	c.Set(localeKey, locale)
+	return locale
+}
+
+// fromProfile 查询用户资料中的语言，查询失败时忽略
+func fromProfile(c *gin.Context) (Locale, bool) {
+	v, ok := c.Get(sourceKey)
+	if !ok {
+		return "", false
+	}
+	src := v.(source)
+	userID := src.userID(c)
+	if userID == "" {
+		return "", false
+	}
+	language, err := src.profile(userID)
+	if err != nil || language == "" {
+		return "", false
+	}
+	return Match(language)
+}
@@ -0,0 +1,73 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFromAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Locale
+		ok     bool
+	}{
+		{"en-US,en;q=0.9", English, true},
+		{"fr-FR,zh-CN;q=0.8,en;q=0.5", Chinese, true},
+		{"zh;q=0.3,en;q=0.7", English, true},
+		{"fr-FR", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := FromAcceptLanguage(tt.header)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("FromAcceptLanguage(%q) = %q, %v, want %q, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	profiles := map[string]string{"alice": "en", "bob": "zh"}
+	profile := func(userID string) (string, error) {
+		return profiles[userID], nil
+	}
+
+	tests := []struct {
+		name           string
+		verifiedUser   string
+		query          string
+		acceptLanguage string
+		want           Locale
+	}{
+		{"verified user profile", "alice", "", "zh-CN", English},
+		{"profile over header", "bob", "", "en-US", Chinese},
+		{"query user_id ignored", "", "?user_id=alice", "", Chinese},
+		{"query user_id ignored with header", "", "?user_id=bob", "en", English},
+		{"user without profile language", "carol", "", "en", English},
+		{"default", "", "", "", Default},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			verified := func(c *gin.Context) string { return tt.verifiedUser }
+			r.Use(Middleware(verified, profile))
+			var got Locale
+			r.GET("/", func(c *gin.Context) {
+				got = FromContext(c)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("FromContext() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package invitecode
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Alphabet 自动生成邀请码使用的字符集，去掉了容易混淆的 0/O 和 1/I
+const Alphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// Length 自动生成的邀请码长度
+const Length = 6
+
+// 自定义邀请码长度限制，与数据库字段长度保持一致
+const (
+	MinVanityLength = 4
+	MaxVanityLength = 16
+)
+
+// ErrInvalidVanity 自定义邀请码格式错误
+var ErrInvalidVanity = fmt.Errorf("自定义邀请码需为%d-%d位字母或数字", MinVanityLength, MaxVanityLength)
+
+// Generate 使用加密随机数生成邀请码
+func Generate() (string, error) {
+	return GenerateN(Length)
+}
+
+// GenerateN 使用加密随机数生成指定长度的码，兑换码等也使用同一字符集
+func GenerateN(length int) (string, error) {
+	base := big.NewInt(int64(len(Alphabet)))
+	b := make([]byte, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, base)
+		if err != nil {
+			return "", fmt.Errorf("生成随机码失败: %v", err)
+		}
+		b[i] = Alphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// Normalize 规范化用户输入的邀请码，去掉首尾空白并转为大写
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ValidateVanity 校验自定义邀请码，返回规范化后的邀请码
+// 自定义邀请码由运营指定，允许使用全部字母和数字
+func ValidateVanity(code string) (string, error) {
+	code = Normalize(code)
+	if len(code) < MinVanityLength || len(code) > MaxVanityLength {
+		return "", ErrInvalidVanity
+	}
+	for _, r := range code {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return "", ErrInvalidVanity
+		}
+	}
+	return code, nil
+}
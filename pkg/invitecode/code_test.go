@@ -0,0 +1,63 @@
+package invitecode
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	// 生成的码只使用字符集中的字符，不会出现容易混淆的 0/O 和 1/I
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		code, err := Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != Length {
+			t.Fatalf("Generate() = %q, want %d characters", code, Length)
+		}
+		for _, r := range code {
+			if !strings.ContainsRune(Alphabet, r) {
+				t.Fatalf("Generate() = %q, contains %q outside the alphabet", code, r)
+			}
+		}
+		seen[code] = true
+	}
+	if len(seen) < 99 {
+		t.Errorf("Generate() returned %d distinct codes out of 100", len(seen))
+	}
+
+	code, err := GenerateN(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 12 {
+		t.Errorf("GenerateN(12) = %q, want 12 characters", code)
+	}
+}
+
+func TestValidateVanity(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{"summer", "SUMMER", false},
+		{"  Vip2026 ", "VIP2026", false},
+		// 自定义邀请码允许使用自动生成时排除的字符
+		{"O0I1", "O0I1", false},
+		{"ABCDEFGHIJKLMNOP", "ABCDEFGHIJKLMNOP", false},
+		{"ABC", "", true},
+		{"ABCDEFGHIJKLMNOPQ", "", true},
+		{"AB-CD", "", true},
+		{"AB CD", "", true},
+		{"发型师小王", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ValidateVanity(tt.code)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ValidateVanity(%q) = %q, %v, want %q, error %v", tt.code, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		tokens       string
+		legacyToken  string
+		provided     string
+		wantStatus   int
+		wantOperator string
+	}{
+		{"named token", "alice:tok1, bob:tok2", "", "tok2", http.StatusOK, "bob"},
+		{"legacy token", "", "legacy", "legacy", http.StatusOK, "admin"},
+		{"legacy token with named tokens", "alice:tok1", "legacy", "legacy", http.StatusOK, "admin"},
+		{"wrong token", "alice:tok1", "legacy", "tok2", http.StatusUnauthorized, ""},
+		{"missing token", "alice:tok1", "", "", http.StatusUnauthorized, ""},
+		{"malformed entries ignored", "tok1,:tok2,carol:", "", "tok1", http.StatusUnauthorized, ""},
+		{"not configured", "", "", "", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ADMIN_TOKENS", tt.tokens)
+			t.Setenv("ADMIN_TOKEN", tt.legacyToken)
+
+			r := gin.New()
+			r.Use(apperr.Middleware(nil), AdminAuthMiddleware())
+			var operator string
+			r.GET("/", func(c *gin.Context) {
+				operator = GetAdminOperator(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.provided != "" {
+				req.Header.Set("X-Admin-Token", tt.provided)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if operator != tt.wantOperator {
+				t.Errorf("operator = %q, want %q", operator, tt.wantOperator)
+			}
+		})
+	}
+}
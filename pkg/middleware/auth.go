@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// UserAuthMiddleware 校验 Authorization: Bearer 请求头中的登录凭证，通过后记录已验证的用户ID
+// 没有携带凭证的请求直接放行，按匿名请求处理；凭证无效时返回 401
+// signer 为 nil 表示未开启登录凭证，所有请求都按匿名处理
+func UserAuthMiddleware(signer *auth.Signer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if signer == nil || !ok || token == "" {
+			c.Next()
+			return
+		}
+
+		userID, err := signer.Verify(token, time.Now())
+		if err != nil {
+			apperr.Abort(c, apperr.ErrUnauthorized.Wrap(err))
+			return
+		}
+		SetVerifiedUserID(c, userID)
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/auth"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestUserAuthMiddleware(t *testing.T) {
+	signer, err := auth.NewSigner(config.JWTConfig{Secret: "secret", Expire: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := signer.Issue("user-1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		signer        *auth.Signer
+		authorization string
+		wantStatus    int
+		wantUserID    string
+	}{
+		{"valid token", signer, "Bearer " + token, http.StatusOK, "user-1"},
+		{"anonymous", signer, "", http.StatusOK, ""},
+		{"other scheme", signer, "Basic dXNlcjpwYXNz", http.StatusOK, ""},
+		{"invalid token", signer, "Bearer " + token + "x", http.StatusUnauthorized, ""},
+		{"tokens disabled", nil, "Bearer " + token, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(apperr.Middleware(nil), UserAuthMiddleware(tt.signer))
+			var userID string
+			r.GET("/", func(c *gin.Context) {
+				userID = GetVerifiedUserID(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if userID != tt.wantUserID {
+				t.Errorf("verified user = %q, want %q", userID, tt.wantUserID)
+			}
+		})
+	}
+}
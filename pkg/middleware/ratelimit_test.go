@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/auth"
+	"github.com/MRsummer/ChangeHairStyle/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// newLimitedEngine 创建一个只信任 trusted 中代理的引擎，接口按 limit 限流
+func newLimitedEngine(t *testing.T, signer *auth.Signer, trusted []string, limit config.RouteLimit) *gin.Engine {
+	t.Helper()
+	r := gin.New()
+	if err := r.SetTrustedProxies(trusted); err != nil {
+		t.Fatal(err)
+	}
+	limiter := NewRateLimiter(ratelimit.NewMemoryStore(), true)
+	r.Use(apperr.Middleware(nil), UserAuthMiddleware(signer))
+	r.GET("/", limiter.Limit("test", limit), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func serve(r *gin.Engine, remoteAddr string, header http.Header) int {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRateLimitPerVerifiedUser(t *testing.T) {
+	signer, _ := auth.NewSigner(config.JWTConfig{Secret: "secret"})
+	alice, _, _ := signer.Issue("alice", time.Now())
+	bob, _, _ := signer.Issue("bob", time.Now())
+	r := newLimitedEngine(t, signer, nil, config.RouteLimit{
+		PerUser: config.BucketLimit{PerMinute: 1, Burst: 1},
+	})
+
+	// 同一用户从不同IP请求仍共用一个桶
+	aliceHeader := http.Header{"Authorization": {"Bearer " + alice}}
+	if code := serve(r, "10.0.0.1:1234", aliceHeader); code != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200", code)
+	}
+	if code := serve(r, "10.0.0.2:1234", aliceHeader); code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429", code)
+	}
+	if code := serve(r, "10.0.0.1:1234", http.Header{"Authorization": {"Bearer " + bob}}); code != http.StatusOK {
+		t.Fatalf("other user status = %d, want 200", code)
+	}
+	// 未登录的请求不受用户桶限制
+	if code := serve(r, "10.0.0.1:1234", nil); code != http.StatusOK {
+		t.Fatalf("anonymous status = %d, want 200", code)
+	}
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	limit := config.RouteLimit{PerIP: config.BucketLimit{PerMinute: 1, Burst: 1}}
+
+	t.Run("untrusted peer", func(t *testing.T) {
+		r := newLimitedEngine(t, nil, nil, limit)
+		if code := serve(r, "203.0.113.7:1234", http.Header{"X-Forwarded-For": {"1.1.1.1"}}); code != http.StatusOK {
+			t.Fatalf("first request status = %d, want 200", code)
+		}
+		// 伪造不同的 X-Forwarded-For 不能绕过IP限流
+		if code := serve(r, "203.0.113.7:1234", http.Header{"X-Forwarded-For": {"2.2.2.2"}}); code != http.StatusTooManyRequests {
+			t.Fatalf("spoofed request status = %d, want 429", code)
+		}
+	})
+
+	t.Run("trusted proxy", func(t *testing.T) {
+		r := newLimitedEngine(t, nil, []string{"10.0.0.0/8"}, limit)
+		if code := serve(r, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"1.1.1.1"}}); code != http.StatusOK {
+			t.Fatalf("first client status = %d, want 200", code)
+		}
+		// 可信代理转发的不同客户端分别限流
+		if code := serve(r, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"2.2.2.2"}}); code != http.StatusOK {
+			t.Fatalf("second client status = %d, want 200", code)
+		}
+		if code := serve(r, "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"1.1.1.1"}}); code != http.StatusTooManyRequests {
+			t.Fatalf("repeated client status = %d, want 429", code)
+		}
+	})
+}
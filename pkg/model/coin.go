@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// coin变动来源
+const (
+	CoinSourcePurchase     = "purchase"      // 充值
+	CoinSourceGeneration   = "generation"    // 生成发型消耗
+	CoinSourceRedeem       = "redeem"        // 兑换码
+	CoinSourceRefund       = "refund"        // 退款扣回
+	CoinSourceRefundRevert = "refund_revert" // 退款失败退回
+	CoinSourceSignIn       = "sign_in"       // 签到奖励
+	CoinSourceInvite       = "invite"        // 邀请奖励
+	CoinSourceExpire       = "expire"        // 促销coin过期
+)
+
+// CoinLedger coin流水
+type CoinLedger struct {
+	ID        int64     `json:"id"`
+	UserID    string    `json:"user_id"`
+	Amount    int       `json:"amount"`  // 变动数量，增加为正，扣除为负
+	Balance   int       `json:"balance"` // 变动后余额
+	Source    string    `json:"source"`
+	RefID     string    `json:"ref_id"` // 关联的业务单号
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CoinExpiry 即将过期的coin，用于提示“N个造型币将于某日过期”
+type CoinExpiry struct {
+	Amount     int    `json:"amount"`
+	ExpireDate string `json:"expire_date"` // 当天结束后过期
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// FavoriteCollection 收藏夹
+type FavoriteCollection struct {
+	ID            int64     `json:"id"`
+	UserID        string    `json:"user_id"`
+	Name          string    `json:"name"`
+	FavoriteCount int       `json:"favorite_count"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Favorite 收藏记录，CollectionID 为0表示默认收藏夹
+type Favorite struct {
+	ID           int64     `json:"id"`
+	UserID       string    `json:"user_id"`
+	ContentID    int64     `json:"content_id"`
+	CollectionID int64     `json:"collection_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// FavoriteItem 收藏列表条目
+type FavoriteItem struct {
+	FavoriteID   int64          `json:"favorite_id"`
+	CollectionID int64          `json:"collection_id"`
+	FavoritedAt  time.Time      `json:"favorited_at"`
+	Content      *SquareContent `json:"content"`
+}
+
+// FavoriteListResponse 收藏列表响应
+type FavoriteListResponse struct {
+	Records    []FavoriteItem `json:"records"`
+	NextCursor int64          `json:"next_cursor"`
+}
+
+// AddFavoriteRequest 收藏请求，内容已收藏时移动到指定收藏夹
+type AddFavoriteRequest struct {
+	UserID       string `json:"user_id" binding:"required"`
+	ContentID    int64  `json:"content_id" binding:"required"`
+	CollectionID int64  `json:"collection_id"`
+}
+
+// RemoveFavoriteRequest 取消收藏请求
+type RemoveFavoriteRequest struct {
+	UserID    string `json:"user_id" binding:"required"`
+	ContentID int64  `json:"content_id" binding:"required"`
+}
+
+// CreateCollectionRequest 创建收藏夹请求
+type CreateCollectionRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Name   string `json:"name" binding:"required"`
+}
+
+// DeleteCollectionRequest 删除收藏夹请求
+type DeleteCollectionRequest struct {
+	UserID       string `json:"user_id" binding:"required"`
+	CollectionID int64  `json:"collection_id" binding:"required"`
+}
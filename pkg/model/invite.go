@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// 邀请关系状态
+const (
+	InviteStatusPending  = 0 // 等待被邀请人完成首次生成
+	InviteStatusRewarded = 1 // 已发放奖励
+	InviteStatusBlocked  = 2 // 命中防刷规则，不发放奖励
+	InviteStatusCapped   = 3 // 邀请人奖励已达上限，仅被邀请人获得奖励
+)
+
+// InviteRelation 邀请关系
+type InviteRelation struct {
+	ID         int64      `json:"id"`
+	InviterID  string     `json:"inviter_id"`
+	InviteeID  string     `json:"invitee_id"`
+	InviteCode string     `json:"invite_code"`
+	DeviceID   string     `json:"device_id"`
+	IP         string     `json:"ip"`
+	Status     int        `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RewardedAt *time.Time `json:"rewarded_at,omitempty"`
+}
+
+// InviteeInfo 邀请统计中的被邀请人
+type InviteeInfo struct {
+	RelationID int64     `json:"relation_id"`
+	Nickname   string    `json:"nickname"`
+	AvatarURL  string    `json:"avatar_url"`
+	Status     int       `json:"status"`
+	Reward     int       `json:"reward"` // 邀请人从该被邀请人获得的奖励
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// InviteStatsResponse 邀请统计响应
+type InviteStatsResponse struct {
+	TotalInvitees    int           `json:"total_invitees"`
+	RewardedInvitees int           `json:"rewarded_invitees"`
+	PendingInvitees  int           `json:"pending_invitees"`
+	DirectReward     int           `json:"direct_reward"`   // 直接邀请获得的奖励
+	IndirectReward   int           `json:"indirect_reward"` // 间接邀请获得的奖励
+	Invitees         []InviteeInfo `json:"invitees"`
+	NextCursor       int64         `json:"next_cursor"`
+}
+
+// SetInviteCodeRequest 设置自定义邀请码请求
+type SetInviteCodeRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Code   string `json:"code" binding:"required"`
+}
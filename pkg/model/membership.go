@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Membership 用户会员
+type Membership struct {
+	UserID    string    `json:"user_id"`
+	Tier      string    `json:"tier"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MembershipInfo 用户信息中展示的会员状态
+type MembershipInfo struct {
+	Tier            string    `json:"tier"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	DailyFreeQuota  int       `json:"daily_free_quota"`
+	FreeQuotaLeft   int       `json:"free_quota_left"`
+	DiscountPercent int       `json:"discount_percent"`
+	Priority        bool      `json:"priority"`
+}
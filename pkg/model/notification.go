@@ -0,0 +1,91 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/i18n"
+)
+
+// 通知类型
+const (
+	NotificationTypeLike           = "like"            // 点赞了你的造型
+	NotificationTypeComment        = "comment"         // 评论了你的造型
+	NotificationTypeFollow         = "follow"          // 关注了你
+	NotificationTypeGenerationDone = "generation_done" // 发型生成完成
+	NotificationTypeContentRemoved = "content_removed" // 分享内容被下架
+)
+
+// Notification 站内通知，同一对象上未读的同类通知会聚合为一条
+type Notification struct {
+	ID            int64     `json:"id"`
+	UserID        string    `json:"user_id"`
+	Type          string    `json:"type"`
+	ContentID     int64     `json:"content_id"` // 关联的广场内容或生成记录ID
+	ActorCount    int       `json:"actor_count"`
+	LastActorID   string    `json:"last_actor_id"`
+	LastActorName string    `json:"last_actor_name"`
+	IsRead        bool      `json:"is_read"`
+	Message       string    `json:"message"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// notificationMessages 各语言的通知文案，%s 为操作人
+var notificationMessages = map[i18n.Locale]map[string]string{
+	i18n.Chinese: {
+		NotificationTypeLike:           "%s赞了你的造型",
+		NotificationTypeComment:        "%s评论了你的造型",
+		NotificationTypeFollow:         "%s关注了你",
+		NotificationTypeGenerationDone: "你的新发型已生成，快去看看吧",
+		NotificationTypeContentRemoved: "你分享的造型因违反社区规范已被下架",
+	},
+	i18n.English: {
+		NotificationTypeLike:           "%s liked your look",
+		NotificationTypeComment:        "%s commented on your look",
+		NotificationTypeFollow:         "%s followed you",
+		NotificationTypeGenerationDone: "Your new hairstyle is ready, take a look",
+		NotificationTypeContentRemoved: "Your shared look was removed for violating the community guidelines",
+	},
+}
+
+// BuildMessage 根据通知类型和聚合人数生成指定语言的展示文案，不支持的语言使用 i18n.Default
+func (n *Notification) BuildMessage(locale i18n.Locale) string {
+	messages, ok := notificationMessages[locale]
+	if !ok {
+		locale, messages = i18n.Default, notificationMessages[i18n.Default]
+	}
+	format, ok := messages[n.Type]
+	if !ok {
+		return ""
+	}
+	if !strings.Contains(format, "%s") {
+		return format
+	}
+	return fmt.Sprintf(format, n.actor(locale))
+}
+
+// actor 聚合后的操作人，例如 "小明等3人"
+func (n *Notification) actor(locale i18n.Locale) string {
+	if n.ActorCount <= 1 {
+		return n.LastActorName
+	}
+	if locale == i18n.English {
+		return fmt.Sprintf("%s and %d others", n.LastActorName, n.ActorCount-1)
+	}
+	return fmt.Sprintf("%s等%d人", n.LastActorName, n.ActorCount)
+}
+
+// NotificationListResponse 通知列表响应
+type NotificationListResponse struct {
+	Records     []Notification `json:"records"`
+	NextCursor  int64          `json:"next_cursor"`
+	UnreadCount int            `json:"unread_count"`
+}
+
+// MarkNotificationsReadRequest 标记通知已读请求，IDs 为空时标记全部已读
+type MarkNotificationsReadRequest struct {
+	UserID string  `json:"user_id" binding:"required"`
+	IDs    []int64 `json:"ids"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/i18n"
+)
+
+func TestNotificationBuildMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      Notification
+		locale i18n.Locale
+		want   string
+	}{
+		{"like zh", Notification{Type: NotificationTypeLike, ActorCount: 1, LastActorName: "小明"}, i18n.Chinese, "小明赞了你的造型"},
+		{"like en", Notification{Type: NotificationTypeLike, ActorCount: 1, LastActorName: "Tom"}, i18n.English, "Tom liked your look"},
+		{"aggregated zh", Notification{Type: NotificationTypeLike, ActorCount: 3, LastActorName: "小明"}, i18n.Chinese, "小明等3人赞了你的造型"},
+		{"aggregated en", Notification{Type: NotificationTypeFollow, ActorCount: 3, LastActorName: "Tom"}, i18n.English, "Tom and 2 others followed you"},
+		{"no actor en", Notification{Type: NotificationTypeContentRemoved}, i18n.English, "Your shared look was removed for violating the community guidelines"},
+		{"unsupported locale", Notification{Type: NotificationTypeGenerationDone}, "fr", "你的新发型已生成，快去看看吧"},
+		{"unknown type", Notification{Type: "unknown"}, i18n.English, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.n.BuildMessage(tt.locale); got != tt.want {
+				t.Errorf("BuildMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
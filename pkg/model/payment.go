@@ -0,0 +1,82 @@
+package model
+
+import "time"
+
+// 支付订单状态
+const (
+	PayOrderStatusPending   = 0 // 待支付
+	PayOrderStatusPaid      = 1 // 已支付，coin已到账
+	PayOrderStatusClosed    = 2 // 已关闭
+	PayOrderStatusRefunding = 3 // 退款中
+	PayOrderStatusRefunded  = 4 // 已退款
+)
+
+// 退款状态
+const (
+	PayRefundStatusProcessing = 0 // 退款处理中
+	PayRefundStatusSuccess    = 1 // 退款成功
+	PayRefundStatusFailed     = 2 // 退款失败，coin已退回
+)
+
+// CoinProduct coin充值商品
+type CoinProduct struct {
+	ID             int64  `json:"id"`
+	Name           string `json:"name"`
+	Coins          int    `json:"coins"`
+	BonusCoins     int    `json:"bonus_coins"`               // 额外赠送的coin
+	Price          int    `json:"price"`                     // 价格，单位为分
+	MembershipTier string `json:"membership_tier,omitempty"` // 购买后开通的会员等级，为空表示coin充值商品
+	MembershipDays int    `json:"membership_days,omitempty"` // 购买后开通的会员天数
+}
+
+// PayOrder 支付订单
+type PayOrder struct {
+	ID             int64      `json:"id"`
+	OutTradeNo     string     `json:"out_trade_no"`
+	UserID         string     `json:"user_id"`
+	ProductID      int64      `json:"product_id"`
+	Description    string     `json:"description"`
+	Coins          int        `json:"coins"`                     // 支付成功后到账的coin，包含赠送部分
+	Amount         int        `json:"amount"`                    // 订单金额，单位为分
+	MembershipTier string     `json:"membership_tier,omitempty"` // 支付成功后开通的会员等级
+	MembershipDays int        `json:"membership_days,omitempty"` // 支付成功后开通的会员天数
+	Status         int        `json:"status"`
+	PrepayID       string     `json:"-"`
+	TransactionID  string     `json:"transaction_id,omitempty"`
+	PaidAt         *time.Time `json:"paid_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// PayRefund 退款单
+type PayRefund struct {
+	ID          int64     `json:"id"`
+	OutRefundNo string    `json:"out_refund_no"`
+	OutTradeNo  string    `json:"out_trade_no"`
+	UserID      string    `json:"user_id"`
+	Amount      int       `json:"amount"` // 退款金额，单位为分
+	Coins       int       `json:"coins"`  // 退款扣回的coin
+	Status      int       `json:"status"`
+	RefundID    string    `json:"refund_id,omitempty"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PayOrderListResponse 支付订单列表响应
+type PayOrderListResponse struct {
+	Records    []PayOrder `json:"records"`
+	NextCursor int64      `json:"next_cursor"`
+}
+
+// CreatePayOrderRequest 创建支付订单请求
+type CreatePayOrderRequest struct {
+	UserID    string `json:"user_id" binding:"required"`
+	ProductID int64  `json:"product_id" binding:"required"`
+}
+
+// RefundPayOrderRequest 退款请求
+type RefundPayOrderRequest struct {
+	OutTradeNo string `json:"out_trade_no" binding:"required"`
+	Reason     string `json:"reason"`
+}
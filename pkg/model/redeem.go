@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+// RedeemCampaign 兑换活动，同一活动下的兑换码发放相同的奖励
+type RedeemCampaign struct {
+	ID             int64     `json:"id"`
+	Name           string    `json:"name"`
+	Coins          int       `json:"coins"`
+	MembershipTier string    `json:"membership_tier,omitempty"`
+	MembershipDays int       `json:"membership_days,omitempty"`
+	PerUserLimit   int       `json:"per_user_limit"` // 每个用户在该活动中最多兑换次数，0表示不限制
+	StartsAt       time.Time `json:"starts_at"`
+	EndsAt         time.Time `json:"ends_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// RedeemCode 兑换码
+type RedeemCode struct {
+	ID         int64     `json:"id"`
+	CampaignID int64     `json:"campaign_id"`
+	Code       string    `json:"code"`
+	MaxUses    int       `json:"max_uses"` // 最多可兑换次数，1为一次性兑换码，0表示不限制
+	UsedCount  int       `json:"used_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// RedeemCodeListResponse 兑换码列表响应
+type RedeemCodeListResponse struct {
+	Records    []RedeemCode `json:"records"`
+	NextCursor int64        `json:"next_cursor"`
+}
+
+// RedeemResult 兑换结果
+type RedeemResult struct {
+	CampaignName        string     `json:"campaign_name"`
+	Coins               int        `json:"coins"`
+	MembershipTier      string     `json:"membership_tier,omitempty"`
+	MembershipDays      int        `json:"membership_days,omitempty"`
+	MembershipExpiresAt *time.Time `json:"membership_expires_at,omitempty"`
+}
+
+// RedeemRequest 兑换请求
+type RedeemRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Code   string `json:"code" binding:"required"`
+}
+
+// CreateRedeemCampaignRequest 创建兑换活动请求
+type CreateRedeemCampaignRequest struct {
+	Name           string    `json:"name" binding:"required"`
+	Coins          int       `json:"coins"`
+	MembershipTier string    `json:"membership_tier"`
+	MembershipDays int       `json:"membership_days"`
+	PerUserLimit   *int      `json:"per_user_limit"` // 为空时默认每人限兑1次
+	StartsAt       time.Time `json:"starts_at" binding:"required"`
+	EndsAt         time.Time `json:"ends_at" binding:"required"`
+}
+
+// CreateRedeemCodesRequest 生成兑换码请求
+// 指定 Code 时创建一个自定义兑换码，否则批量生成 Count 个随机兑换码
+type CreateRedeemCodesRequest struct {
+	CampaignID int64  `json:"campaign_id" binding:"required"`
+	Code       string `json:"code"`
+	Count      int    `json:"count"`
+	MaxUses    int    `json:"max_uses"`
+}
@@ -0,0 +1,97 @@
+package model
+
+import "time"
+
+// 广场内容状态
+const (
+	SquareStatusNormal    = 0 // 正常展示
+	SquareStatusHidden    = 1 // 举报数达到阈值后自动隐藏，等待审核
+	SquareStatusRemoved   = 2 // 审核后下架
+	SquareStatusReviewing = 3 // 发布前审核结果为待人工审核
+)
+
+// 举报原因
+const (
+	ReportReasonPorn       = 1 // 色情低俗
+	ReportReasonViolence   = 2 // 暴力血腥
+	ReportReasonPolitics   = 3 // 政治敏感
+	ReportReasonSpam       = 4 // 广告引流
+	ReportReasonInfringing = 5 // 盗用他人图片
+	ReportReasonOther      = 9 // 其他
+)
+
+// 举报处理状态
+const (
+	ReportStatusPending  = 0 // 待处理
+	ReportStatusResolved = 1 // 已处理
+)
+
+// 审核操作
+const (
+	ModerationActionAutoHide   = "auto_hide"
+	ModerationActionAutoReview = "auto_review"
+	ModerationActionApprove    = "approve"
+	ModerationActionRemove     = "remove"
+)
+
+// IsValidReportReason 判断举报原因是否合法
+func IsValidReportReason(reason int) bool {
+	switch reason {
+	case ReportReasonPorn, ReportReasonViolence, ReportReasonPolitics,
+		ReportReasonSpam, ReportReasonInfringing, ReportReasonOther:
+		return true
+	}
+	return false
+}
+
+// ContentReport 内容举报记录
+type ContentReport struct {
+	ID         int64     `json:"id"`
+	ContentID  int64     `json:"content_id"`
+	ReporterID string    `json:"reporter_id"`
+	Reason     int       `json:"reason"`
+	Detail     string    `json:"detail"`
+	Status     int       `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ModerationLog 审核操作日志
+type ModerationLog struct {
+	ID        int64     `json:"id"`
+	ContentID int64     `json:"content_id"`
+	Operator  string    `json:"operator"`
+	Action    string    `json:"action"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReportedContent 被举报的广场内容（审核队列条目）
+type ReportedContent struct {
+	ContentID   int64       `json:"content_id"`
+	UserID      string      `json:"user_id"`
+	ImageURL    string      `json:"image_url"`
+	Prompt      string      `json:"prompt"`
+	Status      int         `json:"status"`
+	ReportCount int         `json:"report_count"`
+	Reasons     map[int]int `json:"reasons"` // 举报原因 -> 次数
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// ReportedContentResponse 审核队列响应
+type ReportedContentResponse struct {
+	Records    []ReportedContent `json:"records"`
+	NextCursor int64             `json:"next_cursor"`
+}
+
+// ReportContentRequest 举报请求，举报人取自登录凭证
+type ReportContentRequest struct {
+	ContentID int64  `json:"content_id" binding:"required"`
+	Reason    int    `json:"reason" binding:"required"`
+	Detail    string `json:"detail"`
+}
+
+// ModerateContentRequest 审核处理请求，操作人取自管理后台鉴权结果
+type ModerateContentRequest struct {
+	ContentID int64  `json:"content_id" binding:"required"`
+	Note      string `json:"note"`
+}
@@ -0,0 +1,41 @@
+package model
+
+// SignInResult 签到结果
+type SignInResult struct {
+	Date        string `json:"date"`
+	Streak      int    `json:"streak"`       // 签到后的连续签到天数
+	Reward      int    `json:"reward"`       // 本次获得的造型币
+	RepairCards int    `json:"repair_cards"` // 本次获得的补签卡
+	IsRepair    bool   `json:"is_repair"`
+}
+
+// SignInDay 签到日历中的一天
+type SignInDay struct {
+	Date     string `json:"date"`
+	Reward   int    `json:"reward"`
+	IsRepair bool   `json:"is_repair"`
+}
+
+// UpcomingReward 未来某天继续签到可获得的奖励
+type UpcomingReward struct {
+	Date        string `json:"date"`
+	Streak      int    `json:"streak"`
+	Reward      int    `json:"reward"`
+	RepairCards int    `json:"repair_cards"`
+}
+
+// SignInCalendarResponse 签到日历响应
+type SignInCalendarResponse struct {
+	Month       string           `json:"month"`
+	Days        []SignInDay      `json:"days"`
+	Streak      int              `json:"streak"`
+	RepairCards int              `json:"repair_cards"`
+	SignedToday bool             `json:"signed_today"`
+	Upcoming    []UpcomingReward `json:"upcoming"`
+}
+
+// RepairSignInRequest 补签请求
+type RepairSignInRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Date   string `json:"date" binding:"required"` // 补签日期，格式 2006-01-02
+}
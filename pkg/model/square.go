@@ -8,6 +8,7 @@ type SquareContent struct {
 	UserID    string    `json:"user_id"`
 	RecordID  int64     `json:"record_id"`
 	LikeCount int       `json:"like_count"`
+	Status    int       `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
@@ -17,6 +18,10 @@ type SquareContent struct {
 	UserInfo *UserInfo `json:"user_info,omitempty"`
 	// 当前用户是否已点赞
 	IsLiked bool `json:"is_liked"`
+	// 当前用户是否已收藏
+	IsFavorited bool `json:"is_favorited"`
+	// 标签
+	Tags []string `json:"tags"`
 }
 
 // SquareContentResponse 广场内容列表响应
@@ -28,8 +33,9 @@ type SquareContentResponse struct {
 
 // ShareToSquareRequest 分享到广场请求
 type ShareToSquareRequest struct {
-	UserID   string `json:"user_id" binding:"required"`
-	RecordID int64  `json:"record_id" binding:"required"`
+	UserID   string   `json:"user_id" binding:"required"`
+	RecordID int64    `json:"record_id" binding:"required"`
+	Tags     []string `json:"tags"` // 为空时根据提示词自动推荐
 }
 
 // LikeContentRequest 点赞请求
@@ -37,3 +43,9 @@ type LikeContentRequest struct {
 	UserID    string `json:"user_id" binding:"required"`
 	ContentID int64  `json:"content_id" binding:"required"`
 }
+
+// TrendingTag 热门标签
+type TrendingTag struct {
+	Name         string `json:"name"`
+	ContentCount int    `json:"content_count"` // 统计周期内使用该标签的内容数
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// 订阅消息场景
+const (
+	SubscribeSceneGenerationDone = "generation_done"  // 发型生成完成
+	SubscribeSceneSignInReminder = "sign_in_reminder" // 每日签到提醒
+)
+
+// 用户对订阅消息的授权结果，与 wx.requestSubscribeMessage 返回值一致
+const (
+	SubscribeResultAccept = "accept"
+	SubscribeResultReject = "reject"
+	SubscribeResultBan    = "ban"
+)
+
+// Subscription 用户对订阅消息模板的授权，一次性订阅每次授权可发送一条消息
+type Subscription struct {
+	UserID     string    `json:"user_id"`
+	TemplateID string    `json:"template_id"`
+	Remaining  int       `json:"remaining"`   // 剩余可发送次数
+	LastResult string    `json:"last_result"` // 最近一次授权结果
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// SaveSubscriptionsRequest 上报订阅消息授权结果请求，Results 为模板ID到授权结果的映射
+type SaveSubscriptionsRequest struct {
+	UserID  string            `json:"user_id" binding:"required"`
+	Results map[string]string `json:"results" binding:"required"`
+}
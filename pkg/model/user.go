@@ -4,26 +4,30 @@ import "time"
 
 // UserInfo 用户信息
 type UserInfo struct {
-	ID             int64      `json:"id"`
-	UserID         string     `json:"user_id"`
-	Nickname       string     `json:"nickname"`
-	AvatarURL      string     `json:"avatar_url"`
-	Coin           int        `json:"coin"`
-	InviteCode     string     `json:"invite_code"`
-	UsedInviteCode string     `json:"used_invite_code"`
-	LastSignInDate *time.Time `json:"last_sign_in_date,omitempty"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	ID              int64      `json:"id"`
+	UserID          string     `json:"user_id"`
+	Nickname        string     `json:"nickname"`
+	PendingNickname string     `json:"pending_nickname"` // 待人工审核的新昵称，审核通过后才替换 Nickname
+	AvatarURL       string     `json:"avatar_url"`
+	Coin            int        `json:"coin"`
+	InviteCode      string     `json:"invite_code"`
+	UsedInviteCode  string     `json:"used_invite_code"`
+	LastSignInDate  *time.Time `json:"last_sign_in_date,omitempty"`
+	Language        string     `json:"language"` // 提示信息使用的语言，为空时按 Accept-Language
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 // UpdateUserInfoRequest 更新用户信息请求
 type UpdateUserInfoRequest struct {
 	UserID    string `json:"user_id" binding:"required"`
-	Nickname  string `json:"nickname"`
+	Nickname  string `json:"nickname"` // 为空时不修改，也不影响待审核的昵称
 	AvatarURL string `json:"avatar_url"`
+	Language  string `json:"language"` // zh 或 en，为空时不修改
 }
 
 // UseInviteCodeRequest 使用邀请码请求
+// 设备标识由服务端根据请求计算，不取自请求参数
 type UseInviteCodeRequest struct {
 	UserID string `json:"user_id" binding:"required"`
 	Code   string `json:"code" binding:"required"`
@@ -49,16 +53,44 @@ type WxLoginResponse struct {
 	UsedCode       string     `json:"used_code"`
 	LastSignInDate *time.Time `json:"last_sign_in_date,omitempty"`
 	Status         int        `json:"status"`
+	// Token 登录凭证，之后的请求放在 Authorization: Bearer 请求头中，未开启时为空
+	Token          string     `json:"token,omitempty"`
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
 }
 
 // GetUserInfoResponse 获取用户信息响应
 type GetUserInfoResponse struct {
-	UserID         string     `json:"user_id"`
-	Nickname       string     `json:"nickname"`
-	AvatarURL      string     `json:"avatar_url"`
-	Coin           int        `json:"coin"`
-	Code           string     `json:"code"`
-	UsedCode       string     `json:"used_code"`
-	LastSignInDate *time.Time `json:"last_sign_in_date,omitempty"`
-	Status         int        `json:"status"`
+	UserID          string          `json:"user_id"`
+	Nickname        string          `json:"nickname"`
+	PendingNickname string          `json:"pending_nickname,omitempty"` // 审核中的新昵称，审核通过前 nickname 仍为原昵称
+	AvatarURL       string          `json:"avatar_url"`
+	Coin            int             `json:"coin"`
+	Code            string          `json:"code"`
+	UsedCode        string          `json:"used_code"`
+	LastSignInDate  *time.Time      `json:"last_sign_in_date,omitempty"`
+	Status          int             `json:"status"`
+	Language        string          `json:"language"`
+	Membership      *MembershipInfo `json:"membership,omitempty"`    // 会员状态，非会员时为空
+	ExpiringCoin    *CoinExpiry     `json:"expiring_coin,omitempty"` // 最近一批即将过期的coin，没有时为空
+}
+
+// PendingNickname 待人工审核的昵称
+type PendingNickname struct {
+	ID              int64     `json:"id"`
+	UserID          string    `json:"user_id"`
+	Nickname        string    `json:"nickname"`         // 当前展示的昵称
+	PendingNickname string    `json:"pending_nickname"` // 待审核的新昵称
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// PendingNicknameListResponse 待审核昵称列表响应
+type PendingNicknameListResponse struct {
+	Records    []PendingNickname `json:"records"`
+	NextCursor int64             `json:"next_cursor"`
+}
+
+// ReviewNicknameRequest 审核昵称请求，Nickname 须与待审核的昵称一致，避免用户再次修改后误审
+type ReviewNicknameRequest struct {
+	UserID   string `json:"user_id" binding:"required"`
+	Nickname string `json:"nickname" binding:"required"`
 }
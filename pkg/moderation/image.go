@@ -0,0 +1,32 @@
+package moderation
+
+import (
+	"os"
+)
+
+// StubImageModerator 图片审核占位实现，在接入第三方图片审核前使用
+// 对所有图片返回固定结果
+type StubImageModerator struct {
+	Result Result
+}
+
+// NewStubImageModeratorFromEnv 根据环境变量 IMAGE_MODERATION_MODE 创建占位实现
+// 取值 review 时所有图片进入人工审核，reject 时全部拒绝，其余情况直接通过
+func NewStubImageModeratorFromEnv() *StubImageModerator {
+	switch os.Getenv("IMAGE_MODERATION_MODE") {
+	case "review":
+		return &StubImageModerator{Result: ResultReview}
+	case "reject":
+		return &StubImageModerator{Result: ResultReject}
+	default:
+		return &StubImageModerator{Result: ResultPass}
+	}
+}
+
+// ModerateImage 审核图片
+func (m *StubImageModerator) ModerateImage(imageURL string) (Decision, error) {
+	if m.Result == ResultPass {
+		return Decision{Result: ResultPass}, nil
+	}
+	return Decision{Result: m.Result, Reason: "图片审核占位策略: " + m.Result.String()}, nil
+}
@@ -0,0 +1,89 @@
+package moderation
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// defaultRejectWords 默认拒绝词，可通过环境变量追加
+var defaultRejectWords = []string{
+	"赌博",
+	"博彩",
+	"色情",
+	"约炮",
+	"代开发票",
+}
+
+// defaultReviewPatterns 默认需要人工审核的正则，主要拦截引流信息
+var defaultReviewPatterns = []string{
+	`(?i)(vx|wx|微信|薇信|加v)[:：\s]*[a-zA-Z0-9_-]{5,}`,
+	`1[3-9]\d{9}`,
+	`(?i)https?://`,
+}
+
+// KeywordFilter 本地关键词/正则文本过滤器
+type KeywordFilter struct {
+	rejectWords    []string
+	reviewPatterns []*regexp.Regexp
+}
+
+// NewKeywordFilter 创建关键词过滤器，命中拒绝词直接拒绝，命中正则转人工审核
+func NewKeywordFilter(rejectWords, reviewPatterns []string) (*KeywordFilter, error) {
+	f := &KeywordFilter{}
+	for _, w := range rejectWords {
+		if w = strings.TrimSpace(w); w != "" {
+			f.rejectWords = append(f.rejectWords, strings.ToLower(w))
+		}
+	}
+	for _, p := range reviewPatterns {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("审核正则无效 %q: %v", p, err)
+		}
+		f.reviewPatterns = append(f.reviewPatterns, re)
+	}
+	return f, nil
+}
+
+// NewKeywordFilterFromEnv 使用默认规则和环境变量创建关键词过滤器
+// MODERATION_REJECT_WORDS 为逗号分隔的拒绝词，MODERATION_REVIEW_PATTERNS 为分号分隔的正则
+func NewKeywordFilterFromEnv() *KeywordFilter {
+	rejectWords := append([]string{}, defaultRejectWords...)
+	if words := os.Getenv("MODERATION_REJECT_WORDS"); words != "" {
+		rejectWords = append(rejectWords, strings.Split(words, ",")...)
+	}
+	reviewPatterns := append([]string{}, defaultReviewPatterns...)
+	if patterns := os.Getenv("MODERATION_REVIEW_PATTERNS"); patterns != "" {
+		reviewPatterns = append(reviewPatterns, strings.Split(patterns, ";")...)
+	}
+
+	f, err := NewKeywordFilter(rejectWords, reviewPatterns)
+	if err != nil {
+		// 环境变量中的正则无效时退回默认规则
+		f, _ = NewKeywordFilter(rejectWords, defaultReviewPatterns)
+	}
+	return f
+}
+
+// ModerateText 审核文本
+func (f *KeywordFilter) ModerateText(text string) (Decision, error) {
+	lower := strings.ToLower(text)
+	// 去除空白，防止用空格拆分敏感词
+	compact := strings.Join(strings.Fields(lower), "")
+	for _, w := range f.rejectWords {
+		if strings.Contains(compact, w) {
+			return Decision{Result: ResultReject, Reason: "命中关键词: " + w}, nil
+		}
+	}
+	for _, re := range f.reviewPatterns {
+		if re.MatchString(text) {
+			return Decision{Result: ResultReview, Reason: "命中规则: " + re.String()}, nil
+		}
+	}
+	return Decision{Result: ResultPass}, nil
+}
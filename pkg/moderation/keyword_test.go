@@ -0,0 +1,58 @@
+package moderation
+
+import "testing"
+
+func TestKeywordFilterFromEnv(t *testing.T) {
+	t.Setenv("MODERATION_REJECT_WORDS", "违禁词, ")
+	t.Setenv("MODERATION_REVIEW_PATTERNS", `(?i)qq[:：]?\d{5,}`)
+	f := NewKeywordFilterFromEnv()
+
+	tests := []struct {
+		name string
+		text string
+		want Result
+	}{
+		{"plain", "小明的新发型", ResultPass},
+		{"default reject word", "来玩博彩", ResultReject},
+		// 用空白拆开的拒绝词也能命中
+		{"split by spaces", "赌 博", ResultReject},
+		{"env reject word", "这是违禁词", ResultReject},
+		{"wechat id", "加v: abc12345", ResultReview},
+		{"phone number", "电话13812345678", ResultReview},
+		{"url", "看 HTTPS://example.com", ResultReview},
+		{"env review pattern", "QQ:123456", ResultReview},
+		// 拒绝词优先于人工审核规则
+		{"reject before review", "赌博 wx:abc12345", ResultReject},
+		{"short digits", "小明2026", ResultPass},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := f.ModerateText(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decision.Result != tt.want {
+				t.Errorf("ModerateText(%q) = %v (%s), want %v", tt.text, decision.Result, decision.Reason, tt.want)
+			}
+			if tt.want != ResultPass && decision.Reason == "" {
+				t.Errorf("ModerateText(%q) has no reason", tt.text)
+			}
+		})
+	}
+}
+
+func TestKeywordFilterInvalidPattern(t *testing.T) {
+	if _, err := NewKeywordFilter(nil, []string{"("}); err == nil {
+		t.Error("NewKeywordFilter() with an invalid pattern error = nil")
+	}
+
+	// 环境变量中的正则无效时退回默认规则
+	t.Setenv("MODERATION_REVIEW_PATTERNS", "(")
+	decision, err := NewKeywordFilterFromEnv().ModerateText("电话13812345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decision.Result != ResultReview {
+		t.Errorf("ModerateText() = %v, want review by the default patterns", decision.Result)
+	}
+}
@@ -0,0 +1,75 @@
+package moderation
+
+// Result 审核结果
+type Result int
+
+const (
+	ResultPass   Result = iota // 通过
+	ResultReview               // 需要人工审核
+	ResultReject               // 拒绝
+)
+
+// String 返回审核结果的名称
+func (r Result) String() string {
+	switch r {
+	case ResultPass:
+		return "pass"
+	case ResultReview:
+		return "review"
+	case ResultReject:
+		return "reject"
+	}
+	return "unknown"
+}
+
+// Decision 审核结论
+type Decision struct {
+	Result Result
+	Reason string // 命中的规则或第三方返回的原因，便于排查
+}
+
+// TextModerator 文本审核
+type TextModerator interface {
+	ModerateText(text string) (Decision, error)
+}
+
+// ImageModerator 图片审核
+type ImageModerator interface {
+	ModerateImage(imageURL string) (Decision, error)
+}
+
+// Moderator 内容审核接口，公开内容前需要经过审核
+type Moderator interface {
+	TextModerator
+	ImageModerator
+}
+
+// moderator 组合文本审核与图片审核
+type moderator struct {
+	TextModerator
+	ImageModerator
+}
+
+// New 组合文本审核与图片审核实现
+func New(text TextModerator, image ImageModerator) Moderator {
+	return &moderator{
+		TextModerator:  text,
+		ImageModerator: image,
+	}
+}
+
+// NewFromEnv 根据环境变量创建默认的审核实现
+func NewFromEnv() Moderator {
+	return New(NewKeywordFilterFromEnv(), NewStubImageModeratorFromEnv())
+}
+
+// Merge 合并多个审核结论，取最严格的结果
+func Merge(decisions ...Decision) Decision {
+	merged := Decision{Result: ResultPass}
+	for _, d := range decisions {
+		if d.Result > merged.Result {
+			merged = d
+		}
+	}
+	return merged
+}
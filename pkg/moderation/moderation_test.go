@@ -0,0 +1,48 @@
+package moderation
+
+import "testing"
+
+func TestMerge(t *testing.T) {
+	pass := Decision{Result: ResultPass}
+	review := Decision{Result: ResultReview, Reason: "review"}
+	reject := Decision{Result: ResultReject, Reason: "reject"}
+
+	tests := []struct {
+		name      string
+		decisions []Decision
+		want      Decision
+	}{
+		{"none", nil, pass},
+		{"all pass", []Decision{pass, pass}, pass},
+		{"review", []Decision{pass, review}, review},
+		{"reject wins", []Decision{review, reject, pass}, reject},
+	}
+	for _, tt := range tests {
+		if got := Merge(tt.decisions...); got != tt.want {
+			t.Errorf("%s: Merge() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStubImageModeratorFromEnv(t *testing.T) {
+	tests := []struct {
+		mode string
+		want Result
+	}{
+		{"", ResultPass},
+		{"review", ResultReview},
+		{"reject", ResultReject},
+		{"unknown", ResultPass},
+	}
+	for _, tt := range tests {
+		t.Setenv("IMAGE_MODERATION_MODE", tt.mode)
+		m := New(NewKeywordFilterFromEnv(), NewStubImageModeratorFromEnv())
+		decision, err := m.ModerateImage("https://img/1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decision.Result != tt.want {
+			t.Errorf("mode %q: ModerateImage() = %v, want %v", tt.mode, decision.Result, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"unicode/utf8"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/wechat"
+)
+
+// Sender 订阅消息发送接口，由 *wechat.Client 实现
+type Sender interface {
+	SendSubscribeMessage(ctx context.Context, msg *wechat.SubscribeMessage) error
+}
+
+// SubscribeNotifier 通过小程序订阅消息发送通知，发送前检查并扣减用户授权次数
+type SubscribeNotifier struct {
+	db            *sql.DB
+	subscriptions repo.SubscriptionRepo
+	sender        Sender
+	templates     map[string]string // 场景 -> 模板ID
+	state         string            // 跳转小程序类型：developer/trial/formal
+}
+
+// NewSubscribeNotifier 创建订阅消息通知
+func NewSubscribeNotifier(dbConn *sql.DB, subscriptions repo.SubscriptionRepo, sender Sender, templates map[string]string, state string) *SubscribeNotifier {
+	return &SubscribeNotifier{
+		db:            dbConn,
+		subscriptions: subscriptions,
+		sender:        sender,
+		templates:     templates,
+		state:         state,
+	}
+}
+
+// TemplatesFromEnv 从环境变量读取各场景的订阅消息模板ID
+func TemplatesFromEnv() map[string]string {
+	return map[string]string{
+		model.SubscribeSceneGenerationDone: os.Getenv("WX_TEMPLATE_GENERATION_DONE"),
+		model.SubscribeSceneSignInReminder: os.Getenv("WX_TEMPLATE_SIGN_IN_REMINDER"),
+	}
+}
+
+// TemplateID 返回场景对应的模板ID，未配置时返回空字符串
+func (n *SubscribeNotifier) TemplateID(scene string) string {
+	return n.templates[scene]
+}
+
+// Notify 向用户发送订阅消息，用户未授权或场景未配置模板时不发送并返回 false
+// data 的键需要与微信后台配置的模板字段一致
+func (n *SubscribeNotifier) Notify(ctx context.Context, userID, scene, page string, data map[string]string) (bool, error) {
+	templateID := n.templates[scene]
+	if templateID == "" {
+		return false, nil
+	}
+
+	ok, err := n.subscriptions.Consume(n.db, userID, templateID)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	msg := &wechat.SubscribeMessage{
+		ToUser:           userID,
+		TemplateID:       templateID,
+		Page:             page,
+		MiniprogramState: n.state,
+		Lang:             "zh_CN",
+		Data:             make(map[string]wechat.DataItem, len(data)),
+	}
+	for k, v := range data {
+		msg.Data[k] = wechat.DataItem{Value: v}
+	}
+
+	if err := n.sender.SendSubscribeMessage(ctx, msg); err != nil {
+		// 用户已拒收时清空授权次数，其他错误归还本次扣减
+		var wxErr *wechat.Error
+		if errors.As(err, &wxErr) && wxErr.Code == wechat.ErrCodeUserRefused {
+			n.subscriptions.Revoke(n.db, userID, templateID)
+		} else {
+			n.subscriptions.Restore(n.db, userID, templateID)
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Truncate 按字符截断模板字段，thing 类型字段最多20个字符
+func Truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max-1]) + "…"
+}
@@ -0,0 +1,230 @@
+// Package openapi 生成接口的 OpenAPI 3 文档，并按文档校验请求
+// 请求体和响应数据的结构由 pkg/model 中的类型反射生成，模型修改后文档自动同步
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Document OpenAPI 3 文档，只包含本项目用到的字段
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Info 文档基本信息
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Components 可复用的结构定义
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme 鉴权方式
+type SecurityScheme struct {
+	Type string `json:"type"`
+	In   string `json:"in"`
+	Name string `json:"name"`
+}
+
+// Operation 一个接口
+type Operation struct {
+	Summary     string                `json:"summary"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter 查询参数
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody 请求体
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response 响应
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType 请求体或响应的内容
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema 数据结构，Ref 不为空时引用 components 中的定义
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+const (
+	jsonContent = "application/json"
+	refPrefix   = "#/components/schemas/"
+	adminScheme = "AdminToken"
+)
+
+// Build 根据 Routes 生成文档
+func Build() *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: "ChangeHairStyle API", Version: "1.0.0"},
+		Paths:   make(map[string]map[string]*Operation),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]*SecurityScheme{
+				adminScheme: {Type: "apiKey", In: "header", Name: "X-Admin-Token"},
+			},
+		},
+	}
+	gen := &generator{schemas: doc.Components.Schemas}
+
+	for _, route := range Routes {
+		op := &Operation{
+			Summary:   route.Summary,
+			Tags:      []string{route.Tag},
+			Responses: make(map[string]*Response),
+		}
+		for _, q := range route.Query {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:        q.Name,
+				In:          "query",
+				Description: q.Description,
+				Required:    q.Required,
+				Schema:      gen.schemaOf(q.Type),
+			})
+		}
+		if route.Body != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]*MediaType{jsonContent: {Schema: gen.schemaOf(route.Body)}},
+			}
+		}
+		if route.Admin {
+			op.Security = []map[string][]string{{adminScheme: {}}}
+		}
+
+		if route.Raw {
+			op.Responses["200"] = &Response{
+				Description: "成功",
+				Content:     map[string]*MediaType{jsonContent: {Schema: gen.schemaOf(route.Data)}},
+			}
+			doc.add(route, op)
+			continue
+		}
+
+		op.Responses["200"] = &Response{
+			Description: "成功",
+			Content:     map[string]*MediaType{jsonContent: {Schema: envelope(gen.schemaOf(route.Data))}},
+		}
+		if route.Queued != nil {
+			op.Responses["202"] = &Response{
+				Description: "排队中，带上 queue_ticket 重试",
+				Content:     map[string]*MediaType{jsonContent: {Schema: envelope(gen.schemaOf(route.Queued))}},
+			}
+		}
+		op.Responses["default"] = &Response{
+			Description: "错误，code 见 pkg/apperr",
+			Content:     map[string]*MediaType{jsonContent: {Schema: envelope(nil)}},
+		}
+
+		doc.add(route, op)
+	}
+
+	return doc
+}
+
+// add 将接口加入文档
+func (d *Document) add(route Route, op *Operation) {
+	path := d.Paths[route.Path]
+	if path == nil {
+		path = make(map[string]*Operation)
+		d.Paths[route.Path] = path
+	}
+	path[strings.ToLower(route.Method)] = op
+}
+
+// envelope 统一响应格式 {"code": ..., "message": ..., "data": ...}
+func envelope(data *Schema) *Schema {
+	s := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"code":    {Type: "integer", Description: "0 表示成功"},
+			"message": {Type: "string"},
+		},
+		Required: []string{"code", "message"},
+	}
+	if data != nil {
+		s.Properties["data"] = data
+	}
+	return s
+}
+
+// Operation 查找接口定义，未定义时返回 nil
+func (d *Document) Operation(method, path string) *Operation {
+	return d.Paths[path][strings.ToLower(method)]
+}
+
+// Undocumented 返回已注册但文档中没有的路由
+func (d *Document) Undocumented(routes gin.RoutesInfo) []string {
+	var missing []string
+	for _, route := range routes {
+		if route.Path == "/openapi.json" || route.Path == "/health" {
+			continue
+		}
+		if d.Operation(route.Method, route.Path) == nil {
+			missing = append(missing, route.Method+" "+route.Path)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+var (
+	defaultOnce sync.Once
+	defaultDoc  *Document
+)
+
+// Default 返回根据 Routes 生成的文档，只生成一次
+func Default() *Document {
+	defaultOnce.Do(func() {
+		defaultDoc = Build()
+	})
+	return defaultDoc
+}
+
+// Handler 输出文档
+func Handler(doc *Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	}
+}
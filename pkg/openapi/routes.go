@@ -0,0 +1,291 @@
+package openapi
+
+import (
+	"github.com/MRsummer/ChangeHairStyle/pkg/handler"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/pricing"
+	"github.com/MRsummer/ChangeHairStyle/pkg/wxpay"
+)
+
+// Route 一个接口的文档，新增路由时需在 Routes 中补充
+type Route struct {
+	Method  string
+	Path    string
+	Tag     string
+	Summary string
+	Query   []Param
+	// Body 请求体类型的零值，为 nil 时没有请求体
+	Body interface{}
+	// Data 响应中 data 字段类型的零值，用 gin.H 返回的数据用 Fields 描述
+	Data interface{}
+	// Queued 排队时 202 响应的 data
+	Queued interface{}
+	// Admin 需要管理后台鉴权
+	Admin bool
+	// Raw 响应不使用统一的 {"code", "message", "data"} 格式，Data 即为整个响应
+	Raw bool
+}
+
+// Param 查询参数，Type 为参数类型的零值
+type Param struct {
+	Name        string
+	Type        interface{}
+	Required    bool
+	Description string
+}
+
+// 常用的查询参数
+var (
+	userIDParam   = Param{Name: "user_id", Type: "", Required: true}
+	cursorParam   = Param{Name: "cursor", Type: int64(0), Description: "上一页返回的 next_cursor，第一页不传"}
+	pageSizeParam = Param{Name: "page_size", Type: 0}
+)
+
+// Routes 所有接口的文档，与 pkg/app 中注册的路由一一对应
+var Routes = []Route{
+	// 发型生成
+	{
+		Method: "POST", Path: "/api/hair-style", Tag: "hair-style", Summary: "生成发型",
+		Body:   handler.HairStyleRequest{},
+		Data:   Fields{"image_url": "", "record_id": int64(0), "cost": 0, "free": false},
+		Queued: Fields{"queue_ticket": "", "queue_position": 0, "estimated_wait": 0},
+	},
+	{
+		Method: "GET", Path: "/api/hair-style/records", Tag: "hair-style", Summary: "获取生成记录",
+		Query: []Param{userIDParam, {Name: "page", Type: 0}, pageSizeParam},
+		Data:  model.RecordResponse{},
+	},
+	{
+		Method: "GET", Path: "/api/hair-style/quote", Tag: "hair-style", Summary: "查询本次生成的价格",
+		Query: []Param{userIDParam},
+		Data:  pricing.Quote{},
+	},
+
+	// 用户
+	{
+		Method: "POST", Path: "/api/user/info", Tag: "user", Summary: "更新用户信息",
+		Body: model.UpdateUserInfoRequest{},
+	},
+	{
+		Method: "GET", Path: "/api/user/info/get", Tag: "user", Summary: "获取用户信息",
+		Query: []Param{userIDParam},
+		Data:  model.GetUserInfoResponse{},
+	},
+	{
+		Method: "POST", Path: "/api/user/code/use", Tag: "user", Summary: "使用邀请码",
+		Body: model.UseInviteCodeRequest{},
+	},
+	{
+		Method: "GET", Path: "/api/user/invite/stats", Tag: "user", Summary: "获取邀请统计",
+		Query: []Param{userIDParam, cursorParam, pageSizeParam},
+		Data:  model.InviteStatsResponse{},
+	},
+	{
+		Method: "POST", Path: "/api/user/redeem", Tag: "user", Summary: "使用兑换码",
+		Body: model.RedeemRequest{},
+		Data: model.RedeemResult{},
+	},
+	{
+		Method: "POST", Path: "/api/user/sign-in", Tag: "sign-in", Summary: "签到",
+		Body: model.SignInRequest{},
+		Data: model.SignInResult{},
+	},
+	{
+		Method: "POST", Path: "/api/user/sign-in/repair", Tag: "sign-in", Summary: "使用补签卡补签",
+		Body: model.RepairSignInRequest{},
+		Data: model.SignInResult{},
+	},
+	{
+		Method: "GET", Path: "/api/user/sign-in/calendar", Tag: "sign-in", Summary: "获取签到日历",
+		Query: []Param{userIDParam, {Name: "month", Type: "", Description: "格式 2006-01，默认当月"}},
+		Data:  model.SignInCalendarResponse{},
+	},
+	{
+		Method: "POST", Path: "/api/user/wx-login", Tag: "user", Summary: "微信登录",
+		Body: model.WxLoginRequest{},
+		Data: model.WxLoginResponse{},
+	},
+	{
+		Method: "POST", Path: "/api/user/subscriptions", Tag: "user", Summary: "保存订阅消息授权结果",
+		Body: model.SaveSubscriptionsRequest{},
+	},
+	{
+		Method: "GET", Path: "/api/user/subscriptions", Tag: "user", Summary: "获取订阅消息模板和剩余次数",
+		Query: []Param{userIDParam},
+		Data:  Fields{"templates": map[string]string{}, "subscriptions": []model.Subscription{}},
+	},
+
+	// 广场
+	{
+		Method: "POST", Path: "/api/square/share", Tag: "square", Summary: "分享到广场",
+		Body: model.ShareToSquareRequest{},
+		Data: Fields{"content_id": int64(0), "status": 0, "tags": []string{}},
+	},
+	{
+		Method: "GET", Path: "/api/square/contents", Tag: "square", Summary: "获取广场内容",
+		Query: []Param{userIDParam, cursorParam, pageSizeParam, {Name: "tag", Type: "", Description: "按标签筛选"}},
+		Data:  model.SquareContentResponse{},
+	},
+	{
+		Method: "POST", Path: "/api/square/like", Tag: "square", Summary: "点赞或取消点赞",
+		Body: model.LikeContentRequest{},
+		Data: Fields{"is_liked": false},
+	},
+	{
+		Method: "POST", Path: "/api/square/report", Tag: "square", Summary: "举报内容",
+		Body: model.ReportContentRequest{},
+		Data: Fields{"report_id": int64(0)},
+	},
+	{
+		Method: "GET", Path: "/api/square/tags/suggest", Tag: "square", Summary: "根据记录或提示词推荐标签",
+		Query: []Param{{Name: "user_id", Type: ""}, {Name: "record_id", Type: int64(0)}, {Name: "prompt", Type: ""}},
+		Data:  Fields{"tags": []string{}},
+	},
+	{
+		Method: "GET", Path: "/api/square/tags/trending", Tag: "square", Summary: "获取热门标签",
+		Query: []Param{
+			{Name: "days", Type: 0, Description: "统计最近几天，1-90，默认7"},
+			{Name: "limit", Type: 0, Description: "返回数量，1-50，默认10"},
+		},
+		Data: []model.TrendingTag{},
+	},
+
+	// 收藏
+	{
+		Method: "POST", Path: "/api/favorites", Tag: "favorite", Summary: "收藏内容",
+		Body: model.AddFavoriteRequest{},
+		Data: Fields{"favorite_id": int64(0), "collection_id": int64(0), "is_favorited": false},
+	},
+	{
+		Method: "DELETE", Path: "/api/favorites", Tag: "favorite", Summary: "取消收藏",
+		Body: model.RemoveFavoriteRequest{},
+		Data: Fields{"is_favorited": false},
+	},
+	{
+		Method: "GET", Path: "/api/favorites", Tag: "favorite", Summary: "获取收藏列表",
+		Query: []Param{userIDParam, {Name: "collection_id", Type: int64(0), Description: "不传时返回所有收藏夹的内容"}, cursorParam, pageSizeParam},
+		Data:  model.FavoriteListResponse{},
+	},
+	{
+		Method: "POST", Path: "/api/favorites/collections", Tag: "favorite", Summary: "创建收藏夹",
+		Body: model.CreateCollectionRequest{},
+		Data: model.FavoriteCollection{},
+	},
+	{
+		Method: "GET", Path: "/api/favorites/collections", Tag: "favorite", Summary: "获取收藏夹列表",
+		Query: []Param{userIDParam},
+		Data:  []model.FavoriteCollection{},
+	},
+	{
+		Method: "DELETE", Path: "/api/favorites/collections", Tag: "favorite", Summary: "删除收藏夹",
+		Body: model.DeleteCollectionRequest{},
+	},
+
+	// 通知
+	{
+		Method: "GET", Path: "/api/notifications", Tag: "notification", Summary: "获取通知列表",
+		Query: []Param{userIDParam, cursorParam, pageSizeParam},
+		Data:  model.NotificationListResponse{},
+	},
+	{
+		Method: "GET", Path: "/api/notifications/unread-count", Tag: "notification", Summary: "获取未读通知数",
+		Query: []Param{userIDParam},
+		Data:  Fields{"unread_count": 0},
+	},
+	{
+		Method: "POST", Path: "/api/notifications/read", Tag: "notification", Summary: "标记通知已读",
+		Body: model.MarkNotificationsReadRequest{},
+	},
+
+	// 支付
+	{
+		Method: "GET", Path: "/api/pay/products", Tag: "pay", Summary: "获取coin商品列表",
+		Data: []model.CoinProduct{},
+	},
+	{
+		Method: "POST", Path: "/api/pay/orders", Tag: "pay", Summary: "创建支付订单",
+		Body: model.CreatePayOrderRequest{},
+		Data: Fields{"order": model.PayOrder{}, "pay_params": wxpay.PayParams{}},
+	},
+	{
+		Method: "GET", Path: "/api/pay/orders", Tag: "pay", Summary: "获取支付订单列表",
+		Query: []Param{userIDParam, cursorParam, pageSizeParam},
+		Data:  model.PayOrderListResponse{},
+	},
+	{
+		Method: "GET", Path: "/api/pay/orders/status", Tag: "pay", Summary: "查询支付订单状态",
+		Query: []Param{userIDParam, {Name: "out_trade_no", Type: "", Required: true}},
+		Data:  model.PayOrder{},
+	},
+	{
+		// 请求体为微信支付的加密通知，响应按微信支付要求的格式返回
+		Method: "POST", Path: "/api/pay/notify", Tag: "pay", Summary: "微信支付结果通知",
+		Data: wxpay.NotifyResponse{}, Raw: true,
+	},
+
+	// 管理后台
+	{
+		Method: "GET", Path: "/api/admin/reports", Tag: "admin", Summary: "获取审核队列", Admin: true,
+		Query: []Param{{Name: "status", Type: 0, Description: "内容状态，不传时返回所有状态"}, cursorParam, pageSizeParam},
+		Data:  model.ReportedContentResponse{},
+	},
+	{
+		Method: "POST", Path: "/api/admin/reports/approve", Tag: "admin", Summary: "审核通过", Admin: true,
+		Body: model.ModerateContentRequest{},
+	},
+	{
+		Method: "POST", Path: "/api/admin/reports/remove", Tag: "admin", Summary: "审核下架", Admin: true,
+		Body: model.ModerateContentRequest{},
+	},
+	{
+		Method: "GET", Path: "/api/admin/moderation-logs", Tag: "admin", Summary: "获取审核日志", Admin: true,
+		Query: []Param{{Name: "content_id", Type: int64(0), Required: true}},
+		Data:  []model.ModerationLog{},
+	},
+	{
+		Method: "GET", Path: "/api/admin/nicknames", Tag: "admin", Summary: "获取待审核昵称", Admin: true,
+		Query: []Param{cursorParam, pageSizeParam},
+		Data:  model.PendingNicknameListResponse{},
+	},
+	{
+		Method: "POST", Path: "/api/admin/nicknames/approve", Tag: "admin", Summary: "昵称审核通过", Admin: true,
+		Body: model.ReviewNicknameRequest{},
+	},
+	{
+		Method: "POST", Path: "/api/admin/nicknames/reject", Tag: "admin", Summary: "昵称审核拒绝，保留原昵称", Admin: true,
+		Body: model.ReviewNicknameRequest{},
+	},
+	{
+		Method: "POST", Path: "/api/admin/jobs/sign-in-reminder", Tag: "admin", Summary: "发送每日签到提醒", Admin: true,
+		Data: Fields{"sent": 0, "failed": 0},
+	},
+	{
+		Method: "POST", Path: "/api/admin/jobs/expire-coins", Tag: "admin", Summary: "清理过期coin", Admin: true,
+		Data: Fields{"users": 0, "coins": 0},
+	},
+	{
+		Method: "POST", Path: "/api/admin/invite-codes", Tag: "admin", Summary: "设置自定义邀请码", Admin: true,
+		Body: model.SetInviteCodeRequest{},
+		Data: Fields{"invite_code": ""},
+	},
+	{
+		Method: "POST", Path: "/api/admin/pay/refunds", Tag: "admin", Summary: "订单退款", Admin: true,
+		Body: model.RefundPayOrderRequest{},
+		Data: model.PayRefund{},
+	},
+	{
+		Method: "POST", Path: "/api/admin/redeem/campaigns", Tag: "admin", Summary: "创建兑换活动", Admin: true,
+		Body: model.CreateRedeemCampaignRequest{},
+		Data: model.RedeemCampaign{},
+	},
+	{
+		Method: "POST", Path: "/api/admin/redeem/codes", Tag: "admin", Summary: "生成兑换码", Admin: true,
+		Body: model.CreateRedeemCodesRequest{},
+		Data: []model.RedeemCode{},
+	},
+	{
+		Method: "GET", Path: "/api/admin/redeem/codes", Tag: "admin", Summary: "获取兑换码列表", Admin: true,
+		Query: []Param{{Name: "campaign_id", Type: int64(0), Required: true}, cursorParam, pageSizeParam},
+		Data:  model.RedeemCodeListResponse{},
+	},
+}
@@ -0,0 +1,124 @@
+package openapi
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fields 描述处理函数用 gin.H 返回的数据，值只用于确定字段类型
+type Fields map[string]interface{}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	fieldsType = reflect.TypeOf(Fields{})
+)
+
+// generator 由 Go 类型生成 Schema，命名结构体统一放到 components 中引用
+type generator struct {
+	schemas map[string]*Schema
+}
+
+// schemaOf 生成值的类型对应的 Schema，nil 表示没有数据
+func (g *generator) schemaOf(v interface{}) *Schema {
+	if v == nil {
+		return nil
+	}
+	if fields, ok := v.(Fields); ok {
+		return g.fieldsSchema(fields)
+	}
+	return g.typeSchema(reflect.TypeOf(v))
+}
+
+// fieldsSchema gin.H 返回的对象，字段值的类型即为字段类型
+func (g *generator) fieldsSchema(fields Fields) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for name, value := range fields {
+		if value == nil {
+			s.Properties[name] = &Schema{}
+			continue
+		}
+		s.Properties[name] = g.schemaOf(value)
+	}
+	return s
+}
+
+func (g *generator) typeSchema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := g.typeSchema(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		if t == fieldsType {
+			return &Schema{Type: "object"}
+		}
+		return &Schema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			// 先占位，结构体引用自身时不会无限递归
+			g.schemas[t.Name()] = &Schema{}
+			*g.schemas[t.Name()] = *g.structSchema(t)
+		}
+		return &Schema{Ref: refPrefix + t.Name()}
+	}
+	// interface{} 等无法确定类型的字段不限制
+	return &Schema{}
+}
+
+// structSchema 按 json 标签生成对象的字段，binding:"required" 的字段为必填
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			embedded := g.structSchema(f.Type)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s.Properties[name] = g.typeSchema(f.Type)
+		for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+			if rule == "required" {
+				s.Required = append(s.Required, name)
+			}
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
@@ -0,0 +1,174 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateRequest 按文档校验请求的查询参数和请求体，文档中没有的接口不校验
+// 缺少必填参数时返回 apperr.ErrMissingParam，类型不符时返回 apperr.ErrInvalidParam，参数名为字段路径
+func (d *Document) ValidateRequest(method, path string, query url.Values, body []byte) error {
+	op := d.Operation(method, path)
+	if op == nil {
+		return nil
+	}
+
+	for _, p := range op.Parameters {
+		value := query.Get(p.Name)
+		if value == "" {
+			if p.Required {
+				return apperr.ErrMissingParam.With(p.Name)
+			}
+			continue
+		}
+		if !validScalar(p.Schema, value) {
+			return apperr.ErrInvalidParam.With(p.Name)
+		}
+	}
+
+	if op.RequestBody == nil {
+		return nil
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return apperr.ErrBadRequest
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return apperr.ErrBadRequest
+	}
+	return d.validate(op.RequestBody.Content[jsonContent].Schema, value, "")
+}
+
+// validate 校验 JSON 值，允许文档中没有的字段，null 视为未传
+func (d *Document) validate(s *Schema, value interface{}, path string) error {
+	s = d.resolve(s)
+	if s == nil || value == nil {
+		return nil
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return invalid(path)
+		}
+		for _, name := range s.Required {
+			if obj[name] == nil {
+				return apperr.ErrMissingParam.With(join(path, name))
+			}
+		}
+		for name, v := range obj {
+			if prop, ok := s.Properties[name]; ok {
+				if err := d.validate(prop, v, join(path, name)); err != nil {
+					return err
+				}
+			} else if s.AdditionalProperties != nil {
+				if err := d.validate(s.AdditionalProperties, v, join(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return invalid(path)
+		}
+		for i, v := range items {
+			if err := d.validate(s.Items, v, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return invalid(path)
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok || !validScalar(s, n.String()) {
+			return invalid(path)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok || !validScalar(s, str) {
+			return invalid(path)
+		}
+	}
+	return nil
+}
+
+// resolve 展开 components 中的引用
+func (d *Document) resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, refPrefix)]
+	}
+	return s
+}
+
+// validScalar 校验查询参数或 JSON 中的数字、字符串是否符合类型
+func validScalar(s *Schema, value string) bool {
+	switch s.Type {
+	case "integer":
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case "number":
+		_, err := strconv.ParseFloat(value, 64)
+		return err == nil
+	case "boolean":
+		_, err := strconv.ParseBool(value)
+		return err == nil
+	case "string":
+		if s.Format == "date-time" {
+			_, err := time.Parse(time.RFC3339, value)
+			return err == nil
+		}
+	}
+	return true
+}
+
+func invalid(path string) error {
+	if path == "" {
+		return apperr.ErrBadRequest
+	}
+	return apperr.ErrInvalidParam.With(path)
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// Validator 按文档校验请求的中间件，校验失败时中止请求
+// 用于测试环境发现客户端与文档、文档与模型之间的不一致
+func Validator(doc *Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				apperr.Abort(c, apperr.ErrBadRequest.Wrap(err))
+				return
+			}
+			// 还原请求体，处理函数仍需读取
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		if err := doc.ValidateRequest(c.Request.Method, c.FullPath(), c.Request.URL.Query(), body); err != nil {
+			apperr.Abort(c, err)
+			return
+		}
+		c.Next()
+	}
+}
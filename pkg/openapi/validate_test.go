@@ -0,0 +1,103 @@
+package openapi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateRequest(t *testing.T) {
+	doc := Default()
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		query     string
+		body      string
+		wantErr   *apperr.Error
+		wantParam string
+	}{
+		{"valid body", "POST", "/api/user/info", "", `{"user_id":"u1","nickname":"小明"}`, nil, ""},
+		{"valid query", "GET", "/api/hair-style/records", "user_id=u1&page=2", "", nil, ""},
+		{"undocumented route", "GET", "/api/unknown", "", "", nil, ""},
+		{"null optional field", "POST", "/api/user/info", "", `{"user_id":"u1","nickname":null}`, nil, ""},
+		{"unknown field allowed", "POST", "/api/user/info", "", `{"user_id":"u1","extra":1}`, nil, ""},
+
+		{"missing query param", "GET", "/api/hair-style/records", "", "", apperr.ErrMissingParam, "user_id"},
+		{"invalid query param", "GET", "/api/hair-style/records", "user_id=u1&page=abc", "", apperr.ErrInvalidParam, "page"},
+		{"invalid int64 query", "GET", "/api/square/contents", "user_id=u1&cursor=1.5", "", apperr.ErrInvalidParam, "cursor"},
+		{"missing body", "POST", "/api/user/info", "", "", apperr.ErrBadRequest, ""},
+		{"malformed body", "POST", "/api/user/info", "", `{"user_id":`, apperr.ErrBadRequest, ""},
+		{"body not object", "POST", "/api/user/info", "", `["u1"]`, apperr.ErrBadRequest, ""},
+		{"missing required field", "POST", "/api/user/info", "", `{"nickname":"小明"}`, apperr.ErrMissingParam, "user_id"},
+		{"null required field", "POST", "/api/user/info", "", `{"user_id":null}`, apperr.ErrMissingParam, "user_id"},
+		{"string instead of integer", "POST", "/api/pay/orders", "", `{"user_id":"u1","product_id":"1"}`, apperr.ErrInvalidParam, "product_id"},
+		{"float instead of integer", "POST", "/api/pay/orders", "", `{"user_id":"u1","product_id":1.5}`, apperr.ErrInvalidParam, "product_id"},
+		{"number instead of string", "POST", "/api/user/info", "", `{"user_id":123}`, apperr.ErrInvalidParam, "user_id"},
+		{"invalid map value", "POST", "/api/user/subscriptions", "", `{"user_id":"u1","results":{"tpl":1}}`, apperr.ErrInvalidParam, "results.tpl"},
+		{"invalid array item", "POST", "/api/square/share", "", `{"user_id":"u1","record_id":1,"tags":["短发",2]}`, apperr.ErrInvalidParam, "tags[1]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = doc.ValidateRequest(tt.method, tt.path, query, []byte(tt.body))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ValidateRequest() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var appErr *apperr.Error
+			if !errors.As(err, &appErr) || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateRequest() error = %v, want code %d", err, tt.wantErr.Code)
+			}
+			if tt.wantParam != "" && (len(appErr.Args) != 1 || appErr.Args[0] != tt.wantParam) {
+				t.Errorf("error args = %v, want [%s]", appErr.Args, tt.wantParam)
+			}
+		})
+	}
+}
+
+func TestValidator(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(apperr.Middleware(nil), Validator(Default()))
+	r.POST("/api/user/info", func(c *gin.Context) {
+		// 校验后处理函数仍能读到完整的请求体
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"valid", `{"user_id":"u1"}`, http.StatusOK, `{"user_id":"u1"}`},
+		{"missing field", `{}`, http.StatusBadRequest, `"code":40001`},
+		{"wrong type", `{"user_id":1}`, http.StatusBadRequest, `"code":40002`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/user/info", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("response = %d %s, want %d containing %s", w.Code, w.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package pricing
+
+import (
+	"github.com/MRsummer/ChangeHairStyle/config"
+)
+
+// Quote 一次生成的报价
+type Quote struct {
+	Tier          string `json:"tier"`            // 会员等级，非会员为空
+	BasePrice     int    `json:"base_price"`      // 原价
+	Price         int    `json:"price"`           // 实际扣除的coin，使用免费额度时为0
+	Free          bool   `json:"free"`            // 是否使用每日免费额度
+	FreeQuotaLeft int    `json:"free_quota_left"` // 本次生成前剩余的每日免费次数
+	Priority      bool   `json:"priority"`        // 生成排队时是否优先处理
+}
+
+// QuoteGeneration 根据会员等级和今日已用免费次数计算一次生成的价格
+// 会员优先使用每日免费额度，用完后按折扣价扣除coin
+func QuoteGeneration(cfg config.PricingConfig, tier string, freeUsedToday int) Quote {
+	quote := Quote{
+		BasePrice: cfg.GenerationPrice,
+		Price:     cfg.GenerationPrice,
+	}
+
+	tierCfg, ok := cfg.Tier(tier)
+	if !ok {
+		return quote
+	}
+
+	quote.Tier = tier
+	quote.Priority = tierCfg.Priority
+	quote.FreeQuotaLeft = max(tierCfg.DailyFreeQuota-freeUsedToday, 0)
+	if quote.FreeQuotaLeft > 0 {
+		quote.Free = true
+		quote.Price = 0
+		return quote
+	}
+
+	quote.Price = Discount(cfg.GenerationPrice, tierCfg.DiscountPercent)
+	return quote
+}
+
+// Discount 按百分比计算折扣价，向上取整，百分比不在 (0, 100] 内时按原价
+func Discount(price, percent int) int {
+	if percent <= 0 || percent >= 100 {
+		return price
+	}
+	return (price*percent + 99) / 100
+}
@@ -0,0 +1,58 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+)
+
+func TestQuoteGeneration(t *testing.T) {
+	cfg := config.PricingConfig{
+		GenerationPrice: 10,
+		VIP:             config.TierConfig{DailyFreeQuota: 1, DiscountPercent: 80},
+		SVIP:            config.TierConfig{DailyFreeQuota: 3, DiscountPercent: 55, Priority: true},
+	}
+	tests := []struct {
+		name     string
+		tier     string
+		freeUsed int
+		want     Quote
+	}{
+		{"non member", "", 0, Quote{BasePrice: 10, Price: 10}},
+		{"unknown tier", "gold", 0, Quote{BasePrice: 10, Price: 10}},
+		{"vip free quota", "vip", 0, Quote{Tier: "vip", BasePrice: 10, Price: 0, Free: true, FreeQuotaLeft: 1}},
+		{"vip quota used", "vip", 1, Quote{Tier: "vip", BasePrice: 10, Price: 8}},
+		{"vip over quota", "vip", 5, Quote{Tier: "vip", BasePrice: 10, Price: 8}},
+		{"svip free quota", "svip", 2, Quote{Tier: "svip", BasePrice: 10, Price: 0, Free: true, FreeQuotaLeft: 1, Priority: true}},
+		// 折扣价向上取整
+		{"svip discount", "svip", 3, Quote{Tier: "svip", BasePrice: 10, Price: 6, Priority: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QuoteGeneration(cfg, tt.tier, tt.freeUsed); got != tt.want {
+				t.Errorf("QuoteGeneration() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscount(t *testing.T) {
+	tests := []struct {
+		price   int
+		percent int
+		want    int
+	}{
+		{10, 80, 8},
+		{10, 55, 6},
+		{3, 50, 2},
+		{10, 100, 10},
+		{10, 0, 10},
+		{10, -20, 10},
+		{10, 120, 10},
+	}
+	for _, tt := range tests {
+		if got := Discount(tt.price, tt.percent); got != tt.want {
+			t.Errorf("Discount(%d, %d) = %d, want %d", tt.price, tt.percent, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval 清理满桶的间隔
+const sweepInterval = time.Minute
+
+// MemoryStore 保存在进程内存中的令牌桶，只对当前实例生效
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time // 桶重新装满的时间
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// Take 从 key 对应的桶中取一个令牌
+func (s *MemoryStore) Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rule.Burst), last: now}
+		s.buckets[key] = b
+	}
+	tokens, res := rule.take(b.tokens, b.last, now)
+	b.tokens, b.last, b.full = tokens, now, now.Add(res.Reset)
+	return res, nil
+}
+
+// sweep 定期删除已经装满的桶，满桶与不存在的桶等价，避免按IP创建的桶无限增长
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// MySQLStore 保存在 MySQL 中的令牌桶，多个实例共享限流状态
+type MySQLStore struct {
+	db *sql.DB
+}
+
+// NewMySQLStore 创建 MySQL 存储，桶保存在 rate_limit_bucket 表
+func NewMySQLStore(db *sql.DB) *MySQLStore {
+	return &MySQLStore{db: db}
+}
+
+// Take 从 key 对应的桶中取一个令牌，同一个桶的并发请求通过行锁串行执行
+func (s *MySQLStore) Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, fmt.Errorf("开始事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	// 桶不存在时插入满桶；已存在时这条语句直接对行加写锁，避免先加读锁再升级导致死锁
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rate_limit_bucket (bucket_key, tokens, updated_ms) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE bucket_key = bucket_key`,
+		key, rule.Burst, now.UnixMilli())
+	if err != nil {
+		return Result{}, fmt.Errorf("创建令牌桶失败: %v", err)
+	}
+
+	var tokens float64
+	var updatedMs int64
+	err = tx.QueryRowContext(ctx,
+		"SELECT tokens, updated_ms FROM rate_limit_bucket WHERE bucket_key = ? FOR UPDATE",
+		key).Scan(&tokens, &updatedMs)
+	if err != nil {
+		return Result{}, fmt.Errorf("查询令牌桶失败: %v", err)
+	}
+
+	tokens, res := rule.take(tokens, time.UnixMilli(updatedMs), now)
+	_, err = tx.ExecContext(ctx,
+		"UPDATE rate_limit_bucket SET tokens = ?, updated_ms = ? WHERE bucket_key = ?",
+		tokens, now.UnixMilli(), key)
+	if err != nil {
+		return Result{}, fmt.Errorf("更新令牌桶失败: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Result{}, fmt.Errorf("提交事务失败: %v", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,86 @@
+// Package ratelimit 令牌桶限流，桶的状态保存在可替换的存储中
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+)
+
+// Rule 令牌桶规则
+type Rule struct {
+	// Rate 每秒补充的令牌数
+	Rate float64
+	// Burst 桶容量
+	Burst int
+}
+
+// RuleFromConfig 将配置中的每分钟令牌数转换为规则
+func RuleFromConfig(cfg config.BucketLimit) Rule {
+	return Rule{Rate: float64(cfg.PerMinute) / 60, Burst: cfg.Burst}
+}
+
+// Unlimited 规则是否表示不限制
+func (r Rule) Unlimited() bool {
+	return r.Rate <= 0 || r.Burst <= 0
+}
+
+// Result 一次取令牌的结果
+type Result struct {
+	Allowed bool
+	// Limit 桶容量
+	Limit int
+	// Remaining 取令牌后剩余的完整令牌数
+	Remaining int
+	// RetryAfter 被拒绝时需要等待多久才有令牌
+	RetryAfter time.Duration
+	// Reset 桶重新装满需要的时间
+	Reset time.Duration
+}
+
+// Store 令牌桶存储
+type Store interface {
+	// Take 从 key 对应的桶中取一个令牌，桶不存在时视为满桶
+	Take(ctx context.Context, key string, rule Rule, now time.Time) (Result, error)
+}
+
+// NewStore 按配置的存储类型创建存储，mysql 存储使用 db
+func NewStore(name string, db *sql.DB) (Store, error) {
+	switch name {
+	case "", config.RateLimitStoreMemory:
+		return NewMemoryStore(), nil
+	case config.RateLimitStoreMySQL:
+		if db == nil {
+			return nil, fmt.Errorf("限流存储 %q 需要数据库连接", name)
+		}
+		return NewMySQLStore(db), nil
+	}
+	return nil, fmt.Errorf("未知的限流存储: %q", name)
+}
+
+// take 按上次更新以来经过的时间补充令牌后取一个令牌，返回取之后桶里的令牌数
+func (r Rule) take(tokens float64, last, now time.Time) (float64, Result) {
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(r.Burst), tokens+elapsed*r.Rate)
+	}
+
+	res := Result{Limit: r.Burst}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = r.refillTime(1 - tokens)
+	}
+	res.Remaining = int(tokens)
+	res.Reset = r.refillTime(float64(r.Burst) - tokens)
+	return tokens, res
+}
+
+// refillTime 补充 tokens 个令牌需要的时间
+func (r Rule) refillTime(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / r.Rate * float64(time.Second)))
+}
@@ -0,0 +1,294 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+// maxCollectionsPerUser 每个用户最多创建的收藏夹数量
+const maxCollectionsPerUser = 50
+
+type favoriteRepo struct {
+	d    Dialect
+	tags TagRepo
+}
+
+func (r *favoriteRepo) Add(q Querier, favorite *model.Favorite) error {
+	// 只能收藏正常展示的内容
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM square_content WHERE id = ? AND status = ?)",
+		favorite.ContentID, model.SquareStatusNormal).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("检查内容是否存在失败: %v", err)
+	}
+	if !exists {
+		return ErrContentNotFound
+	}
+
+	// 收藏夹必须属于当前用户
+	if favorite.CollectionID != 0 {
+		err = q.QueryRow("SELECT EXISTS(SELECT 1 FROM favorite_collection WHERE id = ? AND user_id = ?)",
+			favorite.CollectionID, favorite.UserID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("检查收藏夹是否存在失败: %v", err)
+		}
+		if !exists {
+			return ErrCollectionNotFound
+		}
+	}
+
+	// 已收藏时移动到指定收藏夹，并发收藏同一内容时插入冲突的一方改为移动
+	for attempt := 0; ; attempt++ {
+		var id int64
+		err = q.QueryRow("SELECT id FROM favorite WHERE user_id = ? AND content_id = ?",
+			favorite.UserID, favorite.ContentID).Scan(&id)
+		if err == nil {
+			_, err = q.Exec("UPDATE favorite SET collection_id = ? WHERE id = ?", favorite.CollectionID, id)
+			if err != nil {
+				return fmt.Errorf("收藏失败: %v", err)
+			}
+			favorite.ID = id
+			return nil
+		}
+		if err != sql.ErrNoRows {
+			return fmt.Errorf("查询收藏失败: %v", err)
+		}
+
+		result, err := q.Exec("INSERT INTO favorite (user_id, content_id, collection_id) VALUES (?, ?, ?)",
+			favorite.UserID, favorite.ContentID, favorite.CollectionID)
+		if r.d.IsDuplicateKey(err, "") && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("收藏失败: %v", err)
+		}
+
+		id, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("获取收藏ID失败: %v", err)
+		}
+		favorite.ID = id
+		return nil
+	}
+}
+
+func (r *favoriteRepo) Remove(q Querier, userID string, contentID int64) error {
+	result, err := q.Exec("DELETE FROM favorite WHERE user_id = ? AND content_id = ?", userID, contentID)
+	if err != nil {
+		return fmt.Errorf("取消收藏失败: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取影响行数失败: %v", err)
+	}
+	if affected == 0 {
+		return ErrFavoriteNotFound
+	}
+
+	return nil
+}
+
+func (r *favoriteRepo) List(q Querier, userID string, collectionID, cursor int64, pageSize int) (*model.FavoriteListResponse, error) {
+	query := `
+        SELECT
+            f.id, f.collection_id, f.created_at,
+            sc.id, sc.user_id, sc.record_id, sc.like_count, sc.created_at, sc.updated_at,
+            hr.image_url, hr.prompt, hr.created_at as record_created_at,
+            ui.nickname, ui.avatar_url,
+            CASE WHEN lr.id IS NOT NULL THEN 1 ELSE 0 END as is_liked
+        FROM favorite f
+        JOIN square_content sc ON f.content_id = sc.id
+        LEFT JOIN hair_style_records hr ON sc.record_id = hr.id
+        LEFT JOIN user_info ui ON sc.user_id = ui.user_id
+        LEFT JOIN like_record lr ON sc.id = lr.content_id AND lr.user_id = f.user_id
+        WHERE f.user_id = ? AND f.id < ? AND sc.status = ?
+    `
+
+	// 如果是第一页，使用一个足够大的ID作为cursor
+	if cursor == 0 {
+		cursor = 9223372036854775807
+	}
+	args := []interface{}{userID, cursor, model.SquareStatusNormal}
+	if collectionID >= 0 {
+		query += " AND f.collection_id = ?"
+		args = append(args, collectionID)
+	}
+	query += " ORDER BY f.id DESC LIMIT ?"
+	args = append(args, pageSize)
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询收藏列表失败: %v", err)
+	}
+	defer rows.Close()
+
+	var items []model.FavoriteItem
+	var contents []model.SquareContent
+	for rows.Next() {
+		var item model.FavoriteItem
+		var content model.SquareContent
+		var record model.HairStyleRecord
+		var nickname, avatarURL sql.NullString
+
+		err := rows.Scan(
+			&item.FavoriteID,
+			&item.CollectionID,
+			&item.FavoritedAt,
+			&content.ID,
+			&content.UserID,
+			&content.RecordID,
+			&content.LikeCount,
+			&content.CreatedAt,
+			&content.UpdatedAt,
+			&record.ImageURL,
+			&record.Prompt,
+			&record.CreatedAt,
+			&nickname,
+			&avatarURL,
+			&content.IsLiked,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("扫描收藏记录失败: %v", err)
+		}
+
+		// 未设置昵称和头像的用户使用默认展示
+		userInfo := model.UserInfo{Nickname: nickname.String, AvatarURL: avatarURL.String}
+		if !nickname.Valid {
+			userInfo.Nickname = defaultNickname(content.UserID)
+		}
+		if !avatarURL.Valid {
+			userInfo.AvatarURL = defaultAvatarURL
+		}
+
+		content.IsFavorited = true
+		content.Record = &record
+		content.UserInfo = &userInfo
+		items = append(items, item)
+		contents = append(contents, content)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询收藏列表失败: %v", err)
+	}
+	rows.Close()
+
+	if err := r.tags.Fill(q, contents); err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Content = &contents[i]
+	}
+
+	var nextCursor int64
+	if len(items) == pageSize {
+		nextCursor = items[len(items)-1].FavoriteID
+	}
+
+	return &model.FavoriteListResponse{
+		Records:    items,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+func (r *favoriteRepo) CreateCollection(q Querier, collection *model.FavoriteCollection) error {
+	var count int
+	err := q.QueryRow("SELECT COUNT(*) FROM favorite_collection WHERE user_id = ?", collection.UserID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("查询收藏夹数量失败: %v", err)
+	}
+	if count >= maxCollectionsPerUser {
+		return apperr.ErrCollectionLimit.With(maxCollectionsPerUser)
+	}
+
+	result, err := q.Exec("INSERT INTO favorite_collection (user_id, name) VALUES (?, ?)",
+		collection.UserID, collection.Name)
+	if r.d.IsDuplicateKey(err, "") {
+		return ErrCollectionExists
+	}
+	if err != nil {
+		return fmt.Errorf("创建收藏夹失败: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取收藏夹ID失败: %v", err)
+	}
+
+	collection.ID = id
+	return nil
+}
+
+func (r *favoriteRepo) Collections(q Querier, userID string) ([]model.FavoriteCollection, error) {
+	// 统计每个收藏夹的收藏数
+	counts := make(map[int64]int)
+	rows, err := q.Query("SELECT collection_id, COUNT(*) FROM favorite WHERE user_id = ? GROUP BY collection_id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("统计收藏数失败: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var collectionID int64
+		var count int
+		if err := rows.Scan(&collectionID, &count); err != nil {
+			return nil, fmt.Errorf("扫描收藏数失败: %v", err)
+		}
+		counts[collectionID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("统计收藏数失败: %v", err)
+	}
+	rows.Close()
+
+	collections := []model.FavoriteCollection{{
+		ID:            0,
+		UserID:        userID,
+		Name:          "默认收藏夹",
+		FavoriteCount: counts[0],
+	}}
+
+	rows, err = q.Query(`
+        SELECT id, user_id, name, created_at
+        FROM favorite_collection
+        WHERE user_id = ?
+        ORDER BY id
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询收藏夹失败: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var collection model.FavoriteCollection
+		if err := rows.Scan(&collection.ID, &collection.UserID, &collection.Name, &collection.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描收藏夹失败: %v", err)
+		}
+		collection.FavoriteCount = counts[collection.ID]
+		collections = append(collections, collection)
+	}
+
+	return collections, rows.Err()
+}
+
+func (r *favoriteRepo) DeleteCollection(q Querier, userID string, collectionID int64) error {
+	result, err := q.Exec("DELETE FROM favorite_collection WHERE id = ? AND user_id = ?", collectionID, userID)
+	if err != nil {
+		return fmt.Errorf("删除收藏夹失败: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取影响行数失败: %v", err)
+	}
+	if affected == 0 {
+		return ErrCollectionNotFound
+	}
+
+	_, err = q.Exec("UPDATE favorite SET collection_id = 0 WHERE user_id = ? AND collection_id = ?", userID, collectionID)
+	if err != nil {
+		return fmt.Errorf("移动收藏失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package repo_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo/sqlite"
+)
+
+func TestFavorites(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+
+	createUser(t, db, repos, "author")
+	content := shareContent(t, db, repos, "author", "短发")
+	collection := &model.FavoriteCollection{UserID: "u1", Name: "短发"}
+	if err := repos.Favorites.CreateCollection(db, collection); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重复收藏同一内容时移动到新的收藏夹
+	favorite := &model.Favorite{UserID: "u1", ContentID: content.ID}
+	if err := repos.Favorites.Add(db, favorite); err != nil {
+		t.Fatal(err)
+	}
+	firstID := favorite.ID
+	favorite = &model.Favorite{UserID: "u1", ContentID: content.ID, CollectionID: collection.ID}
+	if err := repos.Favorites.Add(db, favorite); err != nil {
+		t.Fatal(err)
+	}
+	if favorite.ID != firstID {
+		t.Errorf("favorite id = %d, want %d", favorite.ID, firstID)
+	}
+
+	list, err := repos.Favorites.List(db, "u1", collection.ID, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Records) != 1 {
+		t.Fatalf("records = %d, want 1", len(list.Records))
+	}
+	item := list.Records[0].Content
+	if item.ID != content.ID || !item.IsFavorited || len(item.Tags) != 1 || item.UserInfo.Nickname == "" {
+		t.Errorf("content = %+v, want favorited content with tags and default nickname", item)
+	}
+
+	// 删除收藏夹后收藏移回默认收藏夹
+	if err := repos.Favorites.DeleteCollection(db, "u1", collection.ID); err != nil {
+		t.Fatal(err)
+	}
+	collections, err := repos.Favorites.Collections(db, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collections) != 1 || collections[0].FavoriteCount != 1 {
+		t.Errorf("collections = %+v, want only the default collection with 1 favorite", collections)
+	}
+
+	if err := repos.Favorites.Remove(db, "u1", content.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Favorites.Remove(db, "u1", content.ID); !errors.Is(err, repo.ErrFavoriteNotFound) {
+		t.Errorf("Remove() error = %v, want ErrFavoriteNotFound", err)
+	}
+}
+
+func TestFavoriteRules(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+
+	createUser(t, db, repos, "author")
+	content := shareContent(t, db, repos, "author")
+	hidden := shareContent(t, db, repos, "author")
+	if _, err := db.Exec("UPDATE square_content SET status = ? WHERE id = ?", model.SquareStatusHidden, hidden.ID); err != nil {
+		t.Fatal(err)
+	}
+	collection := &model.FavoriteCollection{UserID: "u2", Name: "短发"}
+	if err := repos.Favorites.CreateCollection(db, collection); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一用户的收藏夹不能重名，不同用户互不影响
+	if err := repos.Favorites.CreateCollection(db, &model.FavoriteCollection{UserID: "u2", Name: "短发"}); !errors.Is(err, repo.ErrCollectionExists) {
+		t.Errorf("CreateCollection() duplicate name error = %v, want ErrCollectionExists", err)
+	}
+	if err := repos.Favorites.CreateCollection(db, &model.FavoriteCollection{UserID: "u1", Name: "短发"}); err != nil {
+		t.Errorf("CreateCollection() same name for another user error = %v", err)
+	}
+
+	// 只能收藏正常展示的内容，只能放进自己的收藏夹
+	tests := []struct {
+		name    string
+		fav     model.Favorite
+		wantErr error
+	}{
+		{"missing content", model.Favorite{UserID: "u1", ContentID: 999}, repo.ErrContentNotFound},
+		{"hidden content", model.Favorite{UserID: "u1", ContentID: hidden.ID}, repo.ErrContentNotFound},
+		{"other user's collection", model.Favorite{UserID: "u1", ContentID: content.ID, CollectionID: collection.ID}, repo.ErrCollectionNotFound},
+		{"own collection", model.Favorite{UserID: "u2", ContentID: content.ID, CollectionID: collection.ID}, nil},
+	}
+	for _, tt := range tests {
+		fav := tt.fav
+		if err := repos.Favorites.Add(db, &fav); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: Add() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestListFavorites(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+
+	createUser(t, db, repos, "author")
+	collection := &model.FavoriteCollection{UserID: "u1", Name: "短发"}
+	if err := repos.Favorites.CreateCollection(db, collection); err != nil {
+		t.Fatal(err)
+	}
+
+	// 前两条在默认收藏夹，后三条在自建收藏夹
+	var contents []*model.SquareContent
+	for i := 0; i < 5; i++ {
+		content := shareContent(t, db, repos, "author")
+		fav := &model.Favorite{UserID: "u1", ContentID: content.ID}
+		if i >= 2 {
+			fav.CollectionID = collection.ID
+		}
+		if err := repos.Favorites.Add(db, fav); err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, content)
+	}
+	// 收藏后被隐藏的内容不再出现在列表中
+	if _, err := db.Exec("UPDATE square_content SET status = ? WHERE id = ?", model.SquareStatusHidden, contents[4].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		collectionID int64
+		wantIDs      []int64
+	}{
+		{"all", -1, []int64{contents[3].ID, contents[2].ID, contents[1].ID, contents[0].ID}},
+		{"default", 0, []int64{contents[1].ID, contents[0].ID}},
+		{"collection", collection.ID, []int64{contents[3].ID, contents[2].ID}},
+	}
+	for _, tt := range tests {
+		// 每页3条翻到最后一页，按收藏时间倒序
+		var gotIDs []int64
+		var cursor int64
+		for page := 0; page < 3; page++ {
+			list, err := repos.Favorites.List(db, "u1", tt.collectionID, cursor, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, item := range list.Records {
+				gotIDs = append(gotIDs, item.Content.ID)
+			}
+			if cursor = list.NextCursor; cursor == 0 {
+				break
+			}
+		}
+		if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+			t.Errorf("%s: content ids = %v, want %v", tt.name, gotIDs, tt.wantIDs)
+		}
+	}
+}
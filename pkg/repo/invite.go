@@ -0,0 +1,329 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/signin"
+)
+
+type inviteRepo struct {
+	d      Dialect
+	ledger LedgerRepo
+}
+
+func (r *inviteRepo) Bind(q Querier, relation *model.InviteRelation, cfg config.InviteConfig) error {
+	// 检查是否已使用过邀请码和是否是自己的邀请码
+	var usedInviteCode, ownInviteCode sql.NullString
+	err := q.QueryRow("SELECT used_invite_code, invite_code FROM user_info WHERE user_id = ?"+r.d.ForUpdate(),
+		relation.InviteeID).Scan(&usedInviteCode, &ownInviteCode)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("检查用户邀请码状态失败: %v", err)
+	}
+	if usedInviteCode.String != "" {
+		return ErrInviteCodeUsed
+	}
+	if ownInviteCode.String == relation.InviteCode {
+		return ErrInviteCodeOwn
+	}
+
+	// 查找邀请人
+	err = q.QueryRow("SELECT user_id FROM user_info WHERE invite_code = ?",
+		relation.InviteCode).Scan(&relation.InviterID)
+	if err == sql.ErrNoRows {
+		return ErrInviteCodeInvalid
+	}
+	if err != nil {
+		return fmt.Errorf("查找邀请人失败: %v", err)
+	}
+	if err := r.checkCycle(q, relation, cfg); err != nil {
+		return err
+	}
+
+	// 奖励在被邀请人首次生成后发放，已经生成过的用户不能再绑定
+	var generated bool
+	err = q.QueryRow("SELECT EXISTS(SELECT 1 FROM hair_style_records WHERE user_id = ?)",
+		relation.InviteeID).Scan(&generated)
+	if err != nil {
+		return fmt.Errorf("检查生成记录失败: %v", err)
+	}
+	if generated {
+		return ErrInviteNotNewUser
+	}
+
+	// 防刷：同一设备只能作为被邀请人一次，同一IP短时间内绑定人数有限
+	relation.Status = model.InviteStatusPending
+	blocked, err := r.isSuspicious(q, relation, cfg)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		relation.Status = model.InviteStatusBlocked
+	}
+
+	result, err := q.Exec(`
+        INSERT INTO invite_relation (inviter_id, invitee_id, invite_code, device_id, ip, status)
+        VALUES (?, ?, ?, ?, ?, ?)
+    `, relation.InviterID, relation.InviteeID, relation.InviteCode, relation.DeviceID, relation.IP, relation.Status)
+	if r.d.IsDuplicateKey(err, "") {
+		return ErrInviteCodeUsed
+	}
+	if err != nil {
+		return fmt.Errorf("保存邀请关系失败: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取邀请关系ID失败: %v", err)
+	}
+	relation.ID = id
+
+	// 标记用户已使用邀请码
+	_, err = q.Exec("UPDATE user_info SET used_invite_code = ? WHERE user_id = ?", relation.InviteCode, relation.InviteeID)
+	if err != nil {
+		return fmt.Errorf("更新用户邀请码使用状态失败: %v", err)
+	}
+	return nil
+}
+
+// checkCycle 沿邀请关系向上查找邀请人的上级，在发放奖励的层级内遇到被邀请人时视为形成环
+// 邀请人可能更换过邀请码，按邀请关系判断；至少检查一级，防止互相邀请
+func (r *inviteRepo) checkCycle(q Querier, relation *model.InviteRelation, cfg config.InviteConfig) error {
+	ancestorID := relation.InviterID
+	for depth := 0; depth < max(len(cfg.LevelRewards), 1); depth++ {
+		var parentID string
+		err := q.QueryRow("SELECT inviter_id FROM invite_relation WHERE invitee_id = ?", ancestorID).Scan(&parentID)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("检查邀请关系失败: %v", err)
+		}
+		if parentID == relation.InviteeID {
+			return ErrInviteCycle
+		}
+		ancestorID = parentID
+	}
+	return nil
+}
+
+// isSuspicious 判断邀请关系是否命中防刷规则
+func (r *inviteRepo) isSuspicious(q Querier, relation *model.InviteRelation, cfg config.InviteConfig) (bool, error) {
+	if relation.DeviceID != "" {
+		var exists bool
+		err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM invite_relation WHERE device_id = ?)",
+			relation.DeviceID).Scan(&exists)
+		if err != nil {
+			return false, fmt.Errorf("检查设备邀请记录失败: %v", err)
+		}
+		if exists {
+			return true, nil
+		}
+	}
+
+	if relation.IP != "" && cfg.MaxInviteesPerIP > 0 {
+		var count int
+		err := q.QueryRow("SELECT COUNT(*) FROM invite_relation WHERE ip = ? AND created_at >= ?",
+			relation.IP, time.Now().Add(-24*time.Hour)).Scan(&count)
+		if err != nil {
+			return false, fmt.Errorf("检查IP邀请记录失败: %v", err)
+		}
+		if count >= cfg.MaxInviteesPerIP {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *inviteRepo) GrantRewards(q Querier, inviteeID string, todayStart time.Time, cfg config.InviteConfig) error {
+	// 只处理待发放的邀请关系，保证奖励只发放一次
+	var relationID int64
+	var inviterID string
+	err := q.QueryRow("SELECT id, inviter_id FROM invite_relation WHERE invitee_id = ? AND status = ?"+r.d.ForUpdate(),
+		inviteeID, model.InviteStatusPending).Scan(&relationID, &inviterID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询邀请关系失败: %v", err)
+	}
+
+	// 邀请奖励属于促销coin，按配置过期
+	expireDate := promoExpireDate(signin.DateOf(todayStart), cfg.RewardExpireDays)
+
+	// 被邀请人奖励
+	if err := r.grant(q, relationID, inviteeID, 0, cfg.InviteeReward, expireDate); err != nil {
+		return err
+	}
+
+	// 直接邀请人受上限限制，达到上限后不再给任何一级邀请人发放奖励
+	status := model.InviteStatusRewarded
+	capped, err := r.isInviterCapped(q, inviterID, todayStart, cfg)
+	if err != nil {
+		return err
+	}
+	if capped {
+		status = model.InviteStatusCapped
+	} else {
+		beneficiaryID := inviterID
+		for i, amount := range cfg.LevelRewards {
+			if i > 0 {
+				// 查找上一级邀请人，没有上一级或形成环时停止
+				var parentID string
+				err := q.QueryRow("SELECT inviter_id FROM invite_relation WHERE invitee_id = ?", beneficiaryID).Scan(&parentID)
+				if err == sql.ErrNoRows {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("查询上级邀请人失败: %v", err)
+				}
+				if parentID == inviteeID {
+					break
+				}
+				beneficiaryID = parentID
+			}
+			if err := r.grant(q, relationID, beneficiaryID, i+1, amount, expireDate); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = q.Exec("UPDATE invite_relation SET status = ?, rewarded_at = CURRENT_TIMESTAMP WHERE id = ?", status, relationID)
+	if err != nil {
+		return fmt.Errorf("更新邀请关系状态失败: %v", err)
+	}
+	return nil
+}
+
+// isInviterCapped 判断邀请人是否已达到奖励上限
+func (r *inviteRepo) isInviterCapped(q Querier, inviterID string, todayStart time.Time, cfg config.InviteConfig) (bool, error) {
+	var today, total int
+	err := q.QueryRow(`
+        SELECT COALESCE(SUM(CASE WHEN rewarded_at >= ? THEN 1 ELSE 0 END), 0), COUNT(*)
+        FROM invite_relation
+        WHERE inviter_id = ? AND status = ?
+    `, todayStart, inviterID, model.InviteStatusRewarded).Scan(&today, &total)
+	if err != nil {
+		return false, fmt.Errorf("查询邀请奖励次数失败: %v", err)
+	}
+
+	if cfg.MaxRewardsPerDay > 0 && today >= cfg.MaxRewardsPerDay {
+		return true, nil
+	}
+	if cfg.MaxRewardsTotal > 0 && total >= cfg.MaxRewardsTotal {
+		return true, nil
+	}
+	return false, nil
+}
+
+// grant 记录邀请奖励并增加coin
+func (r *inviteRepo) grant(q Querier, relationID int64, beneficiaryID string, level, amount int, expireDate *signin.Date) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	_, err := q.Exec(`
+        INSERT INTO invite_reward (relation_id, beneficiary_id, level, amount)
+        VALUES (?, ?, ?, ?)
+    `, relationID, beneficiaryID, level, amount)
+	if err != nil {
+		return fmt.Errorf("记录邀请奖励失败: %v", err)
+	}
+
+	refID := strconv.FormatInt(relationID, 10)
+	if _, err := r.ledger.Change(q, beneficiaryID, amount, model.CoinSourceInvite, refID, expireDate); err != nil {
+		return fmt.Errorf("发放邀请奖励失败: %v", err)
+	}
+	return nil
+}
+
+func (r *inviteRepo) Stats(q Querier, userID string, cursor int64, pageSize int) (*model.InviteStatsResponse, error) {
+	stats := &model.InviteStatsResponse{Invitees: []model.InviteeInfo{}}
+
+	err := q.QueryRow(`
+        SELECT COUNT(*),
+            COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0),
+            COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0)
+        FROM invite_relation
+        WHERE inviter_id = ?
+    `, model.InviteStatusRewarded, model.InviteStatusPending, userID).Scan(
+		&stats.TotalInvitees, &stats.RewardedInvitees, &stats.PendingInvitees)
+	if err != nil {
+		return nil, fmt.Errorf("查询邀请统计失败: %v", err)
+	}
+
+	err = q.QueryRow(`
+        SELECT COALESCE(SUM(CASE WHEN level = 1 THEN amount ELSE 0 END), 0),
+            COALESCE(SUM(CASE WHEN level > 1 THEN amount ELSE 0 END), 0)
+        FROM invite_reward
+        WHERE beneficiary_id = ?
+    `, userID).Scan(&stats.DirectReward, &stats.IndirectReward)
+	if err != nil {
+		return nil, fmt.Errorf("查询邀请奖励失败: %v", err)
+	}
+
+	// 如果是第一页，使用一个足够大的ID作为cursor
+	if cursor == 0 {
+		cursor = 9223372036854775807
+	}
+
+	rows, err := q.Query(`
+        SELECT ir.id, ir.invitee_id, ir.status, ir.created_at,
+            ui.nickname, ui.avatar_url, COALESCE(rw.amount, 0) as reward
+        FROM invite_relation ir
+        LEFT JOIN user_info ui ON ir.invitee_id = ui.user_id
+        LEFT JOIN invite_reward rw ON rw.relation_id = ir.id AND rw.beneficiary_id = ir.inviter_id
+        WHERE ir.inviter_id = ? AND ir.id < ?
+        ORDER BY ir.id DESC
+        LIMIT ?
+    `, userID, cursor, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("查询被邀请人失败: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var invitee model.InviteeInfo
+		var inviteeID string
+		var nickname, avatarURL sql.NullString
+		err := rows.Scan(
+			&invitee.RelationID,
+			&inviteeID,
+			&invitee.Status,
+			&invitee.CreatedAt,
+			&nickname,
+			&avatarURL,
+			&invitee.Reward,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("扫描被邀请人失败: %v", err)
+		}
+
+		// 未设置昵称和头像的用户使用默认展示
+		invitee.Nickname, invitee.AvatarURL = nickname.String, avatarURL.String
+		if !nickname.Valid {
+			invitee.Nickname = defaultNickname(inviteeID)
+		}
+		if !avatarURL.Valid {
+			invitee.AvatarURL = defaultAvatarURL
+		}
+		stats.Invitees = append(stats.Invitees, invitee)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询被邀请人失败: %v", err)
+	}
+
+	if len(stats.Invitees) == pageSize {
+		stats.NextCursor = stats.Invitees[len(stats.Invitees)-1].RelationID
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,211 @@
+package repo_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo/sqlite"
+)
+
+// bindInvite 使用 inviterID 的邀请码为 inviteeID 绑定邀请关系
+func bindInvite(t *testing.T, db *sql.DB, repos *repo.Repos, inviterID, inviteeID string, cfg config.InviteConfig) *model.InviteRelation {
+	t.Helper()
+	relation := &model.InviteRelation{InviteeID: inviteeID, InviteCode: "CODE-" + inviterID}
+	if err := repos.Invites.Bind(db, relation, cfg); err != nil {
+		t.Fatal(err)
+	}
+	return relation
+}
+
+func TestInviteRewards(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+	cfg := config.InviteConfig{InviteeReward: 10, LevelRewards: []int{20, 5}}
+	todayStart := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	// a 邀请 b，b 邀请 c，c 完成首次生成后 b 获得一级奖励，a 获得二级奖励
+	for _, userID := range []string{"a", "b", "c"} {
+		createUser(t, db, repos, userID)
+	}
+	bindInvite(t, db, repos, "a", "b", cfg)
+	bindInvite(t, db, repos, "b", "c", cfg)
+
+	// 重复触发只发放一次
+	for i := 0; i < 2; i++ {
+		if err := repos.Invites.GrantRewards(db, "c", todayStart, cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for userID, want := range map[string]int{"a": 5, "b": 20, "c": 10} {
+		balance, err := repos.Ledger.Balance(db, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balance != want {
+			t.Errorf("%s balance = %d, want %d", userID, balance, want)
+		}
+	}
+
+	stats, err := repos.Invites.Stats(db, "b", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalInvitees != 1 || stats.RewardedInvitees != 1 || stats.DirectReward != 20 || len(stats.Invitees) != 1 {
+		t.Errorf("stats = %+v, want 1 rewarded invitee with 20 direct reward", stats)
+	}
+}
+
+func TestInviteBind(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+	twoLevels := config.InviteConfig{LevelRewards: []int{20, 5}}
+	oneLevel := config.InviteConfig{LevelRewards: []int{20}}
+
+	// a 邀请 b，b 邀请 c；d 已经生成过发型
+	for _, userID := range []string{"a", "b", "c", "d"} {
+		createUser(t, db, repos, userID)
+	}
+	bindInvite(t, db, repos, "a", "b", twoLevels)
+	bindInvite(t, db, repos, "b", "c", twoLevels)
+	if err := repos.Records.Save(db, &model.HairStyleRecord{UserID: "d", ImageURL: "https://img/1", Prompt: "短发"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		inviteeID string
+		inviterID string
+		cfg       config.InviteConfig
+		wantErr   error
+	}{
+		{"own code", "a", "a", twoLevels, repo.ErrInviteCodeOwn},
+		{"already used", "b", "c", twoLevels, repo.ErrInviteCodeUsed},
+		{"generated before", "d", "a", twoLevels, repo.ErrInviteNotNewUser},
+		{"mutual", "a", "b", twoLevels, repo.ErrInviteCycle},
+		// a→b→c→a 在两级奖励内形成环
+		{"three users", "a", "c", twoLevels, repo.ErrInviteCycle},
+		// 只发放一级奖励时，超出层级的环不会重复发放，允许绑定
+		{"beyond reward levels", "a", "c", oneLevel, nil},
+	}
+	for _, tt := range tests {
+		relation := &model.InviteRelation{InviteeID: tt.inviteeID, InviteCode: "CODE-" + tt.inviterID}
+		err := repos.Invites.Bind(db, relation, tt.cfg)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: Bind() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestInviteAntiAbuse(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+	cfg := config.InviteConfig{InviteeReward: 10, LevelRewards: []int{20}, MaxInviteesPerIP: 2}
+	todayStart := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	for _, userID := range []string{"a", "b", "c", "d", "e"} {
+		createUser(t, db, repos, userID)
+	}
+	tests := []struct {
+		inviteeID  string
+		deviceID   string
+		ip         string
+		wantStatus int
+	}{
+		{"b", "device-1", "1.1.1.1", model.InviteStatusPending},
+		// 同一设备只能作为被邀请人一次
+		{"c", "device-1", "2.2.2.2", model.InviteStatusBlocked},
+		{"d", "device-2", "1.1.1.1", model.InviteStatusPending},
+		// 同一IP 24小时内最多绑定2人
+		{"e", "device-3", "1.1.1.1", model.InviteStatusBlocked},
+	}
+	for _, tt := range tests {
+		relation := &model.InviteRelation{InviteeID: tt.inviteeID, InviteCode: "CODE-a", DeviceID: tt.deviceID, IP: tt.ip}
+		if err := repos.Invites.Bind(db, relation, cfg); err != nil {
+			t.Fatal(err)
+		}
+		if relation.Status != tt.wantStatus {
+			t.Errorf("%s status = %d, want %d", tt.inviteeID, relation.Status, tt.wantStatus)
+		}
+		if err := repos.Invites.GrantRewards(db, tt.inviteeID, todayStart, cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 命中防刷规则的关系双方都不发放奖励
+	for userID, want := range map[string]int{"a": 40, "b": 10, "c": 0, "d": 10, "e": 0} {
+		balance, err := repos.Ledger.Balance(db, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balance != want {
+			t.Errorf("%s balance = %d, want %d", userID, balance, want)
+		}
+	}
+}
+
+func TestInviteRewardCaps(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.InviteConfig
+	}{
+		{"per day", config.InviteConfig{InviteeReward: 10, LevelRewards: []int{20}, MaxRewardsPerDay: 1}},
+		{"total", config.InviteConfig{InviteeReward: 10, LevelRewards: []int{20}, MaxRewardsTotal: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sqlite.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			repos := sqlite.New()
+			todayStart := time.Now().UTC().Add(-time.Hour)
+
+			// 邀请人达到上限后不再获得奖励，被邀请人仍然获得奖励
+			for _, userID := range []string{"a", "b", "c"} {
+				createUser(t, db, repos, userID)
+			}
+			wantStatus := map[string]int{"b": model.InviteStatusRewarded, "c": model.InviteStatusCapped}
+			for _, inviteeID := range []string{"b", "c"} {
+				relation := bindInvite(t, db, repos, "a", inviteeID, tt.cfg)
+				if err := repos.Invites.GrantRewards(db, inviteeID, todayStart, tt.cfg); err != nil {
+					t.Fatal(err)
+				}
+				var status int
+				if err := db.QueryRow("SELECT status FROM invite_relation WHERE id = ?", relation.ID).Scan(&status); err != nil {
+					t.Fatal(err)
+				}
+				if status != wantStatus[inviteeID] {
+					t.Errorf("%s status = %d, want %d", inviteeID, status, wantStatus[inviteeID])
+				}
+			}
+
+			for userID, want := range map[string]int{"a": 20, "b": 10, "c": 10} {
+				balance, err := repos.Ledger.Balance(db, userID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if balance != want {
+					t.Errorf("%s balance = %d, want %d", userID, balance, want)
+				}
+			}
+		})
+	}
+}
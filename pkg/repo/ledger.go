@@ -0,0 +1,296 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/signin"
+)
+
+type ledgerRepo struct {
+	d Dialect
+}
+
+func (r *ledgerRepo) Balance(q Querier, userID string) (int, error) {
+	var coin int
+	err := q.QueryRow("SELECT coin FROM user_info WHERE user_id = ?", userID).Scan(&coin)
+	if err == sql.ErrNoRows {
+		return 0, ErrUserNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询coin失败: %v", err)
+	}
+	return coin, nil
+}
+
+func (r *ledgerRepo) Change(q Querier, userID string, amount int, source, refID string, expireDate *signin.Date) (int, error) {
+	balance, err := r.lockBalance(q, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if amount < 0 {
+		// 过期批次在读取余额或扣除时才作废，扣除前先作废，避免消耗已过期的coin
+		balance, err = r.expireLots(q, userID, balance, signin.Today())
+		if err != nil {
+			return 0, err
+		}
+		if balance+amount < 0 {
+			return 0, ErrInsufficientCoin
+		}
+		if err := r.consumeLots(q, userID, balance, -amount); err != nil {
+			return 0, err
+		}
+	} else if amount > 0 {
+		var expire interface{}
+		if expireDate != nil {
+			expire = expireDate.String()
+		}
+		_, err = q.Exec(`
+            INSERT INTO coin_lot (user_id, source, ref_id, amount, remaining, expire_date)
+            VALUES (?, ?, ?, ?, ?, ?)
+        `, userID, source, refID, amount, amount, expire)
+		if err != nil {
+			return 0, fmt.Errorf("记录coin批次失败: %v", err)
+		}
+	}
+
+	balance += amount
+	if err := r.updateBalance(q, userID, amount, balance, source, refID); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+func (r *ledgerRepo) Reclaim(q Querier, userID, lotSource, lotRefID string, amount int, source, refID string) (int, error) {
+	balance, err := r.lockBalance(q, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var lotID int64
+	var remaining int
+	err = q.QueryRow(`
+        SELECT id, remaining FROM coin_lot
+        WHERE user_id = ? AND source = ? AND ref_id = ?`+r.d.ForUpdate(), userID, lotSource, lotRefID).Scan(&lotID, &remaining)
+	if err == sql.ErrNoRows {
+		return 0, ErrInsufficientCoin
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询coin批次失败: %v", err)
+	}
+	if remaining < amount || balance < amount {
+		return 0, ErrInsufficientCoin
+	}
+
+	_, err = q.Exec("UPDATE coin_lot SET remaining = remaining - ? WHERE id = ?", amount, lotID)
+	if err != nil {
+		return 0, fmt.Errorf("扣减coin批次失败: %v", err)
+	}
+
+	balance -= amount
+	if err := r.updateBalance(q, userID, -amount, balance, source, refID); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+func (r *ledgerRepo) ExpireLots(q Querier, userID string, today signin.Date) (int, error) {
+	balance, err := r.lockBalance(q, userID)
+	if errors.Is(err, ErrUserNotFound) {
+		// 用户已不存在时直接作废批次，避免每次任务都重复处理
+		_, err = q.Exec("UPDATE coin_lot SET remaining = 0 WHERE user_id = ?", userID)
+		if err != nil {
+			return 0, fmt.Errorf("作废过期coin失败: %v", err)
+		}
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	after, err := r.expireLots(q, userID, balance, today)
+	if err != nil {
+		return 0, err
+	}
+	return balance - after, nil
+}
+
+func (r *ledgerRepo) ExpiredUsers(q Querier, today signin.Date, limit int) ([]string, error) {
+	rows, err := q.Query(`
+        SELECT DISTINCT user_id FROM coin_lot
+        WHERE remaining > 0 AND expire_date < ?
+        LIMIT ?
+    `, today.String(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询过期coin失败: %v", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("扫描过期coin失败: %v", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询过期coin失败: %v", err)
+	}
+	return userIDs, nil
+}
+
+func (r *ledgerRepo) Expiring(q Querier, userID string, today signin.Date) (*model.CoinExpiry, error) {
+	var expireDate sql.NullTime
+	var amount int
+	err := q.QueryRow(`
+        SELECT expire_date, SUM(remaining) FROM coin_lot
+        WHERE user_id = ? AND remaining > 0 AND expire_date >= ?
+        GROUP BY expire_date
+        ORDER BY expire_date
+        LIMIT 1
+    `, userID, today.String()).Scan(&expireDate, &amount)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询即将过期coin失败: %v", err)
+	}
+
+	return &model.CoinExpiry{
+		Amount:     amount,
+		ExpireDate: signin.DateOf(expireDate.Time).String(),
+	}, nil
+}
+
+// lockBalance 锁定并返回用户的coin余额
+func (r *ledgerRepo) lockBalance(q Querier, userID string) (int, error) {
+	var balance int
+	err := q.QueryRow("SELECT coin FROM user_info WHERE user_id = ?"+r.d.ForUpdate(), userID).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return 0, ErrUserNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询coin失败: %v", err)
+	}
+	return balance, nil
+}
+
+// updateBalance 更新用户coin余额并记录流水
+func (r *ledgerRepo) updateBalance(q Querier, userID string, amount, balance int, source, refID string) error {
+	_, err := q.Exec("UPDATE user_info SET coin = ? WHERE user_id = ?", balance, userID)
+	if err != nil {
+		return fmt.Errorf("更新coin失败: %v", err)
+	}
+
+	_, err = q.Exec(`
+        INSERT INTO coin_ledger (user_id, amount, balance, source, ref_id)
+        VALUES (?, ?, ?, ?, ?)
+    `, userID, amount, balance, source, refID)
+	if err != nil {
+		return fmt.Errorf("记录coin流水失败: %v", err)
+	}
+	return nil
+}
+
+// consumeLots 按顺序扣减coin：先按过期日期从早到晚扣减会过期的批次，再扣没有批次的基础余额，最后扣永不过期的批次
+// 注册赠送的coin和批次表上线前的余额没有对应批次，先于充值等永不过期的批次扣除，使充值批次尽量保持完整可退款
+func (r *ledgerRepo) consumeLots(q Querier, userID string, balance, amount int) error {
+	var lotTotal int
+	err := q.QueryRow(`
+        SELECT COALESCE(SUM(remaining), 0) FROM coin_lot
+        WHERE user_id = ? AND remaining > 0`, userID).Scan(&lotTotal)
+	if err != nil {
+		return fmt.Errorf("查询coin批次失败: %v", err)
+	}
+	base := max(balance-lotTotal, 0)
+
+	rows, err := q.Query(`
+        SELECT id, remaining, expire_date IS NULL FROM coin_lot
+        WHERE user_id = ? AND remaining > 0
+        ORDER BY expire_date IS NULL, expire_date, id`+r.d.ForUpdate(), userID)
+	if err != nil {
+		return fmt.Errorf("查询coin批次失败: %v", err)
+	}
+
+	type lot struct {
+		id     int64
+		deduct int
+	}
+	var lots []lot
+	for rows.Next() && amount > 0 {
+		var id int64
+		var remaining int
+		var permanent bool
+		if err := rows.Scan(&id, &remaining, &permanent); err != nil {
+			rows.Close()
+			return fmt.Errorf("扫描coin批次失败: %v", err)
+		}
+		if permanent && base > 0 {
+			// 会过期的批次已扣完，轮到永不过期的批次前先扣基础余额
+			deduct := min(base, amount)
+			base -= deduct
+			amount -= deduct
+			if amount == 0 {
+				break
+			}
+		}
+		deduct := min(remaining, amount)
+		lots = append(lots, lot{id: id, deduct: deduct})
+		amount -= deduct
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("查询coin批次失败: %v", err)
+	}
+
+	for _, l := range lots {
+		_, err := q.Exec("UPDATE coin_lot SET remaining = remaining - ? WHERE id = ?", l.deduct, l.id)
+		if err != nil {
+			return fmt.Errorf("扣减coin批次失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// expireLots 作废用户在 today 之前过期的coin批次，从余额中扣除并记录流水，返回扣除后的余额
+func (r *ledgerRepo) expireLots(q Querier, userID string, balance int, today signin.Date) (int, error) {
+	var expired int
+	err := q.QueryRow(`
+        SELECT COALESCE(SUM(remaining), 0) FROM coin_lot
+        WHERE user_id = ? AND remaining > 0 AND expire_date < ?`+r.d.ForUpdate(), userID, today.String()).Scan(&expired)
+	if err != nil {
+		return 0, fmt.Errorf("查询过期coin失败: %v", err)
+	}
+	if expired == 0 {
+		return balance, nil
+	}
+
+	_, err = q.Exec(`
+        UPDATE coin_lot SET remaining = 0
+        WHERE user_id = ? AND remaining > 0 AND expire_date < ?
+    `, userID, today.String())
+	if err != nil {
+		return 0, fmt.Errorf("作废过期coin失败: %v", err)
+	}
+
+	// 余额不会扣成负数，防止批次与余额不一致时出现负余额
+	expired = min(expired, balance)
+	balance -= expired
+	if err := r.updateBalance(q, userID, -expired, balance, model.CoinSourceExpire, today.String()); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// promoExpireDate 返回今天发放的促销coin的过期日期，days 不大于0时永不过期
+func promoExpireDate(today signin.Date, days int) *signin.Date {
+	if days <= 0 {
+		return nil
+	}
+	d := today.AddDays(days)
+	return &d
+}
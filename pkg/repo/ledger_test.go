@@ -0,0 +1,79 @@
+package repo_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo/sqlite"
+	"github.com/MRsummer/ChangeHairStyle/pkg/signin"
+)
+
+func TestReclaimPurchaseLot(t *testing.T) {
+	tests := []struct {
+		name        string
+		spend       int
+		wantErr     error
+		wantBalance int
+	}{
+		// 消费先用掉即将过期的促销coin，充值批次保持完整，可以退款
+		{"promo spent first", 30, nil, 20},
+		// 消费超出促销coin后动用了充值批次，不能退款
+		{"purchase lot partly spent", 80, repo.ErrInsufficientCoin, 70},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sqlite.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			repos := sqlite.New()
+
+			if err := repos.Users.Create(db, &model.UserInfo{UserID: "u1", InviteCode: "CODE1"}); err != nil {
+				t.Fatal(err)
+			}
+			expire := signin.Today().AddDays(7)
+			if _, err := repos.Ledger.Change(db, "u1", 100, model.CoinSourcePurchase, "order-1", nil); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repos.Ledger.Change(db, "u1", 50, model.CoinSourceRedeem, "promo", &expire); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repos.Ledger.Change(db, "u1", -tt.spend, model.CoinSourceGeneration, "", nil); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = repos.Ledger.Reclaim(db, "u1", model.CoinSourcePurchase, "order-1", 100, model.CoinSourceRefund, "refund-1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Reclaim() error = %v, want %v", err, tt.wantErr)
+			}
+			balance, err := repos.Ledger.Balance(db, "u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if balance != tt.wantBalance {
+				t.Errorf("balance = %d, want %d", balance, tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestReclaimMissingLot(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+
+	// 批次表上线前的余额没有对应批次，无法确认来源，不允许扣回
+	if err := repos.Users.Create(db, &model.UserInfo{UserID: "u1", Coin: 100, InviteCode: "CODE1"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = repos.Ledger.Reclaim(db, "u1", model.CoinSourcePurchase, "order-1", 100, model.CoinSourceRefund, "refund-1")
+	if !errors.Is(err, repo.ErrInsufficientCoin) {
+		t.Fatalf("Reclaim() error = %v, want ErrInsufficientCoin", err)
+	}
+}
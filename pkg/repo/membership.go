@@ -0,0 +1,126 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+type membershipRepo struct {
+	d Dialect
+}
+
+func (r *membershipRepo) Active(q Querier, userID string, now time.Time) (*model.Membership, error) {
+	var m model.Membership
+	err := q.QueryRow(`
+        SELECT user_id, tier, expires_at, created_at, updated_at
+        FROM user_membership
+        WHERE user_id = ? AND expires_at > ?
+    `, userID, now).Scan(&m.UserID, &m.Tier, &m.ExpiresAt, &m.CreatedAt, &m.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询会员信息失败: %v", err)
+	}
+	return &m, nil
+}
+
+func (r *membershipRepo) Extend(q Querier, userID, tier string, days int, now time.Time) (*model.Membership, error) {
+	m := &model.Membership{UserID: userID, Tier: tier}
+	var expiresAt sql.NullTime
+	var currentTier string
+	err := q.QueryRow("SELECT tier, expires_at FROM user_membership WHERE user_id = ?"+r.d.ForUpdate(),
+		userID).Scan(&currentTier, &expiresAt)
+	exists := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询会员信息失败: %v", err)
+	}
+
+	start := now
+	if expiresAt.Valid && expiresAt.Time.After(now) {
+		switch {
+		case currentTier == tier:
+			start = expiresAt.Time
+		case days < 0:
+			// 缩短有效期只作用于同等级的会员
+			m.Tier = currentTier
+			m.ExpiresAt = expiresAt.Time
+			return m, nil
+		case config.TierRank(tier) < config.TierRank(currentTier):
+			return nil, ErrMembershipDowngrade
+		}
+		// 升级立即生效，新等级的有效期从现在开始计算
+	}
+	if days < 0 && !start.After(now) {
+		// 会员已过期，无需再缩短
+		m.ExpiresAt = start
+		return m, nil
+	}
+	m.ExpiresAt = start.AddDate(0, 0, days)
+
+	// 会员行已加锁，按是否存在分别插入或更新，不依赖各数据库不同的 upsert 语法
+	if exists {
+		_, err = q.Exec("UPDATE user_membership SET tier = ?, expires_at = ? WHERE user_id = ?",
+			m.Tier, m.ExpiresAt, userID)
+	} else {
+		_, err = q.Exec("INSERT INTO user_membership (user_id, tier, expires_at) VALUES (?, ?, ?)",
+			userID, m.Tier, m.ExpiresAt)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("更新会员信息失败: %v", err)
+	}
+
+	return m, nil
+}
+
+func (r *membershipRepo) FreeUsage(q Querier, userID, date string) (int, error) {
+	var used int
+	err := q.QueryRow("SELECT used_count FROM daily_free_usage WHERE user_id = ? AND usage_date = ?",
+		userID, date).Scan(&used)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询免费次数失败: %v", err)
+	}
+	return used, nil
+}
+
+func (r *membershipRepo) ConsumeFreeQuota(q Querier, userID, date string, quota int) (bool, error) {
+	if quota <= 0 {
+		return false, nil
+	}
+
+	// 当天已有记录时，次数未达到上限才累加，并发请求不会超用
+	for attempt := 0; attempt < 2; attempt++ {
+		result, err := q.Exec(`
+            UPDATE daily_free_usage SET used_count = used_count + 1
+            WHERE user_id = ? AND usage_date = ? AND used_count < ?
+        `, userID, date, quota)
+		if err != nil {
+			return false, fmt.Errorf("使用免费次数失败: %v", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return false, fmt.Errorf("获取影响行数失败: %v", err)
+		}
+		if affected > 0 {
+			return true, nil
+		}
+
+		// 没有更新说明当天还没有记录或额度已用完，插入冲突时说明记录已存在，再尝试累加一次
+		_, err = q.Exec("INSERT INTO daily_free_usage (user_id, usage_date, used_count) VALUES (?, ?, 1)",
+			userID, date)
+		if err == nil {
+			return true, nil
+		}
+		if !r.d.IsDuplicateKey(err, "") {
+			return false, fmt.Errorf("使用免费次数失败: %v", err)
+		}
+	}
+	return false, nil
+}
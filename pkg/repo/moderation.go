@@ -0,0 +1,264 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+type moderationRepo struct {
+	d             Dialect
+	notifications NotificationRepo
+}
+
+func (r *moderationRepo) Report(q Querier, report *model.ContentReport, hideThreshold int) (bool, error) {
+	// 锁定内容行，避免并发举报时重复隐藏
+	var status, reportCount int
+	err := q.QueryRow("SELECT status, report_count FROM square_content WHERE id = ?"+r.d.ForUpdate(),
+		report.ContentID).Scan(&status, &reportCount)
+	if err == sql.ErrNoRows {
+		return false, ErrContentNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("查询内容失败: %v", err)
+	}
+	if status == model.SquareStatusRemoved {
+		return false, ErrContentRemoved
+	}
+
+	// 记录举报
+	result, err := q.Exec(`
+        INSERT INTO content_report (content_id, reporter_id, reason, detail)
+        VALUES (?, ?, ?, ?)
+    `, report.ContentID, report.ReporterID, report.Reason, report.Detail)
+	if r.d.IsDuplicateKey(err, "") {
+		return false, ErrAlreadyReported
+	}
+	if err != nil {
+		return false, fmt.Errorf("保存举报记录失败: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("获取举报ID失败: %v", err)
+	}
+	report.ID = id
+
+	// 更新举报数，达到阈值时自动隐藏
+	reportCount++
+	hidden := status == model.SquareStatusNormal && hideThreshold > 0 && reportCount >= hideThreshold
+	newStatus := status
+	if hidden {
+		newStatus = model.SquareStatusHidden
+	}
+	_, err = q.Exec("UPDATE square_content SET report_count = ?, status = ? WHERE id = ?",
+		reportCount, newStatus, report.ContentID)
+	if err != nil {
+		return false, fmt.Errorf("更新举报数失败: %v", err)
+	}
+
+	if hidden {
+		note := fmt.Sprintf("举报数达到%d，自动隐藏", reportCount)
+		if err := r.Log(q, report.ContentID, "system", model.ModerationActionAutoHide, note); err != nil {
+			return false, err
+		}
+	}
+
+	return hidden, nil
+}
+
+func (r *moderationRepo) Queue(q Querier, status int, cursor int64, pageSize int) (*model.ReportedContentResponse, error) {
+	query := `
+        SELECT sc.id, sc.user_id, sc.status, sc.report_count, sc.created_at,
+            COALESCE(hr.image_url, ''), COALESCE(hr.prompt, '')
+        FROM square_content sc
+        LEFT JOIN hair_style_records hr ON sc.record_id = hr.id
+        WHERE sc.id < ?
+            AND (sc.status = ?
+                OR EXISTS (SELECT 1 FROM content_report cr WHERE cr.content_id = sc.id AND cr.status = ?))
+    `
+
+	// 如果是第一页，使用一个足够大的ID作为cursor
+	if cursor == 0 {
+		cursor = 9223372036854775807
+	}
+	args := []interface{}{cursor, model.SquareStatusReviewing, model.ReportStatusPending}
+	if status >= 0 {
+		query += " AND sc.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY sc.id DESC LIMIT ?"
+	args = append(args, pageSize)
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询举报内容失败: %v", err)
+	}
+	defer rows.Close()
+
+	var contents []model.ReportedContent
+	for rows.Next() {
+		var content model.ReportedContent
+		err := rows.Scan(
+			&content.ContentID,
+			&content.UserID,
+			&content.Status,
+			&content.ReportCount,
+			&content.CreatedAt,
+			&content.ImageURL,
+			&content.Prompt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("扫描举报内容失败: %v", err)
+		}
+		content.Reasons = make(map[int]int)
+		contents = append(contents, content)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询举报内容失败: %v", err)
+	}
+	rows.Close()
+
+	if err := r.fillReasons(q, contents); err != nil {
+		return nil, err
+	}
+
+	var nextCursor int64
+	if len(contents) == pageSize {
+		nextCursor = contents[len(contents)-1].ContentID
+	}
+
+	return &model.ReportedContentResponse{
+		Records:    contents,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// fillReasons 统计每条内容待处理举报的原因分布
+func (r *moderationRepo) fillReasons(q Querier, contents []model.ReportedContent) error {
+	if len(contents) == 0 {
+		return nil
+	}
+
+	index := make(map[int64]int, len(contents))
+	placeholders := make([]string, 0, len(contents))
+	args := []interface{}{model.ReportStatusPending}
+	for i, content := range contents {
+		index[content.ContentID] = i
+		placeholders = append(placeholders, "?")
+		args = append(args, content.ContentID)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT content_id, reason, COUNT(*)
+        FROM content_report
+        WHERE status = ? AND content_id IN (%s)
+        GROUP BY content_id, reason
+    `, strings.Join(placeholders, ","))
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("统计举报原因失败: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var contentID int64
+		var reason, count int
+		if err := rows.Scan(&contentID, &reason, &count); err != nil {
+			return fmt.Errorf("扫描举报原因失败: %v", err)
+		}
+		contents[index[contentID]].Reasons[reason] = count
+	}
+
+	return rows.Err()
+}
+
+func (r *moderationRepo) Moderate(q Querier, contentID int64, operator, action, note string) error {
+	var newStatus int
+	switch action {
+	case model.ModerationActionApprove:
+		newStatus = model.SquareStatusNormal
+	case model.ModerationActionRemove:
+		newStatus = model.SquareStatusRemoved
+	default:
+		return apperr.ErrInvalidParam.With("action")
+	}
+
+	// 锁定内容行，同时确认内容存在
+	var ownerID string
+	err := q.QueryRow("SELECT user_id FROM square_content WHERE id = ?"+r.d.ForUpdate(), contentID).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		return ErrContentNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("查询内容失败: %v", err)
+	}
+
+	// 更新内容状态，审核通过时清空举报数，避免下一次举报立即再次隐藏
+	query := "UPDATE square_content SET status = ? WHERE id = ?"
+	if action == model.ModerationActionApprove {
+		query = "UPDATE square_content SET status = ?, report_count = 0 WHERE id = ?"
+	}
+	if _, err := q.Exec(query, newStatus, contentID); err != nil {
+		return fmt.Errorf("更新内容状态失败: %v", err)
+	}
+
+	// 将待处理的举报标记为已处理
+	_, err = q.Exec("UPDATE content_report SET status = ? WHERE content_id = ? AND status = ?",
+		model.ReportStatusResolved, contentID, model.ReportStatusPending)
+	if err != nil {
+		return fmt.Errorf("更新举报状态失败: %v", err)
+	}
+
+	if err := r.Log(q, contentID, operator, action, note); err != nil {
+		return err
+	}
+
+	// 下架时通知内容作者
+	if action == model.ModerationActionRemove {
+		if err := r.notifications.Create(q, ownerID, model.NotificationTypeContentRemoved, contentID, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *moderationRepo) Log(q Querier, contentID int64, operator, action, note string) error {
+	_, err := q.Exec(`
+        INSERT INTO moderation_log (content_id, operator, action, note)
+        VALUES (?, ?, ?, ?)
+    `, contentID, operator, action, note)
+	if err != nil {
+		return fmt.Errorf("记录审核日志失败: %v", err)
+	}
+	return nil
+}
+
+func (r *moderationRepo) Logs(q Querier, contentID int64) ([]model.ModerationLog, error) {
+	rows, err := q.Query(`
+        SELECT id, content_id, operator, action, COALESCE(note, ''), created_at
+        FROM moderation_log
+        WHERE content_id = ?
+        ORDER BY id DESC
+    `, contentID)
+	if err != nil {
+		return nil, fmt.Errorf("查询审核日志失败: %v", err)
+	}
+	defer rows.Close()
+
+	var logs []model.ModerationLog
+	for rows.Next() {
+		var log model.ModerationLog
+		err := rows.Scan(&log.ID, &log.ContentID, &log.Operator, &log.Action, &log.Note, &log.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("扫描审核日志失败: %v", err)
+		}
+		logs = append(logs, log)
+	}
+
+	return logs, rows.Err()
+}
@@ -0,0 +1,24 @@
+package repo
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL MySQL 方言
+var MySQL Dialect = mysqlDialect{}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) ForUpdate() string {
+	return " FOR UPDATE"
+}
+
+// IsDuplicateKey MySQL 的唯一键冲突错误码为 1062，错误信息中包含冲突的键名
+func (mysqlDialect) IsDuplicateKey(err error, key string) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 &&
+		strings.Contains(mysqlErr.Message, key)
+}
@@ -0,0 +1,182 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+type notificationRepo struct {
+	d Dialect
+}
+
+func (r *notificationRepo) Create(q Querier, userID, notificationType string, contentID int64, actorID string) error {
+	// 不给自己发通知
+	if actorID != "" && actorID == userID {
+		return nil
+	}
+
+	var notificationID int64
+	err := q.QueryRow(`
+        SELECT id FROM notification
+        WHERE user_id = ? AND is_read = 0 AND type = ? AND content_id = ?
+        ORDER BY id DESC LIMIT 1`+r.d.ForUpdate(), userID, notificationType, contentID).Scan(&notificationID)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("查询未读通知失败: %v", err)
+	}
+
+	if err == sql.ErrNoRows {
+		actorCount := 0
+		if actorID != "" {
+			actorCount = 1
+		}
+		result, err := q.Exec(`
+            INSERT INTO notification (user_id, type, content_id, actor_count, last_actor_id)
+            VALUES (?, ?, ?, ?, ?)
+        `, userID, notificationType, contentID, actorCount, actorID)
+		if err != nil {
+			return fmt.Errorf("创建通知失败: %v", err)
+		}
+		if actorID == "" {
+			return nil
+		}
+
+		notificationID, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("获取通知ID失败: %v", err)
+		}
+		_, err = q.Exec("INSERT INTO notification_actor (notification_id, actor_id) VALUES (?, ?)",
+			notificationID, actorID)
+		if err != nil {
+			return fmt.Errorf("记录通知参与人失败: %v", err)
+		}
+		return nil
+	}
+
+	// 聚合到已有的未读通知
+	if actorID == "" {
+		_, err = q.Exec("UPDATE notification SET updated_at = CURRENT_TIMESTAMP WHERE id = ?", notificationID)
+		if err != nil {
+			return fmt.Errorf("更新通知失败: %v", err)
+		}
+		return nil
+	}
+
+	// 同一参与人重复操作只更新最近参与人，不重复计数
+	added := 1
+	_, err = q.Exec("INSERT INTO notification_actor (notification_id, actor_id) VALUES (?, ?)",
+		notificationID, actorID)
+	if r.d.IsDuplicateKey(err, "") {
+		added = 0
+	} else if err != nil {
+		return fmt.Errorf("记录通知参与人失败: %v", err)
+	}
+
+	_, err = q.Exec(`
+        UPDATE notification
+        SET actor_count = actor_count + ?, last_actor_id = ?, updated_at = CURRENT_TIMESTAMP
+        WHERE id = ?
+    `, added, actorID, notificationID)
+	if err != nil {
+		return fmt.Errorf("更新通知失败: %v", err)
+	}
+
+	return nil
+}
+
+func (r *notificationRepo) List(q Querier, userID string, cursor int64, pageSize int) (*model.NotificationListResponse, error) {
+	unreadCount, err := r.UnreadCount(q, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+        SELECT n.id, n.user_id, n.type, n.content_id, n.actor_count, n.last_actor_id,
+            ui.nickname, n.is_read, n.created_at, n.updated_at
+        FROM notification n
+        LEFT JOIN user_info ui ON n.last_actor_id = ui.user_id
+        WHERE n.user_id = ? AND n.id < ?
+        ORDER BY n.id DESC
+        LIMIT ?
+    `
+
+	// 如果是第一页，使用一个足够大的ID作为cursor
+	if cursor == 0 {
+		cursor = 9223372036854775807
+	}
+
+	rows, err := q.Query(query, userID, cursor, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("查询通知失败: %v", err)
+	}
+	defer rows.Close()
+
+	var notifications []model.Notification
+	for rows.Next() {
+		var n model.Notification
+		var lastActorName sql.NullString
+		err := rows.Scan(
+			&n.ID,
+			&n.UserID,
+			&n.Type,
+			&n.ContentID,
+			&n.ActorCount,
+			&n.LastActorID,
+			&lastActorName,
+			&n.IsRead,
+			&n.CreatedAt,
+			&n.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("扫描通知失败: %v", err)
+		}
+		n.LastActorName = lastActorName.String
+		if !lastActorName.Valid {
+			n.LastActorName = defaultNickname(n.LastActorID)
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询通知失败: %v", err)
+	}
+
+	var nextCursor int64
+	if len(notifications) == pageSize {
+		nextCursor = notifications[len(notifications)-1].ID
+	}
+
+	return &model.NotificationListResponse{
+		Records:     notifications,
+		NextCursor:  nextCursor,
+		UnreadCount: unreadCount,
+	}, nil
+}
+
+func (r *notificationRepo) UnreadCount(q Querier, userID string) (int, error) {
+	var count int
+	err := q.QueryRow("SELECT COUNT(*) FROM notification WHERE user_id = ? AND is_read = 0", userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("查询未读通知数失败: %v", err)
+	}
+	return count, nil
+}
+
+func (r *notificationRepo) MarkRead(q Querier, userID string, ids []int64) error {
+	query := "UPDATE notification SET is_read = 1 WHERE user_id = ? AND is_read = 0"
+	args := []interface{}{userID}
+	if len(ids) > 0 {
+		placeholders := make([]string, 0, len(ids))
+		for _, id := range ids {
+			placeholders = append(placeholders, "?")
+			args = append(args, id)
+		}
+		query += fmt.Sprintf(" AND id IN (%s)", strings.Join(placeholders, ","))
+	}
+
+	if _, err := q.Exec(query, args...); err != nil {
+		return fmt.Errorf("标记通知已读失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package repo_test
+
+import (
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo/sqlite"
+)
+
+func TestNotificationAggregation(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+
+	// 同一内容的未读点赞聚合为一条，重复点赞的用户只计一次，自己点赞不通知
+	for _, actorID := range []string{"u1", "u2", "u1", "author"} {
+		if err := repos.Notifications.Create(db, "author", model.NotificationTypeLike, 1, actorID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := repos.Notifications.List(db, "author", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.UnreadCount != 1 || len(list.Records) != 1 {
+		t.Fatalf("list = %+v, want 1 unread notification", list)
+	}
+	n := list.Records[0]
+	if n.ActorCount != 2 || n.LastActorID != "u1" || n.LastActorName == "" {
+		t.Errorf("notification = %+v, want 2 actors with u1 last", n)
+	}
+}
+
+func TestNotificationReadState(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+
+	// 不同内容和不同类型的通知各自独立，系统通知没有参与人
+	notify := func(userID, notificationType string, contentID int64, actorID string) {
+		t.Helper()
+		if err := repos.Notifications.Create(db, userID, notificationType, contentID, actorID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	notify("author", model.NotificationTypeLike, 1, "u1")
+	notify("author", model.NotificationTypeLike, 2, "u1")
+	notify("author", model.NotificationTypeGenerationDone, 3, "")
+	notify("author", model.NotificationTypeGenerationDone, 3, "")
+	notify("other", model.NotificationTypeLike, 4, "u1")
+
+	list, err := repos.Notifications.List(db, "author", 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.UnreadCount != 3 || len(list.Records) != 2 || list.NextCursor == 0 {
+		t.Fatalf("first page = %+v, want 2 of 3 unread notifications", list)
+	}
+	if n := list.Records[0]; n.Type != model.NotificationTypeGenerationDone || n.ActorCount != 0 {
+		t.Errorf("latest notification = %+v, want generation_done without actors", n)
+	}
+	next, err := repos.Notifications.List(db, "author", list.NextCursor, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next.Records) != 1 || next.NextCursor != 0 {
+		t.Fatalf("second page = %+v, want the last notification", next)
+	}
+
+	// 按ID标记只影响自己的通知，传入他人的通知ID不生效
+	var otherID int64
+	if err := db.QueryRow("SELECT id FROM notification WHERE user_id = 'other'").Scan(&otherID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Notifications.MarkRead(db, "author", []int64{list.Records[0].ID, otherID}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		userID string
+		want   int
+	}{
+		{"author", 2},
+		{"other", 1},
+	}
+	for _, tt := range tests {
+		count, err := repos.Notifications.UnreadCount(db, tt.userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != tt.want {
+			t.Errorf("%s: unread count = %d, want %d", tt.userID, count, tt.want)
+		}
+	}
+
+	// 不传ID时全部已读，之后的点赞生成新的通知，不再聚合到已读通知
+	if err := repos.Notifications.MarkRead(db, "author", nil); err != nil {
+		t.Fatal(err)
+	}
+	notify("author", model.NotificationTypeLike, 1, "u2")
+	list, err = repos.Notifications.List(db, "author", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.UnreadCount != 1 || len(list.Records) != 4 {
+		t.Fatalf("list = %+v, want 4 notifications with 1 unread", list)
+	}
+	if n := list.Records[0]; n.IsRead || n.ActorCount != 1 || n.LastActorID != "u2" {
+		t.Errorf("new notification = %+v, want unread with only u2", n)
+	}
+}
@@ -0,0 +1,360 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+type payOrderRepo struct {
+	d           Dialect
+	ledger      LedgerRepo
+	memberships MembershipRepo
+}
+
+// rowScanner 单行扫描接口，*sql.Row 和 *sql.Rows 均满足
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// payOrderColumns 查询支付订单的字段
+const payOrderColumns = `id, out_trade_no, user_id, product_id, description, coins, amount,
+    membership_tier, membership_days, status, prepay_id, transaction_id, paid_at, created_at, updated_at`
+
+// scanPayOrder 扫描支付订单
+func scanPayOrder(row rowScanner) (*model.PayOrder, error) {
+	var order model.PayOrder
+	var paidAt sql.NullTime
+	err := row.Scan(
+		&order.ID,
+		&order.OutTradeNo,
+		&order.UserID,
+		&order.ProductID,
+		&order.Description,
+		&order.Coins,
+		&order.Amount,
+		&order.MembershipTier,
+		&order.MembershipDays,
+		&order.Status,
+		&order.PrepayID,
+		&order.TransactionID,
+		&paidAt,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if paidAt.Valid {
+		order.PaidAt = &paidAt.Time
+	}
+	return &order, nil
+}
+
+func (r *payOrderRepo) Products(q Querier) ([]model.CoinProduct, error) {
+	rows, err := q.Query(`
+        SELECT id, name, coins, bonus_coins, price, membership_tier, membership_days
+        FROM coin_product
+        WHERE status = 1
+        ORDER BY sort, id
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %v", err)
+	}
+	defer rows.Close()
+
+	products := []model.CoinProduct{}
+	for rows.Next() {
+		var p model.CoinProduct
+		if err := rows.Scan(&p.ID, &p.Name, &p.Coins, &p.BonusCoins, &p.Price, &p.MembershipTier, &p.MembershipDays); err != nil {
+			return nil, fmt.Errorf("扫描商品失败: %v", err)
+		}
+		products = append(products, p)
+	}
+
+	return products, rows.Err()
+}
+
+func (r *payOrderRepo) Product(q Querier, productID int64) (*model.CoinProduct, error) {
+	var p model.CoinProduct
+	err := q.QueryRow(`
+        SELECT id, name, coins, bonus_coins, price, membership_tier, membership_days
+        FROM coin_product
+        WHERE id = ? AND status = 1
+    `, productID).Scan(&p.ID, &p.Name, &p.Coins, &p.BonusCoins, &p.Price, &p.MembershipTier, &p.MembershipDays)
+	if err == sql.ErrNoRows {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %v", err)
+	}
+	return &p, nil
+}
+
+func (r *payOrderRepo) Create(q Querier, order *model.PayOrder) error {
+	result, err := q.Exec(`
+        INSERT INTO pay_order (out_trade_no, user_id, product_id, description, coins, amount,
+            membership_tier, membership_days, status)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, order.OutTradeNo, order.UserID, order.ProductID, order.Description, order.Coins, order.Amount,
+		order.MembershipTier, order.MembershipDays, model.PayOrderStatusPending)
+	if err != nil {
+		return fmt.Errorf("创建订单失败: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取订单ID失败: %v", err)
+	}
+
+	order.ID = id
+	order.Status = model.PayOrderStatusPending
+	return nil
+}
+
+func (r *payOrderRepo) SetPrepayID(q Querier, outTradeNo, prepayID string) error {
+	_, err := q.Exec("UPDATE pay_order SET prepay_id = ? WHERE out_trade_no = ?", prepayID, outTradeNo)
+	if err != nil {
+		return fmt.Errorf("更新订单失败: %v", err)
+	}
+	return nil
+}
+
+func (r *payOrderRepo) Close(q Querier, outTradeNo string) error {
+	_, err := q.Exec("UPDATE pay_order SET status = ? WHERE out_trade_no = ? AND status = ?",
+		model.PayOrderStatusClosed, outTradeNo, model.PayOrderStatusPending)
+	if err != nil {
+		return fmt.Errorf("关闭订单失败: %v", err)
+	}
+	return nil
+}
+
+func (r *payOrderRepo) Get(q Querier, outTradeNo string) (*model.PayOrder, error) {
+	order, err := scanPayOrder(q.QueryRow("SELECT "+payOrderColumns+" FROM pay_order WHERE out_trade_no = ?", outTradeNo))
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %v", err)
+	}
+	return order, nil
+}
+
+func (r *payOrderRepo) List(q Querier, userID string, cursor int64, pageSize int) (*model.PayOrderListResponse, error) {
+	// 如果是第一页，使用一个足够大的ID作为cursor
+	if cursor == 0 {
+		cursor = 9223372036854775807
+	}
+
+	rows, err := q.Query(`
+        SELECT `+payOrderColumns+`
+        FROM pay_order
+        WHERE user_id = ? AND id < ?
+        ORDER BY id DESC
+        LIMIT ?
+    `, userID, cursor, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %v", err)
+	}
+	defer rows.Close()
+
+	orders := []model.PayOrder{}
+	for rows.Next() {
+		order, err := scanPayOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描订单失败: %v", err)
+		}
+		orders = append(orders, *order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询订单失败: %v", err)
+	}
+
+	var nextCursor int64
+	if len(orders) == pageSize {
+		nextCursor = orders[len(orders)-1].ID
+	}
+
+	return &model.PayOrderListResponse{
+		Records:    orders,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+func (r *payOrderRepo) MarkPaid(q Querier, outTradeNo, transactionID string, amount int, paidAt time.Time) (bool, error) {
+	var order model.PayOrder
+	err := q.QueryRow(`
+        SELECT id, out_trade_no, user_id, coins, amount, membership_tier, membership_days, status
+        FROM pay_order WHERE out_trade_no = ?`+r.d.ForUpdate(), outTradeNo).Scan(
+		&order.ID, &order.OutTradeNo, &order.UserID, &order.Coins, &order.Amount,
+		&order.MembershipTier, &order.MembershipDays, &order.Status,
+	)
+	if err == sql.ErrNoRows {
+		return false, ErrOrderNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("查询订单失败: %v", err)
+	}
+
+	// 已支付、退款中或已退款的订单说明已经处理过该通知
+	if order.Status != model.PayOrderStatusPending && order.Status != model.PayOrderStatusClosed {
+		return false, nil
+	}
+	if order.Amount != amount {
+		return false, ErrOrderAmountMismatch
+	}
+
+	_, err = q.Exec(`
+        UPDATE pay_order SET status = ?, transaction_id = ?, paid_at = ?
+        WHERE id = ?
+    `, model.PayOrderStatusPaid, transactionID, paidAt, order.ID)
+	if err != nil {
+		return false, fmt.Errorf("更新订单状态失败: %v", err)
+	}
+
+	if order.Coins > 0 {
+		if _, err := r.ledger.Change(q, order.UserID, order.Coins, model.CoinSourcePurchase, order.OutTradeNo, nil); err != nil {
+			return false, err
+		}
+	}
+	if order.MembershipDays > 0 {
+		// 下单时已拦截降级，下单后用户又升级了会员时保留当前等级，由退款处理该订单
+		_, err := r.memberships.Extend(q, order.UserID, order.MembershipTier, order.MembershipDays, paidAt)
+		if err != nil && !errors.Is(err, ErrMembershipDowngrade) {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (r *payOrderRepo) CreateRefund(q Querier, refund *model.PayRefund, now time.Time) error {
+	order, err := scanPayOrder(q.QueryRow("SELECT "+payOrderColumns+" FROM pay_order WHERE out_trade_no = ?"+r.d.ForUpdate(), refund.OutTradeNo))
+	if err == sql.ErrNoRows {
+		return ErrOrderNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("查询订单失败: %v", err)
+	}
+	if order.Status != model.PayOrderStatusPaid {
+		return ErrOrderNotRefundable
+	}
+
+	refund.UserID = order.UserID
+	refund.Amount = order.Amount
+	refund.Coins = order.Coins
+	refund.Status = model.PayRefundStatusProcessing
+
+	if order.Coins > 0 {
+		_, err = r.ledger.Reclaim(q, order.UserID, model.CoinSourcePurchase, order.OutTradeNo,
+			order.Coins, model.CoinSourceRefund, refund.OutRefundNo)
+		if errors.Is(err, ErrInsufficientCoin) {
+			return ErrRefundCoinsSpent
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if order.MembershipDays > 0 {
+		if _, err := r.memberships.Extend(q, order.UserID, order.MembershipTier, -order.MembershipDays, now); err != nil {
+			return err
+		}
+	}
+
+	result, err := q.Exec(`
+        INSERT INTO pay_refund (out_refund_no, out_trade_no, user_id, amount, coins, status, reason)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `, refund.OutRefundNo, refund.OutTradeNo, refund.UserID, refund.Amount, refund.Coins, refund.Status, refund.Reason)
+	if err != nil {
+		return fmt.Errorf("创建退款单失败: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取退款单ID失败: %v", err)
+	}
+	refund.ID = id
+
+	_, err = q.Exec("UPDATE pay_order SET status = ? WHERE id = ?", model.PayOrderStatusRefunding, order.ID)
+	if err != nil {
+		return fmt.Errorf("更新订单状态失败: %v", err)
+	}
+	return nil
+}
+
+// lockRefund 锁定处理中的退款单，退款单已处理完成时返回 nil
+func (r *payOrderRepo) lockRefund(q Querier, outRefundNo string) (*model.PayRefund, error) {
+	var refund model.PayRefund
+	err := q.QueryRow(`
+        SELECT id, out_refund_no, out_trade_no, user_id, amount, coins, status
+        FROM pay_refund WHERE out_refund_no = ?`+r.d.ForUpdate(), outRefundNo).Scan(
+		&refund.ID, &refund.OutRefundNo, &refund.OutTradeNo, &refund.UserID,
+		&refund.Amount, &refund.Coins, &refund.Status)
+	if err == sql.ErrNoRows {
+		return nil, ErrRefundNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询退款单失败: %v", err)
+	}
+	if refund.Status != model.PayRefundStatusProcessing {
+		return nil, nil
+	}
+	return &refund, nil
+}
+
+func (r *payOrderRepo) CompleteRefund(q Querier, outRefundNo, refundID string) error {
+	refund, err := r.lockRefund(q, outRefundNo)
+	if err != nil || refund == nil {
+		return err
+	}
+
+	_, err = q.Exec("UPDATE pay_refund SET status = ?, refund_id = ? WHERE id = ?",
+		model.PayRefundStatusSuccess, refundID, refund.ID)
+	if err != nil {
+		return fmt.Errorf("更新退款单状态失败: %v", err)
+	}
+	_, err = q.Exec("UPDATE pay_order SET status = ? WHERE out_trade_no = ?", model.PayOrderStatusRefunded, refund.OutTradeNo)
+	if err != nil {
+		return fmt.Errorf("更新订单状态失败: %v", err)
+	}
+	return nil
+}
+
+func (r *payOrderRepo) FailRefund(q Querier, outRefundNo string, now time.Time) error {
+	refund, err := r.lockRefund(q, outRefundNo)
+	if err != nil || refund == nil {
+		return err
+	}
+
+	_, err = q.Exec("UPDATE pay_refund SET status = ? WHERE id = ?", model.PayRefundStatusFailed, refund.ID)
+	if err != nil {
+		return fmt.Errorf("更新退款单状态失败: %v", err)
+	}
+	_, err = q.Exec("UPDATE pay_order SET status = ? WHERE out_trade_no = ?", model.PayOrderStatusPaid, refund.OutTradeNo)
+	if err != nil {
+		return fmt.Errorf("更新订单状态失败: %v", err)
+	}
+	if refund.Coins > 0 {
+		if _, err := r.ledger.Change(q, refund.UserID, refund.Coins, model.CoinSourceRefundRevert, refund.OutRefundNo, nil); err != nil {
+			return err
+		}
+	}
+
+	var tier string
+	var days int
+	err = q.QueryRow("SELECT membership_tier, membership_days FROM pay_order WHERE out_trade_no = ?",
+		refund.OutTradeNo).Scan(&tier, &days)
+	if err != nil {
+		return fmt.Errorf("查询订单失败: %v", err)
+	}
+	if days > 0 {
+		// 退款期间用户升级了会员时保留当前等级
+		_, err := r.memberships.Extend(q, refund.UserID, tier, days, now)
+		if err != nil && !errors.Is(err, ErrMembershipDowngrade) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package repo_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo/sqlite"
+)
+
+// createPayOrder 创建支付订单
+func createPayOrder(t *testing.T, db *sql.DB, outTradeNo string, coins, amount, membershipDays, status int) {
+	t.Helper()
+	_, err := db.Exec(`
+        INSERT INTO pay_order (out_trade_no, user_id, product_id, description, coins, amount,
+            membership_tier, membership_days, status)
+        VALUES (?, 'u1', 1, '测试商品', ?, ?, 'vip', ?, ?)
+    `, outTradeNo, coins, amount, membershipDays, status)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMarkPaid(t *testing.T) {
+	paidAt := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		status       int
+		amount       int
+		wantErr      error
+		wantCredited []bool // 依次处理两次相同通知的结果
+		wantBalance  int
+		wantStatus   int
+	}{
+		{"pending", model.PayOrderStatusPending, 600, nil, []bool{true, false}, 100, model.PayOrderStatusPaid},
+		// 订单超时关闭后才收到支付成功通知，仍然发放
+		{"closed", model.PayOrderStatusClosed, 600, nil, []bool{true, false}, 100, model.PayOrderStatusPaid},
+		{"refunded", model.PayOrderStatusRefunded, 600, nil, []bool{false, false}, 0, model.PayOrderStatusRefunded},
+		{"amount mismatch", model.PayOrderStatusPending, 1, repo.ErrOrderAmountMismatch, nil, 0, model.PayOrderStatusPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sqlite.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			repos := sqlite.New()
+
+			if err := repos.Users.Create(db, &model.UserInfo{UserID: "u1", InviteCode: "CODE1"}); err != nil {
+				t.Fatal(err)
+			}
+			createPayOrder(t, db, "PAY1", 100, 600, 30, tt.status)
+
+			for i := 0; i < 2; i++ {
+				var credited bool
+				err := repo.WithTx(db, func(tx repo.Querier) error {
+					var err error
+					credited, err = repos.PayOrders.MarkPaid(tx, "PAY1", "4200000001", tt.amount, paidAt)
+					return err
+				})
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("MarkPaid() error = %v, want %v", err, tt.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if credited != tt.wantCredited[i] {
+					t.Errorf("notification %d credited = %v, want %v", i, credited, tt.wantCredited[i])
+				}
+			}
+
+			balance, err := repos.Ledger.Balance(db, "u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if balance != tt.wantBalance {
+				t.Errorf("balance = %d, want %d", balance, tt.wantBalance)
+			}
+
+			var status int
+			if err := db.QueryRow("SELECT status FROM pay_order WHERE out_trade_no = 'PAY1'").Scan(&status); err != nil {
+				t.Fatal(err)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+
+			// 会员时长只在发放时延长一次
+			var expiresAt time.Time
+			err = db.QueryRow("SELECT expires_at FROM user_membership WHERE user_id = 'u1'").Scan(&expiresAt)
+			switch {
+			case tt.wantBalance == 0 && err != sql.ErrNoRows:
+				t.Errorf("membership = %v, %v, want none", expiresAt, err)
+			case tt.wantBalance > 0 && (err != nil || !expiresAt.Equal(paidAt.AddDate(0, 0, 30))):
+				t.Errorf("membership expires at %v, %v, want %v", expiresAt, err, paidAt.AddDate(0, 0, 30))
+			}
+		})
+	}
+}
+
+func TestMarkPaidOrderNotFound(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = sqlite.New().PayOrders.MarkPaid(db, "NOPE", "4200000001", 600, time.Now())
+	if !errors.Is(err, repo.ErrOrderNotFound) {
+		t.Fatalf("MarkPaid() error = %v, want %v", err, repo.ErrOrderNotFound)
+	}
+}
+
+func TestMarkPaidKeepsHigherTier(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+	paidAt := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	// 下单购买 vip 后又升级为 svip，再支付 vip 订单时仍发放coin，会员保持 svip
+	createUser(t, db, repos, "u1")
+	createPayOrder(t, db, "PAY1", 100, 600, 30, model.PayOrderStatusPending)
+	if _, err := repos.Memberships.Extend(db, "u1", "svip", 10, paidAt); err != nil {
+		t.Fatal(err)
+	}
+	credited, err := repos.PayOrders.MarkPaid(db, "PAY1", "4200000001", 600, paidAt)
+	if err != nil || !credited {
+		t.Fatalf("MarkPaid() = %v, %v, want credited", credited, err)
+	}
+
+	m, err := repos.Memberships.Active(db, "u1", paidAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Tier != "svip" || !m.ExpiresAt.Equal(paidAt.AddDate(0, 0, 10)) {
+		t.Errorf("membership = %s until %v, want svip until %v", m.Tier, m.ExpiresAt, paidAt.AddDate(0, 0, 10))
+	}
+}
+
+func TestExtendMembership(t *testing.T) {
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		currentTier string // 为空表示非会员
+		currentDays int    // 当前会员剩余天数，负数表示已过期
+		tier        string
+		days        int
+		wantErr     error
+		wantTier    string
+		wantExpire  time.Time
+	}{
+		{"open", "", 0, "vip", 30, nil, "vip", now.AddDate(0, 0, 30)},
+		{"renew same tier", "vip", 25, "vip", 30, nil, "vip", now.AddDate(0, 0, 55)},
+		{"renew expired", "vip", -5, "vip", 30, nil, "vip", now.AddDate(0, 0, 30)},
+		// 升级立即生效，新等级从现在开始计算，不叠加原等级的剩余时长
+		{"upgrade", "vip", 25, "svip", 30, nil, "svip", now.AddDate(0, 0, 30)},
+		{"downgrade", "svip", 25, "vip", 30, repo.ErrMembershipDowngrade, "svip", now.AddDate(0, 0, 25)},
+		{"downgrade after expiry", "svip", -5, "vip", 30, nil, "vip", now.AddDate(0, 0, 30)},
+		{"shorten", "vip", 60, "vip", -30, nil, "vip", now.AddDate(0, 0, 30)},
+		// 退款缩短其他等级的会员时不生效
+		{"shorten other tier", "svip", 60, "vip", -30, nil, "svip", now.AddDate(0, 0, 60)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sqlite.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			memberships := sqlite.New().Memberships
+
+			if tt.currentTier != "" {
+				_, err := db.Exec("INSERT INTO user_membership (user_id, tier, expires_at) VALUES ('u1', ?, ?)",
+					tt.currentTier, now.AddDate(0, 0, tt.currentDays))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err = memberships.Extend(db, "u1", tt.tier, tt.days, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Extend() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var tier string
+			var expiresAt time.Time
+			err = db.QueryRow("SELECT tier, expires_at FROM user_membership WHERE user_id = 'u1'").Scan(&tier, &expiresAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tier != tt.wantTier || !expiresAt.Equal(tt.wantExpire) {
+				t.Errorf("membership = %s until %v, want %s until %v", tier, expiresAt, tt.wantTier, tt.wantExpire)
+			}
+		})
+	}
+}
+
+func TestRefund(t *testing.T) {
+	tests := []struct {
+		name        string
+		fail        bool
+		wantBalance int
+		wantStatus  int
+	}{
+		{"success", false, 0, model.PayOrderStatusRefunded},
+		// 退款失败时退回扣除的coin，订单恢复为已支付
+		{"failed", true, 100, model.PayOrderStatusPaid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sqlite.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			repos := sqlite.New()
+			now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+			createUser(t, db, repos, "u1")
+			createPayOrder(t, db, "PAY1", 100, 600, 0, model.PayOrderStatusPending)
+			if _, err := repos.PayOrders.MarkPaid(db, "PAY1", "4200000001", 600, now); err != nil {
+				t.Fatal(err)
+			}
+
+			refund := &model.PayRefund{OutRefundNo: "RF1", OutTradeNo: "PAY1"}
+			if err := repos.PayOrders.CreateRefund(db, refund, now); err != nil {
+				t.Fatal(err)
+			}
+			if err := repos.PayOrders.CreateRefund(db, &model.PayRefund{OutRefundNo: "RF2", OutTradeNo: "PAY1"}, now); !errors.Is(err, repo.ErrOrderNotRefundable) {
+				t.Errorf("second CreateRefund() error = %v, want ErrOrderNotRefundable", err)
+			}
+
+			// 重复通知只处理一次
+			for i := 0; i < 2; i++ {
+				if tt.fail {
+					err = repos.PayOrders.FailRefund(db, "RF1", now)
+				} else {
+					err = repos.PayOrders.CompleteRefund(db, "RF1", "5000000001")
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			balance, err := repos.Ledger.Balance(db, "u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			order, err := repos.PayOrders.Get(db, "PAY1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if balance != tt.wantBalance || order.Status != tt.wantStatus {
+				t.Errorf("balance = %d, status = %d, want %d, %d", balance, order.Status, tt.wantBalance, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRefundAfterSpendingSignupCoins(t *testing.T) {
+	tests := []struct {
+		name        string
+		spend       int
+		wantErr     error
+		wantBalance int
+	}{
+		// 注册赠送的coin没有批次，先于充值批次消耗，只花了赠送的coin时仍可退款
+		{"signup coins spent", 20, nil, 40},
+		// 消费超出赠送的coin后动用了充值批次，不能退款
+		{"purchase lot spent", 70, repo.ErrRefundCoinsSpent, 90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := sqlite.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			repos := sqlite.New()
+			now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+			if err := repos.Users.Create(db, &model.UserInfo{UserID: "u1", Coin: 60, InviteCode: "CODE-u1"}); err != nil {
+				t.Fatal(err)
+			}
+			createPayOrder(t, db, "PAY1", 100, 600, 0, model.PayOrderStatusPending)
+			if _, err := repos.PayOrders.MarkPaid(db, "PAY1", "4200000001", 600, now); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repos.Ledger.Change(db, "u1", -tt.spend, model.CoinSourceGeneration, "", nil); err != nil {
+				t.Fatal(err)
+			}
+
+			err = repos.PayOrders.CreateRefund(db, &model.PayRefund{OutRefundNo: "RF1", OutTradeNo: "PAY1"}, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateRefund() error = %v, want %v", err, tt.wantErr)
+			}
+			balance, err := repos.Ledger.Balance(db, "u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if balance != tt.wantBalance {
+				t.Errorf("balance = %d, want %d", balance, tt.wantBalance)
+			}
+		})
+	}
+}
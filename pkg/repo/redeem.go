@@ -0,0 +1,225 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/invitecode"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+// redeemCodeLength 批量生成的兑换码长度
+const redeemCodeLength = 10
+
+// maxRedeemCodesPerBatch 每批最多生成的兑换码数量
+const maxRedeemCodesPerBatch = 1000
+
+type redeemRepo struct {
+	d           Dialect
+	ledger      LedgerRepo
+	memberships MembershipRepo
+}
+
+func (r *redeemRepo) CreateCampaign(q Querier, campaign *model.RedeemCampaign) error {
+	result, err := q.Exec(`
+        INSERT INTO redeem_campaign (name, coins, membership_tier, membership_days, per_user_limit, starts_at, ends_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `, campaign.Name, campaign.Coins, campaign.MembershipTier, campaign.MembershipDays, campaign.PerUserLimit,
+		campaign.StartsAt, campaign.EndsAt)
+	if err != nil {
+		return fmt.Errorf("创建兑换活动失败: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取兑换活动ID失败: %v", err)
+	}
+
+	campaign.ID = id
+	return nil
+}
+
+func (r *redeemRepo) CreateCodes(q Querier, campaignID int64, code string, count, maxUses int) ([]model.RedeemCode, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM redeem_campaign WHERE id = ?)", campaignID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("检查兑换活动失败: %v", err)
+	}
+	if !exists {
+		return nil, ErrCampaignNotFound
+	}
+
+	if code != "" {
+		count = 1
+	}
+	if count <= 0 || count > maxRedeemCodesPerBatch {
+		return nil, apperr.ErrRedeemBatchSize.With(maxRedeemCodesPerBatch)
+	}
+
+	codes := make([]model.RedeemCode, 0, count)
+	for len(codes) < count {
+		value := code
+		if value == "" {
+			value, err = invitecode.GenerateN(redeemCodeLength)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result, err := q.Exec("INSERT INTO redeem_code (campaign_id, code, max_uses) VALUES (?, ?, ?)",
+			campaignID, value, maxUses)
+		if r.d.IsDuplicateKey(err, "") {
+			if code != "" {
+				return nil, ErrRedeemCodeExists
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("保存兑换码失败: %v", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("获取兑换码ID失败: %v", err)
+		}
+		codes = append(codes, model.RedeemCode{
+			ID:         id,
+			CampaignID: campaignID,
+			Code:       value,
+			MaxUses:    maxUses,
+		})
+	}
+
+	return codes, nil
+}
+
+func (r *redeemRepo) ListCodes(q Querier, campaignID int64, cursor int64, pageSize int) (*model.RedeemCodeListResponse, error) {
+	// 如果是第一页，使用一个足够大的ID作为cursor
+	if cursor == 0 {
+		cursor = 9223372036854775807
+	}
+
+	rows, err := q.Query(`
+        SELECT id, campaign_id, code, max_uses, used_count, created_at
+        FROM redeem_code
+        WHERE campaign_id = ? AND id < ?
+        ORDER BY id DESC
+        LIMIT ?
+    `, campaignID, cursor, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("查询兑换码失败: %v", err)
+	}
+	defer rows.Close()
+
+	codes := []model.RedeemCode{}
+	for rows.Next() {
+		var code model.RedeemCode
+		if err := rows.Scan(&code.ID, &code.CampaignID, &code.Code, &code.MaxUses, &code.UsedCount, &code.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描兑换码失败: %v", err)
+		}
+		codes = append(codes, code)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询兑换码失败: %v", err)
+	}
+
+	var nextCursor int64
+	if len(codes) == pageSize {
+		nextCursor = codes[len(codes)-1].ID
+	}
+
+	return &model.RedeemCodeListResponse{
+		Records:    codes,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+func (r *redeemRepo) Redeem(q Querier, userID, code string, now time.Time) (*model.RedeemResult, error) {
+	// 先按兑换码找到活动，再依次锁定活动和兑换码；所有兑换都按这个顺序加锁，
+	// 同一用户用同一活动的不同兑换码并发兑换时在活动行上排队，次数统计不会超限
+	var campaignID int64
+	err := q.QueryRow("SELECT campaign_id FROM redeem_code WHERE code = ?", code).Scan(&campaignID)
+	if err == sql.ErrNoRows {
+		return nil, ErrRedeemCodeInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询兑换码失败: %v", err)
+	}
+
+	var campaign model.RedeemCampaign
+	err = q.QueryRow(`
+        SELECT id, name, coins, membership_tier, membership_days, per_user_limit, starts_at, ends_at
+        FROM redeem_campaign
+        WHERE id = ?`+r.d.ForUpdate(), campaignID).Scan(
+		&campaign.ID, &campaign.Name, &campaign.Coins, &campaign.MembershipTier, &campaign.MembershipDays,
+		&campaign.PerUserLimit, &campaign.StartsAt, &campaign.EndsAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrRedeemCodeInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("锁定兑换活动失败: %v", err)
+	}
+
+	var redeemCode model.RedeemCode
+	err = q.QueryRow("SELECT id, max_uses, used_count FROM redeem_code WHERE code = ?"+r.d.ForUpdate(), code).Scan(
+		&redeemCode.ID, &redeemCode.MaxUses, &redeemCode.UsedCount)
+	if err != nil {
+		return nil, fmt.Errorf("查询兑换码失败: %v", err)
+	}
+
+	if now.Before(campaign.StartsAt) {
+		return nil, ErrRedeemNotStarted
+	}
+	if !now.Before(campaign.EndsAt) {
+		return nil, ErrRedeemExpired
+	}
+	if redeemCode.MaxUses > 0 && redeemCode.UsedCount >= redeemCode.MaxUses {
+		return nil, ErrRedeemCodeExhausted
+	}
+
+	if campaign.PerUserLimit > 0 {
+		var redeemed int
+		err = q.QueryRow("SELECT COUNT(*) FROM redeem_log WHERE campaign_id = ? AND user_id = ?",
+			campaign.ID, userID).Scan(&redeemed)
+		if err != nil {
+			return nil, fmt.Errorf("查询兑换记录失败: %v", err)
+		}
+		if redeemed >= campaign.PerUserLimit {
+			return nil, ErrRedeemUserLimit
+		}
+	}
+
+	_, err = q.Exec("INSERT INTO redeem_log (code_id, campaign_id, user_id) VALUES (?, ?, ?)",
+		redeemCode.ID, campaign.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("记录兑换失败: %v", err)
+	}
+	_, err = q.Exec("UPDATE redeem_code SET used_count = used_count + 1 WHERE id = ?", redeemCode.ID)
+	if err != nil {
+		return nil, fmt.Errorf("更新兑换码失败: %v", err)
+	}
+
+	result := &model.RedeemResult{
+		CampaignName:   campaign.Name,
+		Coins:          campaign.Coins,
+		MembershipTier: campaign.MembershipTier,
+		MembershipDays: campaign.MembershipDays,
+	}
+	if campaign.Coins > 0 {
+		if _, err := r.ledger.Change(q, userID, campaign.Coins, model.CoinSourceRedeem, code, nil); err != nil {
+			return nil, err
+		}
+	}
+	if campaign.MembershipDays > 0 {
+		membership, err := r.memberships.Extend(q, userID, campaign.MembershipTier, campaign.MembershipDays, now)
+		if err != nil {
+			return nil, err
+		}
+		result.MembershipExpiresAt = &membership.ExpiresAt
+	}
+
+	return result, nil
+}
@@ -0,0 +1,137 @@
+package repo_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo/sqlite"
+)
+
+func TestRedeem(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	createUser(t, db, repos, "u1")
+	campaign := &model.RedeemCampaign{
+		Name:           "国庆活动",
+		Coins:          30,
+		MembershipTier: "vip",
+		MembershipDays: 7,
+		PerUserLimit:   1,
+		StartsAt:       now.Add(-time.Hour),
+		EndsAt:         now.Add(time.Hour),
+	}
+	if err := repos.Redeems.CreateCampaign(db, campaign); err != nil {
+		t.Fatal(err)
+	}
+	codes, err := repos.Redeems.CreateCodes(db, campaign.ID, "HELLO2026", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Redeems.CreateCodes(db, campaign.ID, "HELLO2026", 0, 0); !errors.Is(err, repo.ErrRedeemCodeExists) {
+		t.Errorf("CreateCodes() error = %v, want ErrRedeemCodeExists", err)
+	}
+
+	result, err := repos.Redeems.Redeem(db, "u1", codes[0].Code, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Coins != 30 || result.MembershipExpiresAt == nil || !result.MembershipExpiresAt.Equal(now.AddDate(0, 0, 7)) {
+		t.Errorf("result = %+v, want 30 coins and 7 days of vip", result)
+	}
+	balance, err := repos.Ledger.Balance(db, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance != 30 {
+		t.Errorf("balance = %d, want 30", balance)
+	}
+
+	list, err := repos.Redeems.ListCodes(db, campaign.ID, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Records) != 1 || list.Records[0].UsedCount != 1 {
+		t.Errorf("codes = %+v, want 1 code used once", list.Records)
+	}
+}
+
+func TestRedeemRules(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+	now := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	createUser(t, db, repos, "u1")
+	createUser(t, db, repos, "u2")
+	campaigns := map[string]*model.RedeemCampaign{
+		"upcoming": {Name: "双十一", Coins: 10, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)},
+		"ended":    {Name: "国庆", Coins: 10, StartsAt: now.Add(-2 * time.Hour), EndsAt: now},
+		"active":   {Name: "周年庆", Coins: 10, PerUserLimit: 2, StartsAt: now.Add(-time.Hour), EndsAt: now.Add(time.Hour)},
+	}
+	for _, campaign := range campaigns {
+		if err := repos.Redeems.CreateCampaign(db, campaign); err != nil {
+			t.Fatal(err)
+		}
+	}
+	codes := []struct {
+		campaign string
+		code     string
+		maxUses  int
+	}{
+		{"upcoming", "SOON2026", 0},
+		{"ended", "OVER2026", 0},
+		{"active", "ONCE2026", 1},
+		{"active", "MANY2026", 0},
+		{"active", "MORE2026", 0},
+	}
+	for _, c := range codes {
+		if _, err := repos.Redeems.CreateCodes(db, campaigns[c.campaign].ID, c.code, 0, c.maxUses); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		code    string
+		wantErr error
+	}{
+		{"unknown code", "u1", "NOPE2026", repo.ErrRedeemCodeInvalid},
+		{"not started", "u1", "SOON2026", repo.ErrRedeemNotStarted},
+		{"ended", "u1", "OVER2026", repo.ErrRedeemExpired},
+		{"single use", "u1", "ONCE2026", nil},
+		{"exhausted", "u2", "ONCE2026", repo.ErrRedeemCodeExhausted},
+		{"second redeem", "u1", "MANY2026", nil},
+		// 次数限制按活动统计，换同一活动的其他兑换码也不能超出
+		{"per user limit", "u1", "MORE2026", repo.ErrRedeemUserLimit},
+		{"other user", "u2", "MORE2026", nil},
+	}
+	for _, tt := range tests {
+		_, err := repos.Redeems.Redeem(db, tt.userID, tt.code, now)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: Redeem() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	for userID, want := range map[string]int{"u1": 20, "u2": 10} {
+		balance, err := repos.Ledger.Balance(db, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balance != want {
+			t.Errorf("%s balance = %d, want %d", userID, balance, want)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+// Package repo 全部业务数据的访问接口
+// 接口方法接收 Querier，既可以传入 *sql.DB 也可以传入 *sql.Tx，由调用方决定是否在事务中执行；
+// 同一套实现通过 Dialect 适配 MySQL 和 SQLite，测试时可以使用内存中的 SQLite。
+// 实现不使用 upsert、INSERT IGNORE 等各数据库语法不同的语句，需要时先加锁查询再分别插入或更新
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/signin"
+)
+
+// 数据访问错误
+var (
+	ErrUserNotFound        = apperr.ErrUserNotFound
+	ErrInviteCodeTaken     = apperr.ErrInviteCodeTaken
+	ErrNicknameNotPending  = apperr.ErrNicknameNotPending
+	ErrInsufficientCoin    = apperr.ErrInsufficientCoin
+	ErrRecordNotFound      = apperr.ErrRecordNotFound
+	ErrRecordNotOwned      = apperr.ErrRecordNotOwned
+	ErrRecordAlreadyShared = apperr.ErrRecordAlreadyShared
+	ErrOrderNotFound       = apperr.ErrOrderNotFound
+	ErrOrderAmountMismatch = apperr.ErrAmountMismatch
+	ErrOrderNotRefundable  = apperr.ErrOrderNotRefundable
+	ErrRefundCoinsSpent    = apperr.ErrRefundCoinsSpent
+	ErrMembershipDowngrade = apperr.ErrMembershipDowngrade
+	ErrRefundNotFound      = apperr.ErrRefundNotFound
+	ErrProductNotFound     = apperr.ErrProductNotFound
+	ErrContentNotFound     = apperr.ErrContentNotFound
+	ErrContentRemoved      = apperr.ErrContentRemoved
+	ErrAlreadyReported     = apperr.ErrAlreadyReported
+	ErrCollectionNotFound  = apperr.ErrCollectionNotFound
+	ErrCollectionExists    = apperr.ErrCollectionExists
+	ErrFavoriteNotFound    = apperr.ErrFavoriteNotFound
+	ErrAlreadySignedIn     = apperr.ErrAlreadySignedIn
+	ErrDateAlreadySignedIn = apperr.ErrDateAlreadySignedIn
+	ErrNoRepairCard        = apperr.ErrNoRepairCard
+	ErrRepairOutOfWindow   = apperr.ErrRepairOutOfWindow
+	ErrInviteCodeUsed      = apperr.ErrInviteCodeUsed
+	ErrInviteCodeOwn       = apperr.ErrInviteCodeOwn
+	ErrInviteCodeInvalid   = apperr.ErrInviteCodeInvalid
+	ErrInviteCycle         = apperr.ErrInviteCycle
+	ErrInviteNotNewUser    = apperr.ErrInviteNotNewUser
+	ErrRedeemCodeInvalid   = apperr.ErrRedeemCodeInvalid
+	ErrRedeemNotStarted    = apperr.ErrRedeemNotStarted
+	ErrRedeemExpired       = apperr.ErrRedeemExpired
+	ErrRedeemCodeExhausted = apperr.ErrRedeemCodeExhausted
+	ErrRedeemUserLimit     = apperr.ErrRedeemUserLimit
+	ErrCampaignNotFound    = apperr.ErrCampaignNotFound
+	ErrRedeemCodeExists    = apperr.ErrRedeemCodeExists
+)
+
+// Querier 执行SQL的接口，*sql.DB 和 *sql.Tx 均满足
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Dialect 屏蔽不同数据库之间的SQL差异
+type Dialect interface {
+	// ForUpdate 返回加在查询末尾的行锁子句，不支持行锁的数据库返回空字符串
+	ForUpdate() string
+	// IsDuplicateKey 判断是否为唯一键冲突，key 不为空时只匹配该唯一键或列
+	IsDuplicateKey(err error, key string) bool
+}
+
+// UserRepo 用户数据
+type UserRepo interface {
+	// Get 获取用户信息，用户不存在时返回 nil
+	Get(q Querier, userID string) (*model.UserInfo, error)
+	// Create 创建用户，邀请码重复时返回 ErrInviteCodeTaken
+	Create(q Querier, user *model.UserInfo) error
+	// UpdateProfile 更新昵称和头像，用户不存在时返回 ErrUserNotFound；
+	// user.Nickname 为空时昵称和待审核的昵称都不变；user.PendingNickname 不为空时保留原昵称，新昵称记为待审核
+	UpdateProfile(q Querier, user *model.UserInfo) error
+	// SetInviteCode 设置邀请码，邀请码重复时返回 ErrInviteCodeTaken
+	SetInviteCode(q Querier, userID, code string) error
+	// ListPendingNicknames 按用户ID倒序分页获取待审核的昵称
+	ListPendingNicknames(q Querier, cursor int64, pageSize int) (*model.PendingNicknameListResponse, error)
+	// ResolvePendingNickname 审核待审核的昵称，approve 为 true 时替换为新昵称，否则保留原昵称；
+	// 待审核的昵称与 nickname 不一致时返回 ErrNicknameNotPending
+	ResolvePendingNickname(q Querier, userID, nickname string, approve bool) error
+}
+
+// RecordRepo 发型生成记录
+type RecordRepo interface {
+	// Save 保存生成记录并回填ID
+	Save(q Querier, record *model.HairStyleRecord) error
+	// List 按生成时间倒序分页获取用户的记录
+	List(q Querier, userID string, page, pageSize int) (*model.RecordResponse, error)
+}
+
+// SquareRepo 广场内容
+type SquareRepo interface {
+	// GetShareableRecord 获取可分享的记录，同时校验记录存在、归属和是否已分享
+	GetShareableRecord(q Querier, userID string, recordID int64) (*model.HairStyleRecord, error)
+	// Share 将记录发布到广场并回填内容ID，只有记录归属于 content.UserID 时才会写入
+	Share(q Querier, content *model.SquareContent) error
+	// List 获取正常展示的广场内容，tag 不为空时只返回带该标签的内容，不包含标签列表
+	List(q Querier, userID, tag string, cursor int64, pageSize int) (*model.SquareContentResponse, error)
+	// ToggleLike 点赞或取消点赞，返回操作后是否为已点赞以及内容作者
+	ToggleLike(q Querier, userID string, contentID int64) (liked bool, ownerID string, err error)
+}
+
+// LedgerRepo coin余额、批次和流水，变更余额的方法需要在事务中调用才能保证行锁生效
+type LedgerRepo interface {
+	// Balance 获取用户coin余额
+	Balance(q Querier, userID string) (int, error)
+	// Change 变更coin并记录流水，增加时记为一个批次，expireDate 为 nil 表示永不过期；
+	// 扣除时先作废已过期的批次，再依次消耗会过期的批次、没有批次的基础余额（如注册赠送）和永不过期的批次，
+	// 余额不足返回 ErrInsufficientCoin
+	Change(q Querier, userID string, amount int, source, refID string, expireDate *signin.Date) (int, error)
+	// Reclaim 从 lotSource、lotRefID 对应的批次中扣回 amount 个coin并记录流水，用于退款等撤销发放的场景；
+	// 批次不存在或剩余不足 amount（已被部分消耗）时返回 ErrInsufficientCoin
+	Reclaim(q Querier, userID, lotSource, lotRefID string, amount int, source, refID string) (int, error)
+	// ExpireLots 作废用户在 today 之前过期的批次并扣除余额，返回作废的coin数
+	ExpireLots(q Querier, userID string, today signin.Date) (int, error)
+	// ExpiredUsers 获取有过期未作废批次的用户，最多 limit 个
+	ExpiredUsers(q Querier, today signin.Date, limit int) ([]string, error)
+	// Expiring 获取用户最近一批即将过期的coin，没有会过期的coin时返回 nil
+	Expiring(q Querier, userID string, today signin.Date) (*model.CoinExpiry, error)
+}
+
+// MembershipRepo 会员数据和每日免费额度
+type MembershipRepo interface {
+	// Active 获取用户当前有效的会员，非会员或已过期时返回 nil
+	Active(q Querier, userID string, now time.Time) (*model.Membership, error)
+	// Extend 开通或续期会员，需要在事务中调用：同等级从当前到期时间顺延；升级立即生效，从 now 开始计算新等级的有效期，
+	// 原等级的剩余时长作废；当前等级更高时返回 ErrMembershipDowngrade。days 为负数时缩短同等级会员的有效期（用于退款）
+	Extend(q Querier, userID, tier string, days int, now time.Time) (*model.Membership, error)
+	// FreeUsage 获取用户某天已使用的免费生成次数
+	FreeUsage(q Querier, userID, date string) (int, error)
+	// ConsumeFreeQuota 使用一次当天的免费生成额度，额度已用完时返回 false
+	ConsumeFreeQuota(q Querier, userID, date string, quota int) (bool, error)
+}
+
+// PayOrderRepo 充值商品、支付订单和退款数据
+type PayOrderRepo interface {
+	// Products 获取上架的充值商品
+	Products(q Querier) ([]model.CoinProduct, error)
+	// Product 获取上架的充值商品，商品不存在或已下架时返回 ErrProductNotFound
+	Product(q Querier, productID int64) (*model.CoinProduct, error)
+	// Create 创建待支付订单并回填ID
+	Create(q Querier, order *model.PayOrder) error
+	// SetPrepayID 保存微信支付预支付交易会话标识
+	SetPrepayID(q Querier, outTradeNo, prepayID string) error
+	// Close 关闭待支付订单，已支付的订单不受影响
+	Close(q Querier, outTradeNo string) error
+	// Get 按商户订单号获取订单，订单不存在返回 ErrOrderNotFound
+	Get(q Querier, outTradeNo string) (*model.PayOrder, error)
+	// List 按订单ID倒序分页获取用户的订单
+	List(q Querier, userID string, cursor int64, pageSize int) (*model.PayOrderListResponse, error)
+	// MarkPaid 标记订单已支付并发放coin和会员，需要在事务中调用；重复通知时不会重复发放，
+	// 返回本次是否发放，订单不存在返回 ErrOrderNotFound，金额不一致返回 ErrOrderAmountMismatch
+	MarkPaid(q Querier, outTradeNo, transactionID string, amount int, paidAt time.Time) (bool, error)
+	// CreateRefund 创建退款单并扣回订单发放的coin和会员时长，订单进入退款中状态，需要在事务中调用；
+	// 只从订单充值时发放的coin批次中扣回，该批次已被部分消耗时返回 ErrRefundCoinsSpent
+	CreateRefund(q Querier, refund *model.PayRefund, now time.Time) error
+	// CompleteRefund 退款成功，重复通知时不做处理，需要在事务中调用
+	CompleteRefund(q Querier, outRefundNo, refundID string) error
+	// FailRefund 退款失败或关闭，退回扣除的coin和会员时长并恢复订单为已支付，重复通知时不做处理，需要在事务中调用
+	FailRefund(q Querier, outRefundNo string, now time.Time) error
+}
+
+// FavoriteRepo 收藏和收藏夹
+type FavoriteRepo interface {
+	// Add 收藏正常展示的内容并回填收藏ID，内容已收藏时移动到指定收藏夹
+	Add(q Querier, favorite *model.Favorite) error
+	// Remove 取消收藏，未收藏时返回 ErrFavoriteNotFound
+	Remove(q Querier, userID string, contentID int64) error
+	// List 按收藏ID倒序分页获取收藏及内容的标签，collectionID 小于0时返回所有收藏夹的内容
+	List(q Querier, userID string, collectionID, cursor int64, pageSize int) (*model.FavoriteListResponse, error)
+	// CreateCollection 创建收藏夹并回填ID，同名时返回 ErrCollectionExists
+	CreateCollection(q Querier, collection *model.FavoriteCollection) error
+	// Collections 获取用户的收藏夹列表，第一个为ID为0的默认收藏夹
+	Collections(q Querier, userID string) ([]model.FavoriteCollection, error)
+	// DeleteCollection 删除收藏夹，其中的收藏移回默认收藏夹，需要在事务中调用
+	DeleteCollection(q Querier, userID string, collectionID int64) error
+}
+
+// TagRepo 广场标签
+type TagRepo interface {
+	// Attach 为内容添加标签，标签不存在时自动创建，需要在事务中调用
+	Attach(q Querier, contentID int64, tags []string) error
+	// Fill 批量查询并填充内容的标签
+	Fill(q Querier, contents []model.SquareContent) error
+	// Trending 获取 since 之后使用最多的标签，只统计正常展示的内容
+	Trending(q Querier, since time.Time, limit int) ([]model.TrendingTag, error)
+}
+
+// NotificationRepo 站内通知
+type NotificationRepo interface {
+	// Create 发送通知，需要在事务中调用；已有同类型、同对象的未读通知时聚合到该通知上，
+	// 同一参与人只计一次，参与人就是接收人时不发送
+	Create(q Querier, userID, notificationType string, contentID int64, actorID string) error
+	// List 按通知ID倒序分页获取通知及未读数
+	List(q Querier, userID string, cursor int64, pageSize int) (*model.NotificationListResponse, error)
+	// UnreadCount 获取未读通知数
+	UnreadCount(q Querier, userID string) (int, error)
+	// MarkRead 标记通知为已读，ids 为空时标记全部已读
+	MarkRead(q Querier, userID string, ids []int64) error
+}
+
+// SubscriptionRepo 小程序订阅消息授权
+type SubscriptionRepo interface {
+	// SaveResult 保存用户对模板的授权结果，accept 增加一次可发送次数，ban 清空可发送次数，需要在事务中调用
+	SaveResult(q Querier, userID, templateID, result string) error
+	// List 获取用户的全部授权
+	List(q Querier, userID string) ([]model.Subscription, error)
+	// Consume 消耗一次发送次数，没有剩余次数时返回 false
+	Consume(q Querier, userID, templateID string) (bool, error)
+	// Restore 发送失败时归还一次发送次数
+	Restore(q Querier, userID, templateID string) error
+	// Revoke 用户已拒收消息时清空发送次数
+	Revoke(q Querier, userID, templateID string) error
+	// SignInReminderTargets 获取订阅了签到提醒且 today 未签到的用户，按 user_id 分批遍历
+	SignInReminderTargets(q Querier, templateID, today, afterUserID string, limit int) ([]string, error)
+}
+
+// SignInRepo 签到和补签
+type SignInRepo interface {
+	// SignIn 签到并按连续签到天数发放奖励，需要在事务中调用
+	SignIn(q Querier, userID string, today signin.Date, cfg config.SignInConfig) (*model.SignInResult, error)
+	// Repair 使用补签卡补签过去的某一天并重新计算连续签到天数，需要在事务中调用
+	Repair(q Querier, userID string, date, today signin.Date, cfg config.SignInConfig) (*model.SignInResult, error)
+	// Calendar 获取用户在 [from, to] 日期范围内的签到记录及当前签到状态
+	Calendar(q Querier, userID string, from, to, today signin.Date) (*model.SignInCalendarResponse, error)
+}
+
+// InviteRepo 邀请关系和邀请奖励
+type InviteRepo interface {
+	// Bind 使用邀请码绑定邀请关系并回填邀请人和关系ID，需要在事务中调用；
+	// 只有还未生成过发型的用户可以绑定，否则返回 ErrInviteNotNewUser，保证奖励在首次生成后发放；
+	// 邀请人在 cfg.LevelRewards 层级内的上级中包含被邀请人时返回 ErrInviteCycle；
+	// 命中防刷规则的关系记为 InviteStatusBlocked，不发放奖励
+	Bind(q Querier, relation *model.InviteRelation, cfg config.InviteConfig) error
+	// GrantRewards 被邀请人完成首次生成后发放邀请奖励并标记关系已处理，重复调用不会重复发放，需要在事务中调用
+	GrantRewards(q Querier, inviteeID string, todayStart time.Time, cfg config.InviteConfig) error
+	// Stats 获取用户的邀请统计，并按关系ID倒序分页获取被邀请人
+	Stats(q Querier, userID string, cursor int64, pageSize int) (*model.InviteStatsResponse, error)
+}
+
+// ModerationRepo 内容举报和审核
+type ModerationRepo interface {
+	// Report 举报广场内容并回填举报ID，举报数达到阈值时自动隐藏内容，返回内容是否因本次举报被隐藏，需要在事务中调用
+	Report(q Querier, report *model.ContentReport, hideThreshold int) (bool, error)
+	// Queue 获取审核队列：有待处理举报或发布前待人工审核的内容，status 小于0时不按内容状态过滤
+	Queue(q Querier, status int, cursor int64, pageSize int) (*model.ReportedContentResponse, error)
+	// Moderate 审核内容，action 为 approve（恢复展示）或 remove（下架），下架时通知内容作者，需要在事务中调用
+	Moderate(q Querier, contentID int64, operator, action, note string) error
+	// Log 记录审核操作日志
+	Log(q Querier, contentID int64, operator, action, note string) error
+	// Logs 获取内容的审核操作日志，最新的在前
+	Logs(q Querier, contentID int64) ([]model.ModerationLog, error)
+}
+
+// RedeemRepo 兑换活动和兑换码
+type RedeemRepo interface {
+	// CreateCampaign 创建兑换活动并回填ID
+	CreateCampaign(q Querier, campaign *model.RedeemCampaign) error
+	// CreateCodes 为活动生成兑换码，需要在事务中调用；code 不为空时创建一个自定义兑换码，
+	// 否则批量生成 count 个随机兑换码，随机码重复时重新生成
+	CreateCodes(q Querier, campaignID int64, code string, count, maxUses int) ([]model.RedeemCode, error)
+	// ListCodes 按ID倒序分页获取活动的兑换码
+	ListCodes(q Querier, campaignID int64, cursor int64, pageSize int) (*model.RedeemCodeListResponse, error)
+	// Redeem 使用兑换码，发放活动配置的coin和会员，需要在事务中调用
+	Redeem(q Querier, userID, code string, now time.Time) (*model.RedeemResult, error)
+}
+
+// Repos 一种数据库下的全部数据访问实现
+type Repos struct {
+	Users         UserRepo
+	Records       RecordRepo
+	Square        SquareRepo
+	Tags          TagRepo
+	Favorites     FavoriteRepo
+	Notifications NotificationRepo
+	Subscriptions SubscriptionRepo
+	Moderation    ModerationRepo
+	Ledger        LedgerRepo
+	Memberships   MembershipRepo
+	PayOrders     PayOrderRepo
+	SignIns       SignInRepo
+	Invites       InviteRepo
+	Redeems       RedeemRepo
+}
+
+// New 创建指定数据库方言的数据访问实现
+func New(d Dialect) *Repos {
+	ledger := &ledgerRepo{d: d}
+	memberships := &membershipRepo{d: d}
+	tags := &tagRepo{d: d}
+	notifications := &notificationRepo{d: d}
+	return &Repos{
+		Users:         &userRepo{d: d},
+		Records:       &recordRepo{},
+		Square:        &squareRepo{d: d},
+		Tags:          tags,
+		Favorites:     &favoriteRepo{d: d, tags: tags},
+		Notifications: notifications,
+		Subscriptions: &subscriptionRepo{d: d},
+		Moderation:    &moderationRepo{d: d, notifications: notifications},
+		Ledger:        ledger,
+		Memberships:   memberships,
+		PayOrders:     &payOrderRepo{d: d, ledger: ledger, memberships: memberships},
+		SignIns:       &signInRepo{d: d, ledger: ledger},
+		Invites:       &inviteRepo{d: d, ledger: ledger},
+		Redeems:       &redeemRepo{d: d, ledger: ledger, memberships: memberships},
+	}
+}
+
+// WithTx 在事务中执行 fn，fn 返回错误时回滚，否则提交
+func WithTx(db *sql.DB, fn func(tx Querier) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+	return nil
+}
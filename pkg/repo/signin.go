@@ -0,0 +1,231 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/signin"
+)
+
+type signInRepo struct {
+	d      Dialect
+	ledger LedgerRepo
+}
+
+// signInState 用户当前的签到状态
+type signInState struct {
+	lastSignInDate *signin.Date
+	streak         int
+	repairCards    int
+}
+
+// lockState 锁定并读取用户签到状态
+func (r *signInRepo) lockState(q Querier, userID string) (*signInState, error) {
+	var lastSignInDate sql.NullTime
+	state := &signInState{}
+	err := q.QueryRow("SELECT last_sign_in_date, sign_in_streak, repair_cards FROM user_info WHERE user_id = ?"+r.d.ForUpdate(),
+		userID).Scan(&lastSignInDate, &state.streak, &state.repairCards)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询签到记录失败: %v", err)
+	}
+	if lastSignInDate.Valid {
+		d := signin.DateOf(lastSignInDate.Time)
+		state.lastSignInDate = &d
+	}
+	return state, nil
+}
+
+func (r *signInRepo) SignIn(q Querier, userID string, today signin.Date, cfg config.SignInConfig) (*model.SignInResult, error) {
+	// 检查今日是否已签到
+	state, err := r.lockState(q, userID)
+	if err != nil {
+		return nil, err
+	}
+	if state.lastSignInDate != nil && *state.lastSignInDate == today {
+		return nil, ErrAlreadySignedIn
+	}
+
+	// 昨天签到过则延续连续天数，否则重新计算
+	streak := 1
+	if state.lastSignInDate != nil && *state.lastSignInDate == today.AddDays(-1) {
+		streak = state.streak + 1
+	}
+
+	result := &model.SignInResult{
+		Date:        today.String(),
+		Streak:      streak,
+		Reward:      signin.RewardForStreak(cfg, streak),
+		RepairCards: signin.RepairCardsForStreak(cfg, streak),
+	}
+	repairCards := state.repairCards + result.RepairCards
+	if cfg.MaxRepairCards > 0 && repairCards > cfg.MaxRepairCards {
+		repairCards = cfg.MaxRepairCards
+		result.RepairCards = max(repairCards-state.repairCards, 0)
+	}
+
+	// 记录签到日志
+	_, err = q.Exec(`
+        INSERT INTO sign_in_log (user_id, sign_date, streak, reward, is_repair)
+        VALUES (?, ?, ?, ?, 0)
+    `, userID, today.String(), streak, result.Reward)
+	if r.d.IsDuplicateKey(err, "") {
+		return nil, ErrAlreadySignedIn
+	}
+	if err != nil {
+		return nil, fmt.Errorf("记录签到日志失败: %v", err)
+	}
+
+	// 更新签到状态
+	_, err = q.Exec(`
+        UPDATE user_info
+        SET last_sign_in_date = ?, sign_in_streak = ?, repair_cards = ?
+        WHERE user_id = ?
+    `, today.String(), streak, repairCards, userID)
+	if err != nil {
+		return nil, fmt.Errorf("更新签到信息失败: %v", err)
+	}
+
+	// 发放签到奖励，签到奖励属于促销coin，按配置过期
+	expireDate := promoExpireDate(today, cfg.RewardExpireDays)
+	if _, err := r.ledger.Change(q, userID, result.Reward, model.CoinSourceSignIn, today.String(), expireDate); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *signInRepo) Repair(q Querier, userID string, date, today signin.Date, cfg config.SignInConfig) (*model.SignInResult, error) {
+	if !date.Before(today) || today.DaysSince(date) > cfg.RepairWindowDays {
+		return nil, ErrRepairOutOfWindow
+	}
+
+	state, err := r.lockState(q, userID)
+	if err != nil {
+		return nil, err
+	}
+	if state.repairCards <= 0 {
+		return nil, ErrNoRepairCard
+	}
+
+	// 补签只发放基础奖励，不触发连续签到额外奖励
+	reward := signin.RewardForStreak(cfg, 1)
+	_, err = q.Exec(`
+        INSERT INTO sign_in_log (user_id, sign_date, streak, reward, is_repair)
+        VALUES (?, ?, 0, ?, 1)
+    `, userID, date.String(), reward)
+	if r.d.IsDuplicateKey(err, "") {
+		return nil, ErrDateAlreadySignedIn
+	}
+	if err != nil {
+		return nil, fmt.Errorf("记录补签日志失败: %v", err)
+	}
+
+	// 以最近一次签到日期为终点重新计算连续天数
+	anchor := date
+	if state.lastSignInDate != nil && state.lastSignInDate.After(date) {
+		anchor = *state.lastSignInDate
+	}
+	dates, err := r.datesDesc(q, userID, anchor)
+	if err != nil {
+		return nil, err
+	}
+	streak := signin.StreakEndingAt(dates, anchor)
+
+	_, err = q.Exec(`
+        UPDATE user_info
+        SET last_sign_in_date = ?, sign_in_streak = ?, repair_cards = repair_cards - 1
+        WHERE user_id = ?
+    `, anchor.String(), streak, userID)
+	if err != nil {
+		return nil, fmt.Errorf("更新签到信息失败: %v", err)
+	}
+
+	// 补签奖励的有效期从补签当天开始计算
+	expireDate := promoExpireDate(today, cfg.RewardExpireDays)
+	if _, err := r.ledger.Change(q, userID, reward, model.CoinSourceSignIn, date.String(), expireDate); err != nil {
+		return nil, err
+	}
+
+	return &model.SignInResult{
+		Date:     date.String(),
+		Streak:   streak,
+		Reward:   reward,
+		IsRepair: true,
+	}, nil
+}
+
+// datesDesc 获取截至某天的签到日期，按日期倒序
+func (r *signInRepo) datesDesc(q Querier, userID string, until signin.Date) ([]signin.Date, error) {
+	rows, err := q.Query(`
+        SELECT sign_date FROM sign_in_log
+        WHERE user_id = ? AND sign_date <= ?
+        ORDER BY sign_date DESC
+        LIMIT 1000
+    `, userID, until.String())
+	if err != nil {
+		return nil, fmt.Errorf("查询签到日志失败: %v", err)
+	}
+	defer rows.Close()
+
+	var dates []signin.Date
+	for rows.Next() {
+		var t sql.NullTime
+		if err := rows.Scan(&t); err != nil {
+			return nil, fmt.Errorf("扫描签到日志失败: %v", err)
+		}
+		dates = append(dates, signin.DateOf(t.Time))
+	}
+
+	return dates, rows.Err()
+}
+
+func (r *signInRepo) Calendar(q Querier, userID string, from, to, today signin.Date) (*model.SignInCalendarResponse, error) {
+	var lastSignInDate sql.NullTime
+	response := &model.SignInCalendarResponse{Days: []model.SignInDay{}}
+	err := q.QueryRow("SELECT last_sign_in_date, sign_in_streak, repair_cards FROM user_info WHERE user_id = ?",
+		userID).Scan(&lastSignInDate, &response.Streak, &response.RepairCards)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询签到记录失败: %v", err)
+	}
+
+	// 最近一次签到早于昨天时连续签到已中断
+	if lastSignInDate.Valid {
+		last := signin.DateOf(lastSignInDate.Time)
+		response.SignedToday = last == today
+		if last.Before(today.AddDays(-1)) {
+			response.Streak = 0
+		}
+	} else {
+		response.Streak = 0
+	}
+
+	rows, err := q.Query(`
+        SELECT sign_date, reward, is_repair FROM sign_in_log
+        WHERE user_id = ? AND sign_date BETWEEN ? AND ?
+        ORDER BY sign_date
+    `, userID, from.String(), to.String())
+	if err != nil {
+		return nil, fmt.Errorf("查询签到日志失败: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t sql.NullTime
+		var day model.SignInDay
+		if err := rows.Scan(&t, &day.Reward, &day.IsRepair); err != nil {
+			return nil, fmt.Errorf("扫描签到日志失败: %v", err)
+		}
+		day.Date = signin.DateOf(t.Time).String()
+		response.Days = append(response.Days, day)
+	}
+
+	return response, rows.Err()
+}
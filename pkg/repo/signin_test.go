@@ -0,0 +1,137 @@
+package repo_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo/sqlite"
+	"github.com/MRsummer/ChangeHairStyle/pkg/signin"
+)
+
+func TestSignIn(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+	cfg := config.SignInConfig{Rewards: []int{5, 10}}
+
+	createUser(t, db, repos, "u1")
+	today := signin.Date{Year: 2026, Month: 10, Day: 19}
+
+	// 连续两天签到按第二天的奖励发放
+	for i, day := range []signin.Date{today.AddDays(-1), today} {
+		result, err := repos.SignIns.SignIn(db, "u1", day, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Streak != i+1 || result.Reward != cfg.Rewards[i] {
+			t.Errorf("day %d = %+v, want streak %d reward %d", i, result, i+1, cfg.Rewards[i])
+		}
+	}
+	if _, err := repos.SignIns.SignIn(db, "u1", today, cfg); !errors.Is(err, repo.ErrAlreadySignedIn) {
+		t.Errorf("SignIn() error = %v, want ErrAlreadySignedIn", err)
+	}
+
+	balance, err := repos.Ledger.Balance(db, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance != 15 {
+		t.Errorf("balance = %d, want 15", balance)
+	}
+
+	calendar, err := repos.SignIns.Calendar(db, "u1", today.AddDays(-18), today, today)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !calendar.SignedToday || calendar.Streak != 2 || len(calendar.Days) != 2 {
+		t.Errorf("calendar = %+v, want signed today with streak 2", calendar)
+	}
+}
+
+func TestSignInStreakAndRepair(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+	cfg := config.SignInConfig{Rewards: []int{5, 10, 20}, RepairWindowDays: 3, RepairCardInterval: 2, MaxRepairCards: 1}
+
+	createUser(t, db, repos, "u1")
+	today := signin.Date{Year: 2026, Month: 10, Day: 19}
+
+	// 中断一天后连续天数重新计算；补签卡每连续2天奖励一张，达到上限后不再发放
+	signIns := []struct {
+		day             signin.Date
+		wantStreak      int
+		wantReward      int
+		wantRepairCards int
+	}{
+		{today.AddDays(-5), 1, 5, 0},
+		{today.AddDays(-4), 2, 10, 1},
+		{today.AddDays(-2), 1, 5, 0},
+		{today.AddDays(-1), 2, 10, 0},
+	}
+	for _, tt := range signIns {
+		result, err := repos.SignIns.SignIn(db, "u1", tt.day, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Streak != tt.wantStreak || result.Reward != tt.wantReward || result.RepairCards != tt.wantRepairCards {
+			t.Errorf("%s: result = %+v, want streak %d reward %d repair cards %d",
+				tt.day, result, tt.wantStreak, tt.wantReward, tt.wantRepairCards)
+		}
+	}
+
+	// 补签只能补窗口内的过去日期，需要补签卡，补上缺口后连续天数接上之前的签到
+	repairs := []struct {
+		name       string
+		date       signin.Date
+		wantErr    error
+		wantStreak int
+	}{
+		{"today", today, repo.ErrRepairOutOfWindow, 0},
+		{"out of window", today.AddDays(-4), repo.ErrRepairOutOfWindow, 0},
+		{"already signed", today.AddDays(-2), repo.ErrDateAlreadySignedIn, 0},
+		{"gap", today.AddDays(-3), nil, 5},
+		{"no card left", today.AddDays(-3), repo.ErrNoRepairCard, 0},
+	}
+	for _, tt := range repairs {
+		result, err := repos.SignIns.Repair(db, "u1", tt.date, today, cfg)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: Repair() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && (result.Streak != tt.wantStreak || result.Reward != 5 || !result.IsRepair) {
+			t.Errorf("%s: result = %+v, want streak %d with the base reward", tt.name, result, tt.wantStreak)
+		}
+	}
+
+	// 日历在今天未签到时保留连续天数，昨天也未签到时显示为中断
+	calendars := []struct {
+		today      signin.Date
+		wantStreak int
+	}{
+		{today, 5},
+		{today.AddDays(1), 0},
+	}
+	for _, tt := range calendars {
+		calendar, err := repos.SignIns.Calendar(db, "u1", today.AddDays(-5), tt.today, tt.today)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(calendar.Days) != 5 {
+			t.Fatalf("%s: days = %+v, want 5 days", tt.today, calendar.Days)
+		}
+		if calendar.SignedToday || calendar.Streak != tt.wantStreak || calendar.RepairCards != 0 {
+			t.Errorf("%s: calendar = %+v, want streak %d", tt.today, calendar, tt.wantStreak)
+		}
+		if day := calendar.Days[2]; day.Date != today.AddDays(-3).String() || !day.IsRepair {
+			t.Errorf("%s: day = %+v, want the repaired day", tt.today, day)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+-- 内存测试库的表结构，与 schema.sql 中数据访问接口和生成并发控制用到的表保持一致
+CREATE TABLE hair_style_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    image_url TEXT NOT NULL,
+    prompt TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_records_user_id ON hair_style_records (user_id);
+
+CREATE TABLE user_info (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(64) NOT NULL UNIQUE,
+    nickname VARCHAR(64),
+    pending_nickname VARCHAR(64) NOT NULL DEFAULT '',
+    avatar_url VARCHAR(255),
+    coin INT DEFAULT 60,
+    invite_code VARCHAR(16) UNIQUE,
+    used_invite_code VARCHAR(16),
+    last_sign_in_date DATE,
+    sign_in_streak INT NOT NULL DEFAULT 0,
+    repair_cards INT NOT NULL DEFAULT 0,
+    language VARCHAR(8) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE square_content (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    record_id BIGINT NOT NULL UNIQUE,
+    like_count INT DEFAULT 0,
+    status TINYINT NOT NULL DEFAULT 0,
+    report_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE like_record (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    content_id BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, content_id)
+);
+
+CREATE TABLE favorite (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    content_id BIGINT NOT NULL,
+    collection_id BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, content_id)
+);
+
+CREATE TABLE square_tag (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(32) NOT NULL UNIQUE,
+    use_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE square_content_tag (
+    content_id BIGINT NOT NULL,
+    tag_id BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (content_id, tag_id)
+);
+
+CREATE TABLE coin_ledger (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    amount INT NOT NULL,
+    balance INT NOT NULL,
+    source VARCHAR(32) NOT NULL,
+    ref_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE coin_lot (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    source VARCHAR(32) NOT NULL,
+    ref_id VARCHAR(64) NOT NULL DEFAULT '',
+    amount INT NOT NULL,
+    remaining INT NOT NULL,
+    expire_date DATE NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_coin_lot_user_expire ON coin_lot (user_id, expire_date);
+
+CREATE TABLE user_membership (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(64) NOT NULL UNIQUE,
+    tier VARCHAR(16) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE pay_order (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    out_trade_no VARCHAR(32) NOT NULL UNIQUE,
+    user_id VARCHAR(64) NOT NULL,
+    product_id BIGINT NOT NULL,
+    description VARCHAR(128) NOT NULL,
+    coins INT NOT NULL,
+    amount INT NOT NULL,
+    membership_tier VARCHAR(16) NOT NULL DEFAULT '',
+    membership_days INT NOT NULL DEFAULT 0,
+    status TINYINT NOT NULL DEFAULT 0,
+    prepay_id VARCHAR(64) NOT NULL DEFAULT '',
+    transaction_id VARCHAR(32) NOT NULL DEFAULT '',
+    paid_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE favorite_collection (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    name VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+CREATE TABLE content_report (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    content_id BIGINT NOT NULL,
+    reporter_id VARCHAR(64) NOT NULL,
+    reason TINYINT NOT NULL,
+    detail VARCHAR(255),
+    status TINYINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (content_id, reporter_id)
+);
+
+CREATE TABLE moderation_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    content_id BIGINT NOT NULL,
+    operator VARCHAR(64) NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    note VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE notification (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    content_id BIGINT NOT NULL DEFAULT 0,
+    actor_count INT NOT NULL DEFAULT 0,
+    last_actor_id VARCHAR(64) NOT NULL DEFAULT '',
+    is_read TINYINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE notification_actor (
+    notification_id BIGINT NOT NULL,
+    actor_id VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (notification_id, actor_id)
+);
+
+CREATE TABLE wx_subscription (
+    user_id VARCHAR(64) NOT NULL,
+    template_id VARCHAR(64) NOT NULL,
+    remaining INT NOT NULL DEFAULT 0,
+    last_result VARCHAR(16) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, template_id)
+);
+
+CREATE TABLE sign_in_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    sign_date DATE NOT NULL,
+    streak INT NOT NULL DEFAULT 0,
+    reward INT NOT NULL DEFAULT 0,
+    is_repair TINYINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, sign_date)
+);
+
+CREATE TABLE invite_relation (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    inviter_id VARCHAR(64) NOT NULL,
+    invitee_id VARCHAR(64) NOT NULL UNIQUE,
+    invite_code VARCHAR(16) NOT NULL,
+    device_id VARCHAR(128) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    status TINYINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    rewarded_at TIMESTAMP NULL
+);
+
+CREATE TABLE invite_reward (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    relation_id BIGINT NOT NULL,
+    beneficiary_id VARCHAR(64) NOT NULL,
+    level INT NOT NULL,
+    amount INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (relation_id, beneficiary_id)
+);
+
+CREATE TABLE coin_product (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(64) NOT NULL,
+    coins INT NOT NULL,
+    bonus_coins INT NOT NULL DEFAULT 0,
+    price INT NOT NULL,
+    sort INT NOT NULL DEFAULT 0,
+    status TINYINT NOT NULL DEFAULT 1,
+    membership_tier VARCHAR(16) NOT NULL DEFAULT '',
+    membership_days INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE pay_refund (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    out_refund_no VARCHAR(64) NOT NULL UNIQUE,
+    out_trade_no VARCHAR(32) NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    amount INT NOT NULL,
+    coins INT NOT NULL,
+    status TINYINT NOT NULL DEFAULT 0,
+    refund_id VARCHAR(32) NOT NULL DEFAULT '',
+    reason VARCHAR(80) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE daily_free_usage (
+    user_id VARCHAR(64) NOT NULL,
+    usage_date DATE NOT NULL,
+    used_count INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, usage_date)
+);
+
+CREATE TABLE redeem_campaign (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(64) NOT NULL,
+    coins INT NOT NULL DEFAULT 0,
+    membership_tier VARCHAR(16) NOT NULL DEFAULT '',
+    membership_days INT NOT NULL DEFAULT 0,
+    per_user_limit INT NOT NULL DEFAULT 1,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE redeem_code (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    campaign_id BIGINT NOT NULL,
+    code VARCHAR(16) NOT NULL UNIQUE,
+    max_uses INT NOT NULL DEFAULT 1,
+    used_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE redeem_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code_id BIGINT NOT NULL,
+    campaign_id BIGINT NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE generation_slot (
+    slot INT PRIMARY KEY,
+    holder VARCHAR(32) NULL,
+    expires_at DATETIME NULL
+);
+CREATE INDEX idx_generation_slot_holder ON generation_slot (holder);
+
+CREATE TABLE generation_queue (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket VARCHAR(32) NOT NULL UNIQUE,
+    user_id VARCHAR(64) NOT NULL,
+    priority TINYINT NOT NULL DEFAULT 0,
+    expires_at DATETIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_generation_queue_expires_at ON generation_queue (expires_at);
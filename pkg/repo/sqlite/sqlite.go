@@ -0,0 +1,62 @@
+// Package sqlite 基于内存 SQLite 的数据访问实现，用于在没有 MySQL 的环境下运行测试
+package sqlite
+
+import (
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/mattn/go-sqlite3"
+)
+
+//go:embed schema.sql
+var schema string
+
+// Dialect SQLite 方言
+var Dialect repo.Dialect = dialect{}
+
+type dialect struct{}
+
+// ForUpdate SQLite 写事务本身是串行的，不需要也不支持行锁
+func (dialect) ForUpdate() string {
+	return ""
+}
+
+// IsDuplicateKey SQLite 的唯一约束错误信息形如 "UNIQUE constraint failed: user_info.invite_code"，按列名匹配
+func (dialect) IsDuplicateKey(err error, key string) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	if sqliteErr.ExtendedCode != sqlite3.ErrConstraintUnique &&
+		sqliteErr.ExtendedCode != sqlite3.ErrConstraintPrimaryKey {
+		return false
+	}
+	return strings.Contains(sqliteErr.Error(), key)
+}
+
+// Open 打开一个独立的内存数据库并建表，连接关闭后数据随之丢弃
+// 内存数据库只存在于单个连接中，因此连接池限制为一个连接，事务外的查询会等待事务结束
+func Open() (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("打开内存数据库失败: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("创建表结构失败: %v", err)
+	}
+	return db, nil
+}
+
+// New 返回 SQLite 的数据访问实现
+func New() *repo.Repos {
+	return repo.New(Dialect)
+}
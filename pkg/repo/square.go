@@ -0,0 +1,243 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+// defaultAvatarURL 未设置头像的用户在广场展示的默认头像
+const defaultAvatarURL = "https://hairstyle-1255379329.cos.ap-guangzhou.myqcloud.com/avatar.png"
+
+type squareRepo struct {
+	d Dialect
+}
+
+func (r *squareRepo) GetShareableRecord(q Querier, userID string, recordID int64) (*model.HairStyleRecord, error) {
+	query := `
+        SELECT hr.id, hr.user_id, hr.image_url, hr.prompt, hr.created_at,
+            EXISTS(SELECT 1 FROM square_content sc WHERE sc.record_id = hr.id) as shared
+        FROM hair_style_records hr
+        WHERE hr.id = ?
+    `
+
+	var record model.HairStyleRecord
+	var shared bool
+	err := q.QueryRow(query, recordID).Scan(
+		&record.ID,
+		&record.UserID,
+		&record.ImageURL,
+		&record.Prompt,
+		&record.CreatedAt,
+		&shared,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询记录失败: %v", err)
+	}
+	if record.UserID != userID {
+		return nil, ErrRecordNotOwned
+	}
+	if shared {
+		return nil, ErrRecordAlreadyShared
+	}
+
+	return &record, nil
+}
+
+func (r *squareRepo) Share(q Querier, content *model.SquareContent) error {
+	query := `
+        INSERT INTO square_content (user_id, record_id, status)
+        SELECT user_id, id, ?
+        FROM hair_style_records
+        WHERE id = ? AND user_id = ?
+    `
+
+	result, err := q.Exec(query, content.Status, content.RecordID, content.UserID)
+	if r.d.IsDuplicateKey(err, "") {
+		return ErrRecordAlreadyShared
+	}
+	if err != nil {
+		return fmt.Errorf("分享到广场失败: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取影响行数失败: %v", err)
+	}
+	if affected == 0 {
+		// 未写入说明记录不存在或不属于该用户，查询具体原因
+		if _, err := r.GetShareableRecord(q, content.UserID, content.RecordID); err != nil {
+			return err
+		}
+		return ErrRecordNotOwned
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取插入ID失败: %v", err)
+	}
+
+	content.ID = id
+	return nil
+}
+
+func (r *squareRepo) List(q Querier, userID, tag string, cursor int64, pageSize int) (*model.SquareContentResponse, error) {
+	// 按标签筛选
+	tagJoin := ""
+	tagArgs := []interface{}{}
+	if tag != "" {
+		tagJoin = `
+        JOIN square_content_tag sct ON sct.content_id = sc.id
+        JOIN square_tag st ON sct.tag_id = st.id AND st.name = ?`
+		tagArgs = append(tagArgs, tag)
+	}
+
+	// 获取总记录数（仅统计正常展示的内容）
+	countQuery := `SELECT COUNT(*) FROM square_content sc` + tagJoin + ` WHERE sc.status = ?`
+	var total int64
+	err := q.QueryRow(countQuery, append(tagArgs, model.SquareStatusNormal)...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("获取总记录数失败: %v", err)
+	}
+
+	// 获取分页数据
+	query := `
+        SELECT
+            sc.id, sc.user_id, sc.record_id, sc.like_count, sc.created_at, sc.updated_at,
+            hr.image_url, hr.prompt, hr.created_at as record_created_at,
+            ui.nickname, ui.avatar_url,
+            CASE WHEN lr.id IS NOT NULL THEN 1 ELSE 0 END as is_liked,
+            CASE WHEN f.id IS NOT NULL THEN 1 ELSE 0 END as is_favorited
+        FROM square_content sc` + tagJoin + `
+        LEFT JOIN hair_style_records hr ON sc.record_id = hr.id
+        LEFT JOIN user_info ui ON sc.user_id = ui.user_id
+        LEFT JOIN like_record lr ON sc.id = lr.content_id AND lr.user_id = ?
+        LEFT JOIN favorite f ON sc.id = f.content_id AND f.user_id = ?
+        WHERE sc.id < ? AND sc.status = ?
+        ORDER BY sc.id DESC
+        LIMIT ?
+    `
+
+	// 如果是第一页，使用一个足够大的ID作为cursor
+	if cursor == 0 {
+		cursor = 9223372036854775807 // BIGINT的最大值
+	}
+
+	args := append(tagArgs, userID, userID, cursor, model.SquareStatusNormal, pageSize)
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询广场内容失败: %v", err)
+	}
+	defer rows.Close()
+
+	var contents []model.SquareContent
+	var nextCursor int64
+	for rows.Next() {
+		var content model.SquareContent
+		var record model.HairStyleRecord
+		var nickname, avatarURL sql.NullString
+
+		err := rows.Scan(
+			&content.ID,
+			&content.UserID,
+			&content.RecordID,
+			&content.LikeCount,
+			&content.CreatedAt,
+			&content.UpdatedAt,
+			&record.ImageURL,
+			&record.Prompt,
+			&record.CreatedAt,
+			&nickname,
+			&avatarURL,
+			&content.IsLiked,
+			&content.IsFavorited,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("扫描记录失败: %v", err)
+		}
+
+		// 未设置昵称和头像的用户使用默认展示
+		userInfo := model.UserInfo{Nickname: nickname.String, AvatarURL: avatarURL.String}
+		if !nickname.Valid {
+			userInfo.Nickname = defaultNickname(content.UserID)
+		}
+		if !avatarURL.Valid {
+			userInfo.AvatarURL = defaultAvatarURL
+		}
+
+		content.Record = &record
+		content.UserInfo = &userInfo
+		contents = append(contents, content)
+		nextCursor = content.ID
+	}
+
+	// 如果没有更多数据，nextCursor设为0
+	if len(contents) < pageSize {
+		nextCursor = 0
+	}
+
+	return &model.SquareContentResponse{
+		Total:      total,
+		Records:    contents,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+func (r *squareRepo) ToggleLike(q Querier, userID string, contentID int64) (bool, string, error) {
+	// 检查是否已点赞
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM like_record WHERE user_id = ? AND content_id = ?)",
+		userID, contentID).Scan(&exists)
+	if err != nil {
+		return false, "", fmt.Errorf("检查点赞状态失败: %v", err)
+	}
+
+	if exists {
+		// 取消点赞
+		_, err = q.Exec("DELETE FROM like_record WHERE user_id = ? AND content_id = ?",
+			userID, contentID)
+		if err != nil {
+			return false, "", fmt.Errorf("取消点赞失败: %v", err)
+		}
+
+		_, err = q.Exec("UPDATE square_content SET like_count = like_count - 1 WHERE id = ?",
+			contentID)
+		if err != nil {
+			return false, "", fmt.Errorf("更新点赞数失败: %v", err)
+		}
+	} else {
+		// 添加点赞
+		_, err = q.Exec("INSERT INTO like_record (user_id, content_id) VALUES (?, ?)",
+			userID, contentID)
+		if err != nil {
+			return false, "", fmt.Errorf("添加点赞失败: %v", err)
+		}
+
+		_, err = q.Exec("UPDATE square_content SET like_count = like_count + 1 WHERE id = ?",
+			contentID)
+		if err != nil {
+			return false, "", fmt.Errorf("更新点赞数失败: %v", err)
+		}
+	}
+
+	var ownerID string
+	err = q.QueryRow("SELECT user_id FROM square_content WHERE id = ?", contentID).Scan(&ownerID)
+	if err != nil && err != sql.ErrNoRows {
+		return false, "", fmt.Errorf("查询内容作者失败: %v", err)
+	}
+
+	return !exists, ownerID, nil
+}
+
+// defaultNickname 未设置昵称的用户展示为“用户”加用户ID的后6位
+func defaultNickname(userID string) string {
+	runes := []rune(userID)
+	if len(runes) > 6 {
+		runes = runes[len(runes)-6:]
+	}
+	return "用户" + string(runes)
+}
@@ -0,0 +1,122 @@
+package repo_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo/sqlite"
+)
+
+// createUser 创建用户，邀请码由用户ID生成
+func createUser(t *testing.T, db *sql.DB, repos *repo.Repos, userID string) {
+	t.Helper()
+	if err := repos.Users.Create(db, &model.UserInfo{UserID: userID, InviteCode: "CODE-" + userID}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// shareContent 为用户保存一条生成记录并分享到广场
+func shareContent(t *testing.T, db *sql.DB, repos *repo.Repos, userID string, tags ...string) *model.SquareContent {
+	t.Helper()
+	record := &model.HairStyleRecord{UserID: userID, ImageURL: "https://img/1", Prompt: "短发"}
+	if err := repos.Records.Save(db, record); err != nil {
+		t.Fatal(err)
+	}
+	content := &model.SquareContent{UserID: userID, RecordID: record.ID, Status: model.SquareStatusNormal, Tags: tags}
+	if err := repos.Square.Share(db, content); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Tags.Attach(db, content.ID, tags); err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+func TestToggleLike(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+
+	record := &model.HairStyleRecord{UserID: "author", ImageURL: "https://img/1", Prompt: "短发"}
+	if err := repos.Records.Save(db, record); err != nil {
+		t.Fatal(err)
+	}
+	content := &model.SquareContent{UserID: "author", RecordID: record.ID}
+	if err := repos.Square.Share(db, content); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一用户连续操作在点赞和取消点赞之间切换，返回操作后的状态
+	for i, wantLiked := range []bool{true, false, true} {
+		liked, ownerID, err := repos.Square.ToggleLike(db, "u1", content.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if liked != wantLiked || ownerID != "author" {
+			t.Errorf("toggle %d = %v, %q, want %v, author", i, liked, ownerID, wantLiked)
+		}
+	}
+
+	var likeCount int
+	if err := db.QueryRow("SELECT like_count FROM square_content WHERE id = ?", content.ID).Scan(&likeCount); err != nil {
+		t.Fatal(err)
+	}
+	if likeCount != 1 {
+		t.Errorf("like_count = %d, want 1", likeCount)
+	}
+}
+
+func TestListByTag(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+
+	// 三条带“短发”的内容和一条不带的内容，其中一条被隐藏
+	var short []int64
+	for i := 0; i < 3; i++ {
+		short = append(short, shareContent(t, db, repos, "u1", "短发").ID)
+	}
+	shareContent(t, db, repos, "u2", "卷发")
+	if _, err := db.Exec("UPDATE square_content SET status = ? WHERE id = ?", model.SquareStatusHidden, short[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// 按ID倒序分页，最后一页不足一页时 next_cursor 为0
+	first, err := repos.Square.List(db, "u1", "短发", 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Total != 2 || len(first.Records) != 1 || first.Records[0].ID != short[2] || first.NextCursor != short[2] {
+		t.Fatalf("first page = %+v, want content %d of 2", first, short[2])
+	}
+	second, err := repos.Square.List(db, "u1", "短发", first.NextCursor, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.Records) != 1 || second.Records[0].ID != short[1] || second.NextCursor != 0 {
+		t.Fatalf("second page = %+v, want content %d and no next page", second, short[1])
+	}
+
+	all, err := repos.Square.List(db, "u1", "", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.Total != 3 || len(all.Records) != 3 {
+		t.Errorf("unfiltered = %d records of %d, want 3", len(all.Records), all.Total)
+	}
+	none, err := repos.Square.List(db, "u1", "长发", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if none.Total != 0 || len(none.Records) != 0 {
+		t.Errorf("unknown tag = %+v, want no records", none)
+	}
+}
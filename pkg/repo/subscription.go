@@ -0,0 +1,145 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/apperr"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+type subscriptionRepo struct {
+	d Dialect
+}
+
+func (r *subscriptionRepo) SaveResult(q Querier, userID, templateID, result string) error {
+	// 每种结果对应的可发送次数变化：accept 加一次，reject 不变，ban 清空
+	update := "last_result = ?, updated_at = CURRENT_TIMESTAMP"
+	initial := 0
+	switch result {
+	case model.SubscribeResultAccept:
+		update = "remaining = remaining + 1, " + update
+		initial = 1
+	case model.SubscribeResultReject:
+	case model.SubscribeResultBan:
+		update = "remaining = 0, " + update
+	default:
+		return apperr.ErrInvalidParam.With("result")
+	}
+
+	// 授权行加锁后按是否存在分别插入或更新，并发插入冲突时改为更新
+	for attempt := 0; ; attempt++ {
+		var exists int
+		err := q.QueryRow("SELECT 1 FROM wx_subscription WHERE user_id = ? AND template_id = ?"+r.d.ForUpdate(),
+			userID, templateID).Scan(&exists)
+		if err == nil {
+			_, err = q.Exec(`
+                UPDATE wx_subscription SET `+update+`
+                WHERE user_id = ? AND template_id = ?
+            `, result, userID, templateID)
+			if err != nil {
+				return fmt.Errorf("保存订阅授权失败: %v", err)
+			}
+			return nil
+		}
+		if err != sql.ErrNoRows {
+			return fmt.Errorf("查询订阅授权失败: %v", err)
+		}
+
+		_, err = q.Exec(`
+            INSERT INTO wx_subscription (user_id, template_id, remaining, last_result)
+            VALUES (?, ?, ?, ?)
+        `, userID, templateID, initial, result)
+		if r.d.IsDuplicateKey(err, "") && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("保存订阅授权失败: %v", err)
+		}
+		return nil
+	}
+}
+
+func (r *subscriptionRepo) List(q Querier, userID string) ([]model.Subscription, error) {
+	rows, err := q.Query(`
+        SELECT user_id, template_id, remaining, last_result, updated_at
+        FROM wx_subscription
+        WHERE user_id = ?
+    `, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询订阅授权失败: %v", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []model.Subscription{}
+	for rows.Next() {
+		var s model.Subscription
+		if err := rows.Scan(&s.UserID, &s.TemplateID, &s.Remaining, &s.LastResult, &s.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("扫描订阅授权失败: %v", err)
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func (r *subscriptionRepo) Consume(q Querier, userID, templateID string) (bool, error) {
+	result, err := q.Exec(`
+        UPDATE wx_subscription SET remaining = remaining - 1
+        WHERE user_id = ? AND template_id = ? AND remaining > 0
+    `, userID, templateID)
+	if err != nil {
+		return false, fmt.Errorf("扣减订阅次数失败: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("获取影响行数失败: %v", err)
+	}
+	return affected > 0, nil
+}
+
+func (r *subscriptionRepo) Restore(q Querier, userID, templateID string) error {
+	_, err := q.Exec("UPDATE wx_subscription SET remaining = remaining + 1 WHERE user_id = ? AND template_id = ?",
+		userID, templateID)
+	if err != nil {
+		return fmt.Errorf("归还订阅次数失败: %v", err)
+	}
+	return nil
+}
+
+func (r *subscriptionRepo) Revoke(q Querier, userID, templateID string) error {
+	_, err := q.Exec("UPDATE wx_subscription SET remaining = 0, last_result = ? WHERE user_id = ? AND template_id = ?",
+		model.SubscribeResultBan, userID, templateID)
+	if err != nil {
+		return fmt.Errorf("清空订阅次数失败: %v", err)
+	}
+	return nil
+}
+
+func (r *subscriptionRepo) SignInReminderTargets(q Querier, templateID, today, afterUserID string, limit int) ([]string, error) {
+	rows, err := q.Query(`
+        SELECT ws.user_id
+        FROM wx_subscription ws
+        JOIN user_info ui ON ws.user_id = ui.user_id
+        WHERE ws.template_id = ? AND ws.remaining > 0 AND ws.user_id > ?
+            AND (ui.last_sign_in_date IS NULL OR ui.last_sign_in_date < ?)
+        ORDER BY ws.user_id
+        LIMIT ?
+    `, templateID, afterUserID, today, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询签到提醒用户失败: %v", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("扫描签到提醒用户失败: %v", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
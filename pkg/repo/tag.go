@@ -0,0 +1,137 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+type tagRepo struct {
+	d Dialect
+}
+
+func (r *tagRepo) Attach(q Querier, contentID int64, tags []string) error {
+	for _, name := range tags {
+		tagID, err := r.lockOrCreate(q, name)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.Exec("INSERT INTO square_content_tag (content_id, tag_id) VALUES (?, ?)", contentID, tagID)
+		if r.d.IsDuplicateKey(err, "") {
+			// 同一条内容重复的标签只关联一次，也不重复计数
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("关联标签失败: %v", err)
+		}
+
+		_, err = q.Exec("UPDATE square_tag SET use_count = use_count + 1 WHERE id = ?", tagID)
+		if err != nil {
+			return fmt.Errorf("保存标签失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// lockOrCreate 锁定并返回标签ID，标签不存在时创建，并发创建同名标签时取已存在的标签
+func (r *tagRepo) lockOrCreate(q Querier, name string) (int64, error) {
+	for attempt := 0; ; attempt++ {
+		var tagID int64
+		err := q.QueryRow("SELECT id FROM square_tag WHERE name = ?"+r.d.ForUpdate(), name).Scan(&tagID)
+		if err == nil {
+			return tagID, nil
+		}
+		if err != sql.ErrNoRows {
+			return 0, fmt.Errorf("查询标签失败: %v", err)
+		}
+
+		result, err := q.Exec("INSERT INTO square_tag (name, use_count) VALUES (?, 0)", name)
+		if r.d.IsDuplicateKey(err, "") && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("保存标签失败: %v", err)
+		}
+
+		tagID, err = result.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("获取标签ID失败: %v", err)
+		}
+		return tagID, nil
+	}
+}
+
+func (r *tagRepo) Fill(q Querier, contents []model.SquareContent) error {
+	if len(contents) == 0 {
+		return nil
+	}
+
+	index := make(map[int64]int, len(contents))
+	placeholders := make([]string, 0, len(contents))
+	args := make([]interface{}, 0, len(contents))
+	for i, content := range contents {
+		index[content.ID] = i
+		placeholders = append(placeholders, "?")
+		args = append(args, content.ID)
+	}
+
+	query := fmt.Sprintf(`
+        SELECT sct.content_id, st.name
+        FROM square_content_tag sct
+        JOIN square_tag st ON sct.tag_id = st.id
+        WHERE sct.content_id IN (%s)
+        ORDER BY sct.content_id, st.id
+    `, strings.Join(placeholders, ","))
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("查询内容标签失败: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var contentID int64
+		var name string
+		if err := rows.Scan(&contentID, &name); err != nil {
+			return fmt.Errorf("扫描内容标签失败: %v", err)
+		}
+		i := index[contentID]
+		contents[i].Tags = append(contents[i].Tags, name)
+	}
+
+	return rows.Err()
+}
+
+func (r *tagRepo) Trending(q Querier, since time.Time, limit int) ([]model.TrendingTag, error) {
+	query := `
+        SELECT st.name, COUNT(*) as content_count
+        FROM square_content_tag sct
+        JOIN square_tag st ON sct.tag_id = st.id
+        JOIN square_content sc ON sct.content_id = sc.id
+        WHERE sct.created_at >= ? AND sc.status = ?
+        GROUP BY st.id, st.name
+        ORDER BY content_count DESC, st.id DESC
+        LIMIT ?
+    `
+
+	rows, err := q.Query(query, since, model.SquareStatusNormal, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询热门标签失败: %v", err)
+	}
+	defer rows.Close()
+
+	tags := make([]model.TrendingTag, 0, limit)
+	for rows.Next() {
+		var tag model.TrendingTag
+		if err := rows.Scan(&tag.Name, &tag.ContentCount); err != nil {
+			return nil, fmt.Errorf("扫描热门标签失败: %v", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
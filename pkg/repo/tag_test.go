@@ -0,0 +1,51 @@
+package repo_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+	"github.com/MRsummer/ChangeHairStyle/pkg/repo/sqlite"
+)
+
+func TestAttachTags(t *testing.T) {
+	db, err := sqlite.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repos := sqlite.New()
+
+	// 同一内容重复的标签只关联一次
+	first := shareContent(t, db, repos, "u1", "短发", "短发", "卷发")
+	second := shareContent(t, db, repos, "u2", "短发")
+
+	contents := []model.SquareContent{{ID: first.ID}, {ID: second.ID}}
+	if err := repos.Tags.Fill(db, contents); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"短发", "卷发"}; !reflect.DeepEqual(contents[0].Tags, want) {
+		t.Errorf("first tags = %v, want %v", contents[0].Tags, want)
+	}
+	if want := []string{"短发"}; !reflect.DeepEqual(contents[1].Tags, want) {
+		t.Errorf("second tags = %v, want %v", contents[1].Tags, want)
+	}
+
+	var useCount int
+	if err := db.QueryRow("SELECT use_count FROM square_tag WHERE name = '短发'").Scan(&useCount); err != nil {
+		t.Fatal(err)
+	}
+	if useCount != 2 {
+		t.Errorf("use_count = %d, want 2", useCount)
+	}
+
+	trending, err := repos.Tags.Trending(db, time.Now().UTC().Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []model.TrendingTag{{Name: "短发", ContentCount: 2}, {Name: "卷发", ContentCount: 1}}
+	if !reflect.DeepEqual(trending, want) {
+		t.Errorf("trending = %+v, want %+v", trending, want)
+	}
+}
@@ -0,0 +1,211 @@
+package repo
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+type userRepo struct {
+	d Dialect
+}
+
+func (r *userRepo) Get(q Querier, userID string) (*model.UserInfo, error) {
+	query := `
+        SELECT id, user_id, nickname, pending_nickname, avatar_url, coin, invite_code, used_invite_code, last_sign_in_date, language, created_at, updated_at
+        FROM user_info
+        WHERE user_id = ?
+    `
+
+	userInfo := &model.UserInfo{}
+	var nickname, avatarURL, inviteCode, usedInviteCode sql.NullString
+	var lastSignInDate sql.NullTime
+	err := q.QueryRow(query, userID).Scan(
+		&userInfo.ID,
+		&userInfo.UserID,
+		&nickname,
+		&userInfo.PendingNickname,
+		&avatarURL,
+		&userInfo.Coin,
+		&inviteCode,
+		&usedInviteCode,
+		&lastSignInDate,
+		&userInfo.Language,
+		&userInfo.CreatedAt,
+		&userInfo.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取用户信息失败: %v", err)
+	}
+
+	// 将 sql.NullString 转换为 string
+	if nickname.Valid {
+		userInfo.Nickname = nickname.String
+	}
+	if avatarURL.Valid {
+		userInfo.AvatarURL = avatarURL.String
+	}
+	if inviteCode.Valid {
+		userInfo.InviteCode = inviteCode.String
+	}
+	if usedInviteCode.Valid {
+		userInfo.UsedInviteCode = usedInviteCode.String
+	}
+	if lastSignInDate.Valid {
+		userInfo.LastSignInDate = &lastSignInDate.Time
+	}
+
+	return userInfo, nil
+}
+
+func (r *userRepo) Create(q Querier, user *model.UserInfo) error {
+	result, err := q.Exec(`
+        INSERT INTO user_info (user_id, coin, invite_code)
+        VALUES (?, ?, ?)
+    `, user.UserID, user.Coin, user.InviteCode)
+	if r.d.IsDuplicateKey(err, "invite_code") {
+		return ErrInviteCodeTaken
+	}
+	if err != nil {
+		return fmt.Errorf("创建用户失败: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取用户ID失败: %v", err)
+	}
+
+	user.ID = id
+	return nil
+}
+
+func (r *userRepo) UpdateProfile(q Querier, user *model.UserInfo) error {
+	// 先检查用户是否存在
+	exists, err := r.exists(q, user.UserID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	// 没有修改昵称时保留原昵称和待审核的昵称；新昵称需要审核时保留原昵称，
+	// 否则直接更新昵称并清除之前待审核的昵称
+	_, err = q.Exec(`
+        UPDATE user_info
+        SET nickname = CASE WHEN ? = '' OR ? != '' THEN nickname ELSE ? END,
+            pending_nickname = CASE WHEN ? = '' THEN pending_nickname ELSE ? END,
+            avatar_url = ?,
+            language = CASE WHEN ? = '' THEN language ELSE ? END
+        WHERE user_id = ?
+    `, user.Nickname, user.PendingNickname, user.Nickname, user.Nickname, user.PendingNickname,
+		user.AvatarURL, user.Language, user.Language, user.UserID)
+	if err != nil {
+		return fmt.Errorf("更新用户信息失败: %v", err)
+	}
+
+	return nil
+}
+
+func (r *userRepo) SetInviteCode(q Querier, userID, code string) error {
+	result, err := q.Exec("UPDATE user_info SET invite_code = ? WHERE user_id = ?", code, userID)
+	if r.d.IsDuplicateKey(err, "invite_code") {
+		return ErrInviteCodeTaken
+	}
+	if err != nil {
+		return fmt.Errorf("设置邀请码失败: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取影响行数失败: %v", err)
+	}
+	if affected == 0 {
+		// 邀请码未变化时也不会有影响行，需要区分用户是否存在
+		exists, err := r.exists(q, userID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrUserNotFound
+		}
+	}
+
+	return nil
+}
+
+func (r *userRepo) ListPendingNicknames(q Querier, cursor int64, pageSize int) (*model.PendingNicknameListResponse, error) {
+	// 第一页使用一个足够大的ID作为cursor
+	if cursor == 0 {
+		cursor = 9223372036854775807
+	}
+
+	rows, err := q.Query(`
+        SELECT id, user_id, nickname, pending_nickname, updated_at
+        FROM user_info
+        WHERE pending_nickname <> '' AND id < ?
+        ORDER BY id DESC
+        LIMIT ?
+    `, cursor, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("查询待审核昵称失败: %v", err)
+	}
+	defer rows.Close()
+
+	records := []model.PendingNickname{}
+	for rows.Next() {
+		var p model.PendingNickname
+		var nickname sql.NullString
+		if err := rows.Scan(&p.ID, &p.UserID, &nickname, &p.PendingNickname, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("扫描待审核昵称失败: %v", err)
+		}
+		p.Nickname = nickname.String
+		records = append(records, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询待审核昵称失败: %v", err)
+	}
+
+	var nextCursor int64
+	if len(records) == pageSize {
+		nextCursor = records[len(records)-1].ID
+	}
+	return &model.PendingNicknameListResponse{Records: records, NextCursor: nextCursor}, nil
+}
+
+func (r *userRepo) ResolvePendingNickname(q Querier, userID, nickname string, approve bool) error {
+	// 只处理与审核时看到的一致的昵称，用户在审核期间再次修改时需要重新审核
+	result, err := q.Exec(`
+        UPDATE user_info
+        SET nickname = CASE WHEN ? THEN pending_nickname ELSE nickname END,
+            pending_nickname = ''
+        WHERE user_id = ? AND pending_nickname = ? AND pending_nickname <> ''
+    `, approve, userID, nickname)
+	if err != nil {
+		return fmt.Errorf("审核昵称失败: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取影响行数失败: %v", err)
+	}
+	if affected == 0 {
+		return ErrNicknameNotPending
+	}
+	return nil
+}
+
+// exists 检查用户是否存在
+func (r *userRepo) exists(q Querier, userID string) (bool, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM user_info WHERE user_id = ?)", userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("检查用户是否存在失败: %v", err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,116 @@
+// Package server 独立运行的 HTTP 服务，负责超时设置、信号处理和优雅退出
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/logger"
+)
+
+// Server 带优雅退出的 HTTP 服务
+type Server struct {
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+
+	mu    sync.Mutex
+	hooks []func(ctx context.Context) error
+}
+
+// New 根据服务配置创建 HTTP 服务
+func New(cfg config.ServerConfig, h http.Handler) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:         ":" + cfg.Port,
+			Handler:      h,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+		},
+		shutdownTimeout: cfg.ShutdownTimeout,
+	}
+}
+
+// OnShutdown 注册退出时执行的回调，例如停止后台任务、关闭数据库连接
+// 回调在进行中的请求处理完成后按注册的逆序执行，先注册的资源最后释放
+func (s *Server) OnShutdown(hook func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook)
+}
+
+// Run 启动服务并阻塞，直到 ctx 取消或收到 SIGINT、SIGTERM 信号后优雅退出
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %v", s.httpServer.Addr, err)
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve 在指定的监听器上提供服务，退出流程与 Run 相同
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Infof("服务器启动，监听地址 %s", ln.Addr())
+		serveErr <- s.httpServer.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		// 服务异常退出时也执行退出回调，释放资源
+		s.runHooks(context.Background())
+		return fmt.Errorf("服务异常退出: %v", err)
+	case <-ctx.Done():
+	}
+
+	logger.Info("收到退出信号，等待进行中的请求完成")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	// Shutdown 先停止接受新连接，再等待进行中的请求（包括正在生成的发型）处理完成
+	err := s.httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		logger.WithError(err).Warn("等待请求完成超时，强制关闭连接")
+		s.httpServer.Close()
+	}
+	if serr := <-serveErr; serr != nil && !errors.Is(serr, http.ErrServerClosed) {
+		logger.WithError(serr).Warn("服务退出异常")
+	}
+
+	if hookErr := s.runHooks(shutdownCtx); hookErr != nil && err == nil {
+		err = hookErr
+	}
+	logger.Info("服务器已退出")
+	return err
+}
+
+// runHooks 按注册的逆序执行退出回调，返回第一个错误
+func (s *Server) runHooks(ctx context.Context) error {
+	s.mu.Lock()
+	hooks := s.hooks
+	s.hooks = nil
+	s.mu.Unlock()
+
+	var firstErr error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i](ctx); err != nil {
+			logger.WithError(err).Warn("执行退出回调失败")
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+)
+
+// startServer 在随机端口上启动服务，返回请求地址、用于退出的 cancel 和 Serve 的返回值
+func startServer(t *testing.T, s *Server) (string, context.CancelFunc, <-chan error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, ln) }()
+	return "http://" + ln.Addr().String(), cancel, done
+}
+
+// blockingHandler 收到请求后通知 started，等到 release 关闭后才返回
+func blockingHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// recorder 按执行顺序记录退出回调
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) hook(name string, err error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.calls = append(r.calls, name)
+		return err
+	}
+}
+
+func (r *recorder) names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func TestServeWaitsForInFlightRequests(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	s := New(config.ServerConfig{ShutdownTimeout: 5 * time.Second}, blockingHandler(started, release))
+	var hooks recorder
+	s.OnShutdown(hooks.hook("db", nil))
+	s.OnShutdown(hooks.hook("worker", nil))
+	url, cancel, done := startServer(t, s)
+
+	respErr := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				err = errors.New(resp.Status)
+			}
+		}
+		respErr <- err
+	}()
+	<-started
+
+	// 收到退出信号后等待进行中的请求完成，期间不执行退出回调
+	cancel()
+	select {
+	case err := <-done:
+		t.Fatalf("Serve() returned %v before the in-flight request finished", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if calls := hooks.names(); len(calls) != 0 {
+		t.Errorf("hooks ran before the in-flight request finished: %v", calls)
+	}
+
+	close(release)
+	if err := <-respErr; err != nil {
+		t.Errorf("in-flight request error = %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+
+	// 回调按注册的逆序执行，先注册的资源最后释放
+	if calls, want := hooks.names(), []string{"worker", "db"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("hooks = %v, want %v", calls, want)
+	}
+}
+
+func TestServeShutdownTimeout(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	s := New(config.ServerConfig{ShutdownTimeout: 50 * time.Millisecond}, blockingHandler(started, release))
+	var hooks recorder
+	s.OnShutdown(hooks.hook("db", nil))
+	url, cancel, done := startServer(t, s)
+
+	go func() {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	// 请求超时未完成时强制关闭连接，退出回调仍然执行
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Serve() error = %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve() did not return after the shutdown timeout")
+	}
+	if calls, want := hooks.names(), []string{"db"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("hooks = %v, want %v", calls, want)
+	}
+}
+
+func TestServeHookErrors(t *testing.T) {
+	s := New(config.ServerConfig{ShutdownTimeout: time.Second}, http.NotFoundHandler())
+	var hooks recorder
+	errFirst, errLast := errors.New("first"), errors.New("last")
+	s.OnShutdown(hooks.hook("a", errLast))
+	s.OnShutdown(hooks.hook("b", nil))
+	s.OnShutdown(hooks.hook("c", errFirst))
+	_, cancel, done := startServer(t, s)
+
+	// 某个回调失败不影响后续回调执行，返回最先出现的错误
+	cancel()
+	if err := <-done; !errors.Is(err, errFirst) {
+		t.Errorf("Serve() error = %v, want %v", err, errFirst)
+	}
+	if calls, want := hooks.names(), []string{"c", "b", "a"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("hooks = %v, want %v", calls, want)
+	}
+}
+
+func TestServeListenerError(t *testing.T) {
+	s := New(config.ServerConfig{ShutdownTimeout: time.Second}, http.NotFoundHandler())
+	var hooks recorder
+	s.OnShutdown(hooks.hook("db", nil))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.Close()
+
+	// 服务异常退出时返回错误，同样执行退出回调
+	if err := s.Serve(context.Background(), ln); err == nil {
+		t.Error("Serve() on a closed listener error = nil, want error")
+	}
+	if calls, want := hooks.names(), []string{"db"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("hooks = %v, want %v", calls, want)
+	}
+}
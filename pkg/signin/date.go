@@ -0,0 +1,72 @@
+package signin
+
+import (
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+)
+
+// dateLayout 签到日期格式
+const dateLayout = "2006-01-02"
+
+// Date 不含时区的日历日期
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// Today 返回业务时区的今天
+func Today() Date {
+	return DateOf(config.Now())
+}
+
+// DateIn 返回时间在指定时区的日历日期，例如 UTC 16:00 在北京时间已是次日
+func DateIn(t time.Time, loc *time.Location) Date {
+	return DateOf(t.In(loc))
+}
+
+// DateOf 返回时间在其所在时区的日历日期
+func DateOf(t time.Time) Date {
+	y, m, d := t.Date()
+	return Date{Year: y, Month: m, Day: d}
+}
+
+// ParseDate 解析 2006-01-02 格式的日期
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return Date{}, err
+	}
+	return DateOf(t), nil
+}
+
+// Time 返回该日期的零点（UTC），用于写入 DATE 类型字段
+func (d Date) Time() time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// AddDays 返回 n 天后的日期
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.Time().AddDate(0, 0, n))
+}
+
+// After 判断是否晚于另一个日期
+func (d Date) After(other Date) bool {
+	return d.Time().After(other.Time())
+}
+
+// Before 判断是否早于另一个日期
+func (d Date) Before(other Date) bool {
+	return d.Time().Before(other.Time())
+}
+
+// DaysSince 返回距另一个日期的天数
+func (d Date) DaysSince(other Date) int {
+	return int(d.Time().Sub(other.Time()).Hours() / 24)
+}
+
+// String 返回 2006-01-02 格式的日期
+func (d Date) String() string {
+	return d.Time().Format(dateLayout)
+}
@@ -0,0 +1,88 @@
+package signin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+)
+
+func TestTodayAtMidnight(t *testing.T) {
+	// 默认业务时区为 Asia/Shanghai（UTC+8）
+	tests := []struct {
+		now  string
+		want string
+	}{
+		{"2026-01-04T15:59:59Z", "2026-01-04"},
+		{"2026-01-04T16:00:00Z", "2026-01-05"},
+		{"2025-12-31T15:59:59Z", "2025-12-31"},
+		{"2025-12-31T16:00:00Z", "2026-01-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.now, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			restore := config.SetClock(func() time.Time { return now })
+			defer restore()
+
+			if got := Today().String(); got != tt.want {
+				t.Errorf("Today() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateInAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 按启动时的固定偏移计算会在夏令时切换后把零点前后一小时算错日期
+	tests := []struct {
+		name string
+		now  string
+		want string
+	}{
+		{"before spring forward midnight", "2026-03-08T04:59:59Z", "2026-03-07"},
+		{"spring forward midnight", "2026-03-08T05:00:00Z", "2026-03-08"},
+		{"after spring forward, before midnight", "2026-03-09T03:59:59Z", "2026-03-08"},
+		{"after spring forward, midnight", "2026-03-09T04:00:00Z", "2026-03-09"},
+		{"after fall back, before midnight", "2026-11-02T04:59:59Z", "2026-11-01"},
+		{"after fall back, midnight", "2026-11-02T05:00:00Z", "2026-11-02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := DateIn(now, ny).String(); got != tt.want {
+				t.Errorf("DateIn(%s) = %s, want %s", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateArithmetic(t *testing.T) {
+	d, err := ParseDate("2026-03-07")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 日期不含时区，跨夏令时切换和月末时仍按自然日计算
+	if got := d.AddDays(1).String(); got != "2026-03-08" {
+		t.Errorf("AddDays(1) = %s", got)
+	}
+	if got := d.AddDays(25).String(); got != "2026-04-01" {
+		t.Errorf("AddDays(25) = %s", got)
+	}
+	if got := d.AddDays(2).DaysSince(d); got != 2 {
+		t.Errorf("DaysSince = %d, want 2", got)
+	}
+	if !d.AddDays(1).After(d) || !d.Before(d.AddDays(1)) {
+		t.Error("After/Before order is wrong")
+	}
+}
@@ -0,0 +1,58 @@
+package signin
+
+import (
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+// defaultReward 未配置奖励时的每日签到奖励
+const defaultReward = 20
+
+// RewardForStreak 返回连续签到第 streak 天的奖励，超过配置长度后按周期循环
+func RewardForStreak(cfg config.SignInConfig, streak int) int {
+	if len(cfg.Rewards) == 0 || streak <= 0 {
+		return defaultReward
+	}
+	return cfg.Rewards[(streak-1)%len(cfg.Rewards)]
+}
+
+// RepairCardsForStreak 返回连续签到第 streak 天奖励的补签卡数量
+func RepairCardsForStreak(cfg config.SignInConfig, streak int) int {
+	if cfg.RepairCardInterval <= 0 || streak <= 0 || streak%cfg.RepairCardInterval != 0 {
+		return 0
+	}
+	return 1
+}
+
+// Upcoming 计算从 firstDate 起连续签到 days 天的奖励，firstStreak 为 firstDate 当天签到后的连续天数
+func Upcoming(cfg config.SignInConfig, firstDate Date, firstStreak, days int) []model.UpcomingReward {
+	rewards := make([]model.UpcomingReward, 0, days)
+	for i := 0; i < days; i++ {
+		streak := firstStreak + i
+		rewards = append(rewards, model.UpcomingReward{
+			Date:        firstDate.AddDays(i).String(),
+			Streak:      streak,
+			Reward:      RewardForStreak(cfg, streak),
+			RepairCards: RepairCardsForStreak(cfg, streak),
+		})
+	}
+	return rewards
+}
+
+// StreakEndingAt 根据按日期倒序排列的签到日期计算截至 end 的连续签到天数
+// end 当天未签到时返回0
+func StreakEndingAt(datesDesc []Date, end Date) int {
+	streak := 0
+	expected := end
+	for _, d := range datesDesc {
+		if d.After(expected) {
+			continue
+		}
+		if d != expected {
+			break
+		}
+		streak++
+		expected = expected.AddDays(-1)
+	}
+	return streak
+}
@@ -0,0 +1,72 @@
+package signin
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/config"
+	"github.com/MRsummer/ChangeHairStyle/pkg/model"
+)
+
+func TestRewardForStreak(t *testing.T) {
+	cfg := config.SignInConfig{Rewards: []int{5, 10, 20}, RepairCardInterval: 3}
+
+	// 超过配置长度后按周期循环，每满3天奖励一张补签卡
+	tests := []struct {
+		cfg             config.SignInConfig
+		streak          int
+		wantReward      int
+		wantRepairCards int
+	}{
+		{cfg, 1, 5, 0},
+		{cfg, 3, 20, 1},
+		{cfg, 4, 5, 0},
+		{cfg, 6, 20, 1},
+		{cfg, 0, defaultReward, 0},
+		{config.SignInConfig{}, 3, defaultReward, 0},
+	}
+	for _, tt := range tests {
+		if got := RewardForStreak(tt.cfg, tt.streak); got != tt.wantReward {
+			t.Errorf("RewardForStreak(%v, %d) = %d, want %d", tt.cfg.Rewards, tt.streak, got, tt.wantReward)
+		}
+		if got := RepairCardsForStreak(tt.cfg, tt.streak); got != tt.wantRepairCards {
+			t.Errorf("RepairCardsForStreak(%d, %d) = %d, want %d", tt.cfg.RepairCardInterval, tt.streak, got, tt.wantRepairCards)
+		}
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	cfg := config.SignInConfig{Rewards: []int{5, 10}, RepairCardInterval: 2}
+	first := Date{Year: 2026, Month: 12, Day: 31}
+
+	got := Upcoming(cfg, first, 3, 2)
+	want := []model.UpcomingReward{
+		{Date: "2026-12-31", Streak: 3, Reward: 5, RepairCards: 0},
+		{Date: "2027-01-01", Streak: 4, Reward: 10, RepairCards: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Upcoming() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStreakEndingAt(t *testing.T) {
+	end := Date{Year: 2026, Month: 10, Day: 19}
+
+	tests := []struct {
+		name  string
+		dates []Date
+		want  int
+	}{
+		{"empty", nil, 0},
+		{"end not signed", []Date{end.AddDays(-1), end.AddDays(-2)}, 0},
+		{"consecutive", []Date{end, end.AddDays(-1), end.AddDays(-2)}, 3},
+		{"gap", []Date{end, end.AddDays(-1), end.AddDays(-3)}, 2},
+		// 晚于 end 的签到不计入
+		{"after end", []Date{end.AddDays(1), end, end.AddDays(-1)}, 2},
+	}
+	for _, tt := range tests {
+		if got := StreakEndingAt(tt.dates, end); got != tt.want {
+			t.Errorf("%s: StreakEndingAt() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package tag
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTagsPerContent 每条广场内容最多的标签数
+	MaxTagsPerContent = 5
+	// MaxTagLength 单个标签的最大字符数
+	MaxTagLength = 20
+)
+
+// suggestRules 提示词关键词到标签的映射，按顺序匹配
+var suggestRules = []struct {
+	keywords []string
+	tag      string
+}{
+	{[]string{"短发", "波波头", "bob", "寸头", "pixie"}, "短发"},
+	{[]string{"长发", "及腰"}, "长发"},
+	{[]string{"中长发", "锁骨发", "lob"}, "中长发"},
+	{[]string{"卷发", "大波浪", "羊毛卷", "烫发", "curly", "wavy"}, "卷发"},
+	{[]string{"直发", "黑长直", "straight"}, "直发"},
+	{[]string{"刘海", "空气刘海", "八字刘海", "bangs"}, "刘海"},
+	{[]string{"染发", "挑染", "漂染", "金发", "棕色", "粉色", "银灰", "blonde"}, "染发"},
+	{[]string{"盘发", "丸子头", "发髻", "bun"}, "盘发"},
+	{[]string{"编发", "麻花辫", "辫子", "braid"}, "编发"},
+	{[]string{"马尾", "ponytail"}, "马尾"},
+	{[]string{"婚礼", "新娘", "婚纱", "wedding", "bridal"}, "婚礼造型"},
+	{[]string{"复古", "港风", "vintage", "retro"}, "复古"},
+	{[]string{"韩系", "韩式", "korean"}, "韩系"},
+	{[]string{"日系", "japanese"}, "日系"},
+	{[]string{"男士", "男生", "背头", "油头", "men"}, "男士发型"},
+}
+
+// Normalize 规范化用户输入的标签：去除 # 前缀和空白，去重，截断长度并限制数量
+func Normalize(tags []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, t := range tags {
+		t = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(t), "#＃"))
+		t = strings.Join(strings.Fields(t), "")
+		if t == "" {
+			continue
+		}
+		if utf8.RuneCountInString(t) > MaxTagLength {
+			t = string([]rune(t)[:MaxTagLength])
+		}
+		key := strings.ToLower(t)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, t)
+		if len(result) == MaxTagsPerContent {
+			break
+		}
+	}
+	return result
+}
+
+// Suggest 根据提示词推荐标签
+func Suggest(prompt string) []string {
+	lower := strings.ToLower(prompt)
+	var tags []string
+	for _, rule := range suggestRules {
+		for _, keyword := range rule.keywords {
+			if strings.Contains(lower, keyword) {
+				tags = append(tags, rule.tag)
+				break
+			}
+		}
+	}
+	return Normalize(tags)
+}
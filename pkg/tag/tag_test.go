@@ -0,0 +1,46 @@
+package tag
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{"empty", nil, nil},
+		{"hash prefix and spaces", []string{" #短发 ", "＃卷 发", "  "}, []string{"短发", "卷发"}},
+		// 去重不区分大小写，保留第一次出现的写法
+		{"duplicates", []string{"Bob", "bob", "#BOB"}, []string{"Bob"}},
+		{"too long", []string{strings.Repeat("长", MaxTagLength+5)}, []string{strings.Repeat("长", MaxTagLength)}},
+		{"too many", []string{"a", "b", "c", "d", "e", "f"}, []string{"a", "b", "c", "d", "e"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.tags); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		prompt string
+		want   []string
+	}{
+		{"黑长直加空气刘海", []string{"直发", "刘海"}},
+		{"Curly BOB with bangs", []string{"短发", "卷发", "刘海"}},
+		{"新娘盘发", []string{"盘发", "婚礼造型"}},
+		{"换个发型", nil},
+	}
+	for _, tt := range tests {
+		if got := Suggest(tt.prompt); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Suggest(%q) = %q, want %q", tt.prompt, got, tt.want)
+		}
+	}
+}
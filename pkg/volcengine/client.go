@@ -22,6 +22,7 @@ import (
         "encoding/base64"
         "encoding/hex"
         "encoding/json"
+        "errors"
         "fmt"
         "image"
         _ "image/jpeg"
@@ -35,6 +36,9 @@ import (
         "time"
 )
 
+// ErrRateLimited 火山引擎接口返回429，调用方可稍后重试
+var ErrRateLimited = errors.New("火山引擎接口限流")
+
 // Client 火山引擎API客户端
 type Client struct {
         AccessKeyID     string
@@ -217,6 +221,9 @@ func (c *Client) GenerateHairStyle(imageURL string, prompt string) (string, erro
                 return "", fmt.Errorf("请求失败: %v", err)
         }
 
+        if statusCode == http.StatusTooManyRequests {
+                return "", ErrRateLimited
+        }
         if statusCode != 200 {
                 return "", fmt.Errorf("API返回错误状态码: %d", statusCode)
         }
@@ -297,6 +304,9 @@ func (c *Client) GenerateHairStyleWithBase64(base64Image string, prompt string)
                 return "", fmt.Errorf("请求失败: %v", err)
         }
 
+        if statusCode == http.StatusTooManyRequests {
+                return "", ErrRateLimited
+        }
         if statusCode != 200 {
                 return "", fmt.Errorf("API返回错误状态码: %d", statusCode)
         }
@@ -0,0 +1,197 @@
+package wechat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultBaseURL 微信服务端接口地址
+const DefaultBaseURL = "https://api.weixin.qq.com"
+
+// 微信接口错误码
+const (
+	ErrCodeInvalidToken      = 40001 // access_token 无效
+	ErrCodeTokenExpired      = 42001 // access_token 过期
+	ErrCodeUserRefused       = 43101 // 用户拒绝接受消息
+	ErrCodeInvalidTemplateID = 40037 // 模板ID无效
+)
+
+// Error 微信接口返回的业务错误
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("微信接口错误: errcode=%d, errmsg=%s", e.Code, e.Message)
+}
+
+// Session 小程序登录凭证校验结果
+type Session struct {
+	OpenID     string `json:"openid"`
+	SessionKey string `json:"session_key"`
+	UnionID    string `json:"unionid"`
+}
+
+// AccessToken 接口调用凭证
+type AccessToken struct {
+	Token     string
+	ExpiresIn time.Duration
+}
+
+// DataItem 订阅消息模板字段
+type DataItem struct {
+	Value string `json:"value"`
+}
+
+// SubscribeMessage 小程序订阅消息
+type SubscribeMessage struct {
+	ToUser           string              `json:"touser"`
+	TemplateID       string              `json:"template_id"`
+	Page             string              `json:"page,omitempty"`
+	MiniprogramState string              `json:"miniprogram_state,omitempty"`
+	Lang             string              `json:"lang,omitempty"`
+	Data             map[string]DataItem `json:"data"`
+}
+
+// API 微信服务端接口，本地测试时可替换为指向假服务器的实现
+type API interface {
+	// Code2Session 用登录 code 换取 openid
+	Code2Session(ctx context.Context, code string) (*Session, error)
+	// GetStableAccessToken 获取稳定版 access_token，forceRefresh 为 true 时强制刷新
+	GetStableAccessToken(ctx context.Context, forceRefresh bool) (*AccessToken, error)
+	// SendSubscribeMessage 发送订阅消息
+	SendSubscribeMessage(ctx context.Context, accessToken string, msg *SubscribeMessage) error
+}
+
+// HTTPAPI 基于 HTTP 的微信接口实现
+type HTTPAPI struct {
+	BaseURL    string
+	AppID      string
+	Secret     string
+	HTTPClient *http.Client
+}
+
+// NewHTTPAPI 创建微信接口实现，baseURL 为空时使用微信正式地址
+func NewHTTPAPI(baseURL, appID, secret string) *HTTPAPI {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &HTTPAPI{
+		BaseURL:    baseURL,
+		AppID:      appID,
+		Secret:     secret,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// errorResponse 微信接口通用错误字段
+type errorResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (r *errorResponse) err() error {
+	if r.ErrCode == 0 {
+		return nil
+	}
+	return &Error{Code: r.ErrCode, Message: r.ErrMsg}
+}
+
+// Code2Session 用登录 code 换取 openid
+func (a *HTTPAPI) Code2Session(ctx context.Context, code string) (*Session, error) {
+	query := url.Values{}
+	query.Set("appid", a.AppID)
+	query.Set("secret", a.Secret)
+	query.Set("js_code", code)
+	query.Set("grant_type", "authorization_code")
+
+	var resp struct {
+		Session
+		errorResponse
+	}
+	if err := a.do(ctx, http.MethodGet, "/sns/jscode2session?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+	return &resp.Session, nil
+}
+
+// GetStableAccessToken 获取稳定版 access_token，多实例并发获取时不会互相失效
+func (a *HTTPAPI) GetStableAccessToken(ctx context.Context, forceRefresh bool) (*AccessToken, error) {
+	body := map[string]interface{}{
+		"grant_type":    "client_credential",
+		"appid":         a.AppID,
+		"secret":        a.Secret,
+		"force_refresh": forceRefresh,
+	}
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		errorResponse
+	}
+	if err := a.do(ctx, http.MethodPost, "/cgi-bin/stable_token", body, &resp); err != nil {
+		return nil, err
+	}
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+	return &AccessToken{
+		Token:     resp.AccessToken,
+		ExpiresIn: time.Duration(resp.ExpiresIn) * time.Second,
+	}, nil
+}
+
+// SendSubscribeMessage 发送订阅消息
+func (a *HTTPAPI) SendSubscribeMessage(ctx context.Context, accessToken string, msg *SubscribeMessage) error {
+	path := "/cgi-bin/message/subscribe/send?access_token=" + url.QueryEscape(accessToken)
+
+	var resp errorResponse
+	if err := a.do(ctx, http.MethodPost, path, msg, &resp); err != nil {
+		return err
+	}
+	return resp.err()
+}
+
+// do 发送请求并解析 JSON 响应
+func (a *HTTPAPI) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.BaseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("调用微信接口失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("微信接口返回错误状态码: %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析微信接口响应失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package wechat
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// tokenRefreshAhead 提前刷新 access_token 的时间
+const tokenRefreshAhead = 5 * time.Minute
+
+// Client 微信客户端，缓存 access_token 并在失效时自动刷新
+type Client struct {
+	api API
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+	now       func() time.Time
+}
+
+// NewClient 创建微信客户端
+func NewClient(api API) *Client {
+	return &Client{
+		api: api,
+		now: time.Now,
+	}
+}
+
+// NewClientFromEnv 根据环境变量创建微信客户端
+// WX_API_BASE_URL 可指向本地假服务器，便于联调
+func NewClientFromEnv() *Client {
+	return NewClient(NewHTTPAPI(
+		os.Getenv("WX_API_BASE_URL"),
+		os.Getenv("WX_APP_ID"),
+		os.Getenv("WX_APP_SECRET"),
+	))
+}
+
+// Code2Session 用登录 code 换取 openid
+func (c *Client) Code2Session(ctx context.Context, code string) (*Session, error) {
+	return c.api.Code2Session(ctx, code)
+}
+
+// AccessToken 获取缓存的 access_token，即将过期时刷新
+func (c *Client) AccessToken(ctx context.Context) (string, error) {
+	return c.accessToken(ctx, false)
+}
+
+func (c *Client) accessToken(ctx context.Context, forceRefresh bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !forceRefresh && c.token != "" && c.now().Before(c.expiresAt) {
+		return c.token, nil
+	}
+
+	token, err := c.api.GetStableAccessToken(ctx, forceRefresh)
+	if err != nil {
+		return "", err
+	}
+	c.token = token.Token
+	c.expiresAt = c.now().Add(token.ExpiresIn - tokenRefreshAhead)
+	return c.token, nil
+}
+
+// SendSubscribeMessage 发送订阅消息，access_token 失效时刷新后重试一次
+func (c *Client) SendSubscribeMessage(ctx context.Context, msg *SubscribeMessage) error {
+	token, err := c.AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.api.SendSubscribeMessage(ctx, token, msg)
+	var wxErr *Error
+	if errors.As(err, &wxErr) && (wxErr.Code == ErrCodeInvalidToken || wxErr.Code == ErrCodeTokenExpired) {
+		if token, err = c.accessToken(ctx, true); err != nil {
+			return err
+		}
+		err = c.api.SendSubscribeMessage(ctx, token, msg)
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package wechat_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/wechat"
+	"github.com/MRsummer/ChangeHairStyle/pkg/wechat/wechattest"
+)
+
+// newMessage 创建测试用的订阅消息
+func newMessage() *wechat.SubscribeMessage {
+	return &wechat.SubscribeMessage{
+		ToUser:           "openid-1",
+		TemplateID:       "tpl-generation-done",
+		Page:             "pages/record/index?id=1",
+		MiniprogramState: "formal",
+		Lang:             "zh_CN",
+		Data: map[string]wechat.DataItem{
+			"thing1": {Value: "发型生成完成"},
+			"time2":  {Value: "2026-10-19 16:00"},
+		},
+	}
+}
+
+func TestSendSubscribeMessage(t *testing.T) {
+	srv := wechattest.NewServer()
+	defer srv.Close()
+	client := wechat.NewClient(srv.API())
+
+	for i := 0; i < 2; i++ {
+		if err := client.SendSubscribeMessage(context.Background(), newMessage()); err != nil {
+			t.Fatalf("SendSubscribeMessage() error = %v", err)
+		}
+	}
+
+	// access_token 在有效期内复用
+	if n := srv.TokenCount(); n != 1 {
+		t.Errorf("token count = %d, want 1", n)
+	}
+	messages := srv.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("messages = %d, want 2", len(messages))
+	}
+	if want := *newMessage(); !reflect.DeepEqual(messages[0], want) {
+		t.Errorf("message = %+v, want %+v", messages[0], want)
+	}
+}
+
+func TestSendSubscribeMessageRefreshesToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		code           int
+		wantErr        bool
+		wantTokenCount int
+		wantMessages   int
+	}{
+		{"invalid token", wechat.ErrCodeInvalidToken, false, 2, 1},
+		{"token expired", wechat.ErrCodeTokenExpired, false, 2, 1},
+		// 其他错误不刷新也不重试
+		{"user refused", wechat.ErrCodeUserRefused, true, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := wechattest.NewServer()
+			defer srv.Close()
+			client := wechat.NewClient(srv.API())
+
+			srv.FailNextSend(tt.code, "test error")
+			err := client.SendSubscribeMessage(context.Background(), newMessage())
+
+			var wxErr *wechat.Error
+			if tt.wantErr != (err != nil) || (tt.wantErr && (!errors.As(err, &wxErr) || wxErr.Code != tt.code)) {
+				t.Fatalf("SendSubscribeMessage() error = %v, want error %v with code %d", err, tt.wantErr, tt.code)
+			}
+			if n := srv.TokenCount(); n != tt.wantTokenCount {
+				t.Errorf("token count = %d, want %d", n, tt.wantTokenCount)
+			}
+			if n := len(srv.Messages()); n != tt.wantMessages {
+				t.Errorf("messages = %d, want %d", n, tt.wantMessages)
+			}
+		})
+	}
+}
+
+func TestCode2Session(t *testing.T) {
+	srv := wechattest.NewServer()
+	defer srv.Close()
+	srv.SetOpenID("code-1", "openid-1")
+	client := wechat.NewClient(srv.API())
+
+	session, err := client.Code2Session(context.Background(), "code-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.OpenID != "openid-1" {
+		t.Errorf("openid = %q, want openid-1", session.OpenID)
+	}
+
+	var wxErr *wechat.Error
+	if _, err := client.Code2Session(context.Background(), "unknown"); !errors.As(err, &wxErr) || wxErr.Code != 40029 {
+		t.Errorf("Code2Session() with unknown code error = %v, want errcode 40029", err)
+	}
+}
@@ -0,0 +1,117 @@
+// Package wechattest 提供本地假微信服务器，用于在测试和联调中校验发送给微信的请求
+package wechattest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/wechat"
+)
+
+// Server 假微信服务器，记录收到的订阅消息
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	tokenCount int
+	messages   []wechat.SubscribeMessage
+	sendErr    *wechat.Error
+	openIDs    map[string]string
+}
+
+// NewServer 启动假微信服务器，使用完毕后需调用 Close
+func NewServer() *Server {
+	s := &Server{openIDs: make(map[string]string)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sns/jscode2session", s.handleCode2Session)
+	mux.HandleFunc("/cgi-bin/stable_token", s.handleStableToken)
+	mux.HandleFunc("/cgi-bin/message/subscribe/send", s.handleSend)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// API 返回指向假服务器的微信接口实现
+func (s *Server) API() *wechat.HTTPAPI {
+	return wechat.NewHTTPAPI(s.URL, "test-appid", "test-secret")
+}
+
+// SetOpenID 设置登录 code 对应的 openid
+func (s *Server) SetOpenID(code, openID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.openIDs[code] = openID
+}
+
+// FailNextSend 让下一次发送订阅消息返回指定错误
+func (s *Server) FailNextSend(code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sendErr = &wechat.Error{Code: code, Message: message}
+}
+
+// Messages 返回收到的订阅消息
+func (s *Server) Messages() []wechat.SubscribeMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]wechat.SubscribeMessage(nil), s.messages...)
+}
+
+// TokenCount 返回签发 access_token 的次数
+func (s *Server) TokenCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenCount
+}
+
+func (s *Server) handleCode2Session(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	openID, ok := s.openIDs[r.URL.Query().Get("js_code")]
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, map[string]interface{}{"errcode": 40029, "errmsg": "invalid code"})
+		return
+	}
+	writeJSON(w, map[string]interface{}{"openid": openID, "session_key": "test-session-key"})
+}
+
+func (s *Server) handleStableToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.tokenCount++
+	token := fmt.Sprintf("test-token-%d", s.tokenCount)
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]interface{}{"access_token": token, "expires_in": 7200})
+}
+
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	var msg wechat.SubscribeMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		writeJSON(w, map[string]interface{}{"errcode": 47001, "errmsg": "data format error"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sendErr != nil {
+		sendErr := s.sendErr
+		s.sendErr = nil
+		writeJSON(w, map[string]interface{}{"errcode": sendErr.Code, "errmsg": sendErr.Message})
+		return
+	}
+	if r.URL.Query().Get("access_token") == "" {
+		writeJSON(w, map[string]interface{}{"errcode": wechat.ErrCodeInvalidToken, "errmsg": "invalid credential"})
+		return
+	}
+	s.messages = append(s.messages, msg)
+	writeJSON(w, map[string]interface{}{"errcode": 0, "errmsg": "ok"})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
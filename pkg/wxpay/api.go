@@ -0,0 +1,252 @@
+package wxpay
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultBaseURL 微信支付接口地址
+const DefaultBaseURL = "https://api.mch.weixin.qq.com"
+
+// 交易状态
+const (
+	TradeStateSuccess    = "SUCCESS"    // 支付成功
+	TradeStateRefund     = "REFUND"     // 转入退款
+	TradeStateNotPay     = "NOTPAY"     // 未支付
+	TradeStateClosed     = "CLOSED"     // 已关闭
+	TradeStateRevoked    = "REVOKED"    // 已撤销
+	TradeStateUserPaying = "USERPAYING" // 用户支付中
+	TradeStatePayError   = "PAYERROR"   // 支付失败
+)
+
+// 退款状态
+const (
+	RefundStatusSuccess    = "SUCCESS"    // 退款成功
+	RefundStatusClosed     = "CLOSED"     // 退款关闭
+	RefundStatusProcessing = "PROCESSING" // 退款处理中
+	RefundStatusAbnormal   = "ABNORMAL"   // 退款异常
+)
+
+// Error 微信支付接口返回的业务错误
+type Error struct {
+	StatusCode int
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("微信支付接口错误: status=%d, code=%s, message=%s", e.StatusCode, e.Code, e.Message)
+}
+
+// Amount 订单金额，单位为分
+type Amount struct {
+	Total      int    `json:"total"`
+	PayerTotal int    `json:"payer_total,omitempty"`
+	Currency   string `json:"currency,omitempty"`
+}
+
+// Payer 支付者
+type Payer struct {
+	OpenID string `json:"openid"`
+}
+
+// PrepayRequest JSAPI 下单请求
+type PrepayRequest struct {
+	AppID       string `json:"appid"`
+	MchID       string `json:"mchid"`
+	Description string `json:"description"`
+	OutTradeNo  string `json:"out_trade_no"`
+	TimeExpire  string `json:"time_expire,omitempty"`
+	NotifyURL   string `json:"notify_url"`
+	Amount      Amount `json:"amount"`
+	Payer       Payer  `json:"payer"`
+}
+
+// Transaction 支付订单
+type Transaction struct {
+	AppID          string `json:"appid"`
+	MchID          string `json:"mchid"`
+	OutTradeNo     string `json:"out_trade_no"`
+	TransactionID  string `json:"transaction_id"`
+	TradeType      string `json:"trade_type"`
+	TradeState     string `json:"trade_state"`
+	TradeStateDesc string `json:"trade_state_desc"`
+	SuccessTime    string `json:"success_time"`
+	Payer          Payer  `json:"payer"`
+	Amount         Amount `json:"amount"`
+}
+
+// RefundAmount 退款金额，单位为分
+type RefundAmount struct {
+	Refund   int    `json:"refund"`
+	Total    int    `json:"total"`
+	Currency string `json:"currency"`
+}
+
+// RefundRequest 申请退款请求
+type RefundRequest struct {
+	OutTradeNo  string       `json:"out_trade_no"`
+	OutRefundNo string       `json:"out_refund_no"`
+	Reason      string       `json:"reason,omitempty"`
+	NotifyURL   string       `json:"notify_url,omitempty"`
+	Amount      RefundAmount `json:"amount"`
+}
+
+// Refund 退款单
+type Refund struct {
+	RefundID    string       `json:"refund_id"`
+	OutRefundNo string       `json:"out_refund_no"`
+	OutTradeNo  string       `json:"out_trade_no"`
+	Status      string       `json:"status"`
+	SuccessTime string       `json:"success_time"`
+	Amount      RefundAmount `json:"amount"`
+}
+
+// API 微信支付接口，本地测试时可替换为指向假服务器的实现
+type API interface {
+	// PrepayJSAPI JSAPI/小程序下单，返回预支付交易会话标识
+	PrepayJSAPI(ctx context.Context, req *PrepayRequest) (string, error)
+	// QueryOrder 按商户订单号查询订单
+	QueryOrder(ctx context.Context, mchID, outTradeNo string) (*Transaction, error)
+	// Refund 申请退款
+	Refund(ctx context.Context, req *RefundRequest) (*Refund, error)
+}
+
+// HTTPAPI 基于 HTTP 的微信支付 APIv3 实现，请求使用商户私钥签名，应答使用平台公钥验签
+type HTTPAPI struct {
+	BaseURL     string
+	MchID       string
+	SerialNo    string // 商户API证书序列号
+	PrivateKey  *rsa.PrivateKey
+	PlatformKey *rsa.PublicKey
+	HTTPClient  *http.Client
+	now         func() time.Time
+}
+
+// NewHTTPAPI 创建微信支付接口实现，baseURL 为空时使用微信支付正式地址
+func NewHTTPAPI(baseURL, mchID, serialNo string, privateKey *rsa.PrivateKey, platformKey *rsa.PublicKey) *HTTPAPI {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &HTTPAPI{
+		BaseURL:     baseURL,
+		MchID:       mchID,
+		SerialNo:    serialNo,
+		PrivateKey:  privateKey,
+		PlatformKey: platformKey,
+		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+		now:         time.Now,
+	}
+}
+
+// PrepayJSAPI JSAPI/小程序下单
+func (a *HTTPAPI) PrepayJSAPI(ctx context.Context, req *PrepayRequest) (string, error) {
+	var resp struct {
+		PrepayID string `json:"prepay_id"`
+	}
+	if err := a.do(ctx, http.MethodPost, "/v3/pay/transactions/jsapi", req, &resp); err != nil {
+		return "", err
+	}
+	return resp.PrepayID, nil
+}
+
+// QueryOrder 按商户订单号查询订单
+func (a *HTTPAPI) QueryOrder(ctx context.Context, mchID, outTradeNo string) (*Transaction, error) {
+	path := "/v3/pay/transactions/out-trade-no/" + url.PathEscape(outTradeNo) + "?mchid=" + url.QueryEscape(mchID)
+
+	var resp Transaction
+	if err := a.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Refund 申请退款
+func (a *HTTPAPI) Refund(ctx context.Context, req *RefundRequest) (*Refund, error) {
+	var resp Refund
+	if err := a.do(ctx, http.MethodPost, "/v3/refund/domestic/refunds", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do 签名并发送请求，校验应答签名后解析 JSON 响应
+func (a *HTTPAPI) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var data []byte
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.BaseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	authorization, err := a.authorization(method, path, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("调用微信支付接口失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取微信支付接口响应失败: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		json.Unmarshal(respBody, apiErr)
+		return apiErr
+	}
+
+	if a.PlatformKey != nil {
+		if err := VerifyHeaders(a.PlatformKey, resp.Header, respBody, a.now()); err != nil {
+			return fmt.Errorf("微信支付应答验签失败: %v", err)
+		}
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("解析微信支付接口响应失败: %v", err)
+	}
+	return nil
+}
+
+// authorization 生成 WECHATPAY2-SHA256-RSA2048 认证头
+func (a *HTTPAPI) authorization(method, path string, body []byte) (string, error) {
+	timestamp := strconv.FormatInt(a.now().Unix(), 10)
+	nonce, err := NewNonce()
+	if err != nil {
+		return "", err
+	}
+
+	message := method + "\n" + path + "\n" + timestamp + "\n" + nonce + "\n" + string(body) + "\n"
+	signature, err := Sign(a.PrivateKey, message)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`WECHATPAY2-SHA256-RSA2048 mchid="%s",nonce_str="%s",signature="%s",timestamp="%s",serial_no="%s"`,
+		a.MchID, nonce, signature, timestamp, a.SerialNo), nil
+}
@@ -0,0 +1,133 @@
+package wxpay
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ErrNotConfigured 未配置微信支付商户信息
+var ErrNotConfigured = errors.New("支付功能未开启")
+
+// Config 商户配置
+type Config struct {
+	AppID       string
+	MchID       string
+	APIv3Key    string
+	NotifyURL   string // 支付和退款结果回调地址
+	PlatformKey *rsa.PublicKey
+	PrivateKey  *rsa.PrivateKey
+}
+
+// PayParams 小程序调起支付所需的参数，字段名与 wx.requestPayment 一致
+type PayParams struct {
+	AppID     string `json:"appId"`
+	TimeStamp string `json:"timeStamp"`
+	NonceStr  string `json:"nonceStr"`
+	Package   string `json:"package"`
+	SignType  string `json:"signType"`
+	PaySign   string `json:"paySign"`
+}
+
+// Client 微信支付客户端
+type Client struct {
+	api API
+	cfg Config
+	now func() time.Time
+}
+
+// NewClient 创建微信支付客户端
+func NewClient(api API, cfg Config) *Client {
+	return &Client{
+		api: api,
+		cfg: cfg,
+		now: time.Now,
+	}
+}
+
+// NewClientFromEnv 根据环境变量创建微信支付客户端，未配置商户号时返回 ErrNotConfigured
+// WXPAY_API_BASE_URL 可指向本地假服务器，便于联调
+func NewClientFromEnv() (*Client, error) {
+	mchID := os.Getenv("WXPAY_MCH_ID")
+	if mchID == "" {
+		return nil, ErrNotConfigured
+	}
+
+	privateKey, err := ParsePrivateKey(os.Getenv("WXPAY_PRIVATE_KEY"))
+	if err != nil {
+		return nil, err
+	}
+	platformKey, err := ParsePublicKey(os.Getenv("WXPAY_PLATFORM_PUBLIC_KEY"))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := Config{
+		AppID:       os.Getenv("WX_APP_ID"),
+		MchID:       mchID,
+		APIv3Key:    os.Getenv("WXPAY_API_V3_KEY"),
+		NotifyURL:   os.Getenv("WXPAY_NOTIFY_URL"),
+		PlatformKey: platformKey,
+		PrivateKey:  privateKey,
+	}
+	api := NewHTTPAPI(os.Getenv("WXPAY_API_BASE_URL"), mchID, os.Getenv("WXPAY_MCH_SERIAL_NO"), privateKey, platformKey)
+	return NewClient(api, cfg), nil
+}
+
+// Prepay 下单并生成小程序调起支付的参数
+func (c *Client) Prepay(ctx context.Context, outTradeNo, description, openID string, total int, expireAt time.Time) (*PayParams, error) {
+	prepayID, err := c.api.PrepayJSAPI(ctx, &PrepayRequest{
+		AppID:       c.cfg.AppID,
+		MchID:       c.cfg.MchID,
+		Description: description,
+		OutTradeNo:  outTradeNo,
+		TimeExpire:  expireAt.Format(time.RFC3339),
+		NotifyURL:   c.cfg.NotifyURL,
+		Amount:      Amount{Total: total, Currency: "CNY"},
+		Payer:       Payer{OpenID: openID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.PayParams(prepayID)
+}
+
+// PayParams 根据预支付交易会话标识生成小程序调起支付的参数
+func (c *Client) PayParams(prepayID string) (*PayParams, error) {
+	nonce, err := NewNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	params := &PayParams{
+		AppID:     c.cfg.AppID,
+		TimeStamp: strconv.FormatInt(c.now().Unix(), 10),
+		NonceStr:  nonce,
+		Package:   "prepay_id=" + prepayID,
+		SignType:  "RSA",
+	}
+	params.PaySign, err = Sign(c.cfg.PrivateKey, params.AppID+"\n"+params.TimeStamp+"\n"+params.NonceStr+"\n"+params.Package+"\n")
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// QueryOrder 按商户订单号查询订单
+func (c *Client) QueryOrder(ctx context.Context, outTradeNo string) (*Transaction, error) {
+	return c.api.QueryOrder(ctx, c.cfg.MchID, outTradeNo)
+}
+
+// Refund 申请退款，金额单位为分
+func (c *Client) Refund(ctx context.Context, outTradeNo, outRefundNo, reason string, refund, total int) (*Refund, error) {
+	return c.api.Refund(ctx, &RefundRequest{
+		OutTradeNo:  outTradeNo,
+		OutRefundNo: outRefundNo,
+		Reason:      reason,
+		NotifyURL:   c.cfg.NotifyURL,
+		Amount:      RefundAmount{Refund: refund, Total: total, Currency: "CNY"},
+	})
+}
@@ -0,0 +1,125 @@
+package wxpay_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/wxpay"
+	"github.com/MRsummer/ChangeHairStyle/pkg/wxpay/wxpaytest"
+)
+
+// newServer 启动假微信支付服务器，测试结束时关闭
+func newServer(t *testing.T) *wxpaytest.Server {
+	t.Helper()
+	srv, err := wxpaytest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPrepay(t *testing.T) {
+	srv := newServer(t)
+	cfg := srv.Config()
+
+	params, err := srv.Client().Prepay(context.Background(), "PAY1", "60金币", "openid-1", 600, time.Now().Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("Prepay() error = %v", err)
+	}
+	if params.AppID != wxpaytest.AppID || params.Package != "prepay_id=wx-prepay-PAY1" || params.SignType != "RSA" {
+		t.Errorf("pay params = %+v", params)
+	}
+
+	// 小程序调起支付的签名使用商户私钥，按 appId、timeStamp、nonceStr、package 逐行拼接
+	message := params.AppID + "\n" + params.TimeStamp + "\n" + params.NonceStr + "\n" + params.Package + "\n"
+	if err := wxpay.Verify(&cfg.PrivateKey.PublicKey, message, params.PaySign); err != nil {
+		t.Errorf("paySign does not verify: %v", err)
+	}
+
+	order, ok := srv.Order("PAY1")
+	if !ok {
+		t.Fatal("order not created on the server")
+	}
+	if order.Amount.Total != 600 || order.Payer.OpenID != "openid-1" || order.MchID != wxpaytest.MchID || order.TradeState != wxpay.TradeStateNotPay {
+		t.Errorf("order = %+v", order)
+	}
+}
+
+func TestPrepayRejectsBadSignatures(t *testing.T) {
+	srv := newServer(t)
+	cfg := srv.Config()
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 商户私钥与服务器登记的不一致，请求签名被拒绝
+	api := wxpay.NewHTTPAPI(srv.URL, wxpaytest.MchID, wxpaytest.MerchantSerial, otherKey, cfg.PlatformKey)
+	_, err = wxpay.NewClient(api, cfg).Prepay(context.Background(), "PAY1", "60金币", "openid-1", 600, time.Now().Add(30*time.Minute))
+	var apiErr *wxpay.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Code != "SIGN_ERROR" {
+		t.Errorf("Prepay() with wrong merchant key error = %v, want SIGN_ERROR", err)
+	}
+
+	// 平台公钥不一致时应答验签失败
+	api = wxpay.NewHTTPAPI(srv.URL, wxpaytest.MchID, wxpaytest.MerchantSerial, cfg.PrivateKey, &otherKey.PublicKey)
+	_, err = wxpay.NewClient(api, cfg).Prepay(context.Background(), "PAY2", "60金币", "openid-1", 600, time.Now().Add(30*time.Minute))
+	if err == nil || errors.As(err, &apiErr) {
+		t.Errorf("Prepay() with wrong platform key error = %v, want response signature error", err)
+	}
+}
+
+func TestParseNotification(t *testing.T) {
+	srv := newServer(t)
+	client := srv.Client()
+	if _, err := client.Prepay(context.Background(), "PAY1", "60金币", "openid-1", 600, time.Now().Add(30*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	header, body, err := srv.Pay("PAY1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notification, err := client.ParseNotification(header, body)
+	if err != nil {
+		t.Fatalf("ParseNotification() error = %v", err)
+	}
+	var transaction wxpay.Transaction
+	if err := client.DecodeResource(notification, &transaction); err != nil {
+		t.Fatal(err)
+	}
+	if notification.EventType != wxpay.EventTransactionSuccess || transaction.OutTradeNo != "PAY1" ||
+		transaction.TradeState != wxpay.TradeStateSuccess || transaction.Amount.Total != 600 {
+		t.Errorf("notification = %+v, transaction = %+v", notification, transaction)
+	}
+
+	tampered := append([]byte(nil), body...)
+	tampered[len(tampered)-2] = ' '
+	stale := header.Clone()
+	stale.Set(wxpay.HeaderTimestamp, "1600000000")
+	unsigned := header.Clone()
+	unsigned.Del(wxpay.HeaderSignature)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+	}{
+		{"tampered body", header, tampered},
+		{"stale timestamp", stale, body},
+		{"missing signature", unsigned, body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.ParseNotification(tt.header, tt.body); !errors.Is(err, wxpay.ErrInvalidSignature) {
+				t.Errorf("ParseNotification() error = %v, want %v", err, wxpay.ErrInvalidSignature)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package wxpay
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 微信支付签名相关的请求头
+const (
+	HeaderSignature = "Wechatpay-Signature"
+	HeaderTimestamp = "Wechatpay-Timestamp"
+	HeaderNonce     = "Wechatpay-Nonce"
+	HeaderSerial    = "Wechatpay-Serial"
+)
+
+// maxSignatureAge 回调和响应签名时间戳允许的最大偏差，防止重放
+const maxSignatureAge = 5 * time.Minute
+
+// ErrInvalidSignature 签名校验失败
+var ErrInvalidSignature = errors.New("微信支付签名校验失败")
+
+// ParsePrivateKey 解析 PEM 格式的商户私钥，支持 PKCS#1 和 PKCS#8
+func ParsePrivateKey(pemData string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return nil, fmt.Errorf("解析商户私钥失败: 不是有效的PEM格式")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析商户私钥失败: %v", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("解析商户私钥失败: 不是RSA私钥")
+	}
+	return rsaKey, nil
+}
+
+// ParsePublicKey 解析 PEM 格式的微信支付平台公钥，支持公钥和平台证书
+func ParsePublicKey(pemData string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemData))
+	if block == nil {
+		return nil, fmt.Errorf("解析平台公钥失败: 不是有效的PEM格式")
+	}
+
+	var key interface{}
+	var err error
+	if block.Type == "CERTIFICATE" {
+		var cert *x509.Certificate
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			key = cert.PublicKey
+		}
+	} else {
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析平台公钥失败: %v", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("解析平台公钥失败: 不是RSA公钥")
+	}
+	return rsaKey, nil
+}
+
+// Sign 使用 SHA256-RSA 对消息签名，返回 base64 编码的签名
+func Sign(key *rsa.PrivateKey, message string) (string, error) {
+	hashed := sha256.Sum256([]byte(message))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", fmt.Errorf("签名失败: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// Verify 校验 SHA256-RSA 签名
+func Verify(key *rsa.PublicKey, message, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	hashed := sha256.Sum256([]byte(message))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], sig); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// SignHeaders 生成应答或回调的签名请求头，供假服务器使用
+func SignHeaders(key *rsa.PrivateKey, serial string, body []byte, now time.Time) (http.Header, error) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	nonce, err := NewNonce()
+	if err != nil {
+		return nil, err
+	}
+	signature, err := Sign(key, timestamp+"\n"+nonce+"\n"+string(body)+"\n")
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set(HeaderTimestamp, timestamp)
+	header.Set(HeaderNonce, nonce)
+	header.Set(HeaderSignature, signature)
+	header.Set(HeaderSerial, serial)
+	return header, nil
+}
+
+// VerifyHeaders 校验微信支付应答或回调的签名，并检查时间戳是否过期
+func VerifyHeaders(key *rsa.PublicKey, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get(HeaderTimestamp)
+	nonce := header.Get(HeaderNonce)
+	signature := header.Get(HeaderSignature)
+	if timestamp == "" || nonce == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > maxSignatureAge || age < -maxSignatureAge {
+		return fmt.Errorf("%w: 时间戳已过期", ErrInvalidSignature)
+	}
+
+	return Verify(key, timestamp+"\n"+nonce+"\n"+string(body)+"\n", signature)
+}
+
+// DecryptResource 使用 APIv3 密钥解密回调通知中的资源数据
+func DecryptResource(apiV3Key string, resource *Resource) ([]byte, error) {
+	if resource.Algorithm != "AEAD_AES_256_GCM" {
+		return nil, fmt.Errorf("不支持的加密算法: %s", resource.Algorithm)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(resource.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("解码回调密文失败: %v", err)
+	}
+
+	gcm, err := newGCM(apiV3Key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, []byte(resource.Nonce), ciphertext, []byte(resource.AssociatedData))
+	if err != nil {
+		return nil, fmt.Errorf("解密回调数据失败: %v", err)
+	}
+	return plaintext, nil
+}
+
+// EncryptResource 使用 APIv3 密钥加密资源数据，供假服务器构造回调通知
+func EncryptResource(apiV3Key, originalType, associatedData string, plaintext []byte) (*Resource, error) {
+	gcm, err := newGCM(apiV3Key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := randomString(12)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := gcm.Seal(nil, []byte(nonce), plaintext, []byte(associatedData))
+
+	return &Resource{
+		Algorithm:      "AEAD_AES_256_GCM",
+		Ciphertext:     base64.StdEncoding.EncodeToString(ciphertext),
+		AssociatedData: associatedData,
+		OriginalType:   originalType,
+		Nonce:          nonce,
+	}, nil
+}
+
+func newGCM(apiV3Key string) (cipher.AEAD, error) {
+	if len(apiV3Key) != 32 {
+		return nil, fmt.Errorf("APIv3密钥长度必须为32字节")
+	}
+	block, err := aes.NewCipher([]byte(apiV3Key))
+	if err != nil {
+		return nil, fmt.Errorf("创建解密器失败: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("创建解密器失败: %v", err)
+	}
+	return gcm, nil
+}
+
+// NewNonce 生成32位随机字符串
+func NewNonce() (string, error) {
+	return randomString(32)
+}
+
+// randomString 生成指定长度的随机十六进制字符串
+func randomString(n int) (string, error) {
+	b := make([]byte, (n+1)/2)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成随机串失败: %v", err)
+	}
+	return hex.EncodeToString(b)[:n], nil
+}
+
+// NewOutTradeNo 生成商户订单号，格式为前缀 + 时间 + 8位随机数
+func NewOutTradeNo(prefix string, now time.Time) (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(100000000))
+	if err != nil {
+		return "", fmt.Errorf("生成订单号失败: %v", err)
+	}
+	return fmt.Sprintf("%s%s%08d", prefix, now.Format("20060102150405"), n.Int64()), nil
+}
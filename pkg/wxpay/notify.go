@@ -0,0 +1,90 @@
+package wxpay
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// 回调通知事件类型
+const (
+	EventTransactionSuccess = "TRANSACTION.SUCCESS"
+	EventRefundSuccess      = "REFUND.SUCCESS"
+	EventRefundAbnormal     = "REFUND.ABNORMAL"
+	EventRefundClosed       = "REFUND.CLOSED"
+)
+
+// Resource 回调通知中加密的资源数据
+type Resource struct {
+	Algorithm      string `json:"algorithm"`
+	Ciphertext     string `json:"ciphertext"`
+	AssociatedData string `json:"associated_data"`
+	OriginalType   string `json:"original_type"`
+	Nonce          string `json:"nonce"`
+}
+
+// Notification 回调通知
+type Notification struct {
+	ID           string    `json:"id"`
+	CreateTime   string    `json:"create_time"`
+	EventType    string    `json:"event_type"`
+	ResourceType string    `json:"resource_type"`
+	Summary      string    `json:"summary"`
+	Resource     *Resource `json:"resource"`
+}
+
+// RefundNotification 退款结果通知的资源数据
+type RefundNotification struct {
+	MchID         string       `json:"mchid"`
+	OutTradeNo    string       `json:"out_trade_no"`
+	TransactionID string       `json:"transaction_id"`
+	OutRefundNo   string       `json:"out_refund_no"`
+	RefundID      string       `json:"refund_id"`
+	RefundStatus  string       `json:"refund_status"`
+	SuccessTime   string       `json:"success_time"`
+	Amount        RefundAmount `json:"amount"`
+}
+
+// NotifyResponse 回调应答，处理失败时微信支付会重试
+type NotifyResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// ParseNotification 校验回调签名并解析通知
+func (c *Client) ParseNotification(header http.Header, body []byte) (*Notification, error) {
+	if err := VerifyHeaders(c.cfg.PlatformKey, header, body, c.now()); err != nil {
+		return nil, err
+	}
+
+	var notification Notification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("解析回调通知失败: %v", err)
+	}
+	if notification.Resource == nil {
+		return nil, fmt.Errorf("回调通知缺少资源数据")
+	}
+	return &notification, nil
+}
+
+// DecodeResource 解密通知中的资源数据并解析到 out
+func (c *Client) DecodeResource(notification *Notification, out interface{}) error {
+	plaintext, err := DecryptResource(c.cfg.APIv3Key, notification.Resource)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(plaintext, out); err != nil {
+		return fmt.Errorf("解析回调资源数据失败: %v", err)
+	}
+	return nil
+}
+
+// ParseTime 解析微信支付返回的 RFC3339 时间，为空或格式错误时返回 now
+func ParseTime(value string, now time.Time) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return now
+	}
+	return t
+}
@@ -0,0 +1,314 @@
+// Package wxpaytest 提供本地假微信支付服务器，用于在测试和联调中模拟下单、支付回调和退款
+package wxpaytest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MRsummer/ChangeHairStyle/pkg/wxpay"
+)
+
+// 假服务器使用的商户配置
+const (
+	AppID          = "test-appid"
+	MchID          = "1900000001"
+	APIv3Key       = "test-apiv3-key-0123456789abcdefg"
+	MerchantSerial = "TEST-MERCHANT-SERIAL"
+	PlatformSerial = "TEST-PLATFORM-SERIAL"
+)
+
+// Server 假微信支付服务器，记录订单和退款，并可生成带签名的回调通知
+type Server struct {
+	*httptest.Server
+
+	merchantKey *rsa.PrivateKey
+	platformKey *rsa.PrivateKey
+
+	mu      sync.Mutex
+	orders  map[string]*wxpay.Transaction
+	refunds map[string]*wxpay.Refund
+	seq     int
+}
+
+// NewServer 启动假微信支付服务器，使用完毕后需调用 Close
+func NewServer() (*Server, error) {
+	merchantKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("生成商户密钥失败: %v", err)
+	}
+	platformKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("生成平台密钥失败: %v", err)
+	}
+
+	s := &Server{
+		merchantKey: merchantKey,
+		platformKey: platformKey,
+		orders:      make(map[string]*wxpay.Transaction),
+		refunds:     make(map[string]*wxpay.Refund),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/pay/transactions/jsapi", s.handlePrepay)
+	mux.HandleFunc("/v3/pay/transactions/out-trade-no/", s.handleQuery)
+	mux.HandleFunc("/v3/refund/domestic/refunds", s.handleRefund)
+	s.Server = httptest.NewServer(mux)
+	return s, nil
+}
+
+// Config 返回与假服务器匹配的商户配置
+func (s *Server) Config() wxpay.Config {
+	return wxpay.Config{
+		AppID:       AppID,
+		MchID:       MchID,
+		APIv3Key:    APIv3Key,
+		NotifyURL:   "https://example.com/api/pay/notify",
+		PlatformKey: &s.platformKey.PublicKey,
+		PrivateKey:  s.merchantKey,
+	}
+}
+
+// API 返回指向假服务器的微信支付接口实现
+func (s *Server) API() *wxpay.HTTPAPI {
+	return wxpay.NewHTTPAPI(s.URL, MchID, MerchantSerial, s.merchantKey, &s.platformKey.PublicKey)
+}
+
+// Client 返回指向假服务器的微信支付客户端
+func (s *Server) Client() *wxpay.Client {
+	return wxpay.NewClient(s.API(), s.Config())
+}
+
+// Order 返回假服务器记录的订单
+func (s *Server) Order(outTradeNo string) (*wxpay.Transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.orders[outTradeNo]
+	if !ok {
+		return nil, false
+	}
+	copied := *order
+	return &copied, true
+}
+
+// Pay 模拟用户支付成功，返回应发送到回调地址的请求头和请求体
+func (s *Server) Pay(outTradeNo string) (http.Header, []byte, error) {
+	s.mu.Lock()
+	order, ok := s.orders[outTradeNo]
+	if !ok {
+		s.mu.Unlock()
+		return nil, nil, fmt.Errorf("订单不存在: %s", outTradeNo)
+	}
+	s.seq++
+	order.TradeState = wxpay.TradeStateSuccess
+	order.TradeStateDesc = "支付成功"
+	order.TransactionID = fmt.Sprintf("4200000000%010d", s.seq)
+	order.SuccessTime = time.Now().Format(time.RFC3339)
+	order.Amount.PayerTotal = order.Amount.Total
+	transaction := *order
+	s.mu.Unlock()
+
+	return s.notification(wxpay.EventTransactionSuccess, "transaction", &transaction)
+}
+
+// CompleteRefund 模拟退款完成，status 为退款最终状态，返回应发送到回调地址的请求头和请求体
+func (s *Server) CompleteRefund(outRefundNo, status string) (http.Header, []byte, error) {
+	s.mu.Lock()
+	refund, ok := s.refunds[outRefundNo]
+	if !ok {
+		s.mu.Unlock()
+		return nil, nil, fmt.Errorf("退款单不存在: %s", outRefundNo)
+	}
+	refund.Status = status
+	if status == wxpay.RefundStatusSuccess {
+		refund.SuccessTime = time.Now().Format(time.RFC3339)
+	}
+	notification := wxpay.RefundNotification{
+		MchID:         MchID,
+		OutTradeNo:    refund.OutTradeNo,
+		TransactionID: s.orders[refund.OutTradeNo].TransactionID,
+		OutRefundNo:   refund.OutRefundNo,
+		RefundID:      refund.RefundID,
+		RefundStatus:  refund.Status,
+		SuccessTime:   refund.SuccessTime,
+		Amount:        refund.Amount,
+	}
+	s.mu.Unlock()
+
+	return s.notification("REFUND."+status, "refund", &notification)
+}
+
+// notification 构造加密并签名的回调通知
+func (s *Server) notification(eventType, originalType string, resource interface{}) (http.Header, []byte, error) {
+	plaintext, err := json.Marshal(resource)
+	if err != nil {
+		return nil, nil, err
+	}
+	encrypted, err := wxpay.EncryptResource(APIv3Key, originalType, originalType, plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	s.seq++
+	id := fmt.Sprintf("notify-%d", s.seq)
+	s.mu.Unlock()
+
+	body, err := json.Marshal(wxpay.Notification{
+		ID:           id,
+		CreateTime:   time.Now().Format(time.RFC3339),
+		EventType:    eventType,
+		ResourceType: "encrypt-resource",
+		Resource:     encrypted,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header, err := wxpay.SignHeaders(s.platformKey, PlatformSerial, body, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	header.Set("Content-Type", "application/json")
+	return header, body, nil
+}
+
+func (s *Server) handlePrepay(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.readAuthorized(w, r)
+	if !ok {
+		return
+	}
+
+	var req wxpay.PrepayRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.OutTradeNo == "" || req.Payer.OpenID == "" || req.Amount.Total <= 0 {
+		s.writeError(w, http.StatusBadRequest, "PARAM_ERROR", "参数错误")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.orders[req.OutTradeNo]; exists {
+		s.writeError(w, http.StatusBadRequest, "OUT_TRADE_NO_USED", "商户订单号重复")
+		return
+	}
+	s.orders[req.OutTradeNo] = &wxpay.Transaction{
+		AppID:          req.AppID,
+		MchID:          req.MchID,
+		OutTradeNo:     req.OutTradeNo,
+		TradeType:      "JSAPI",
+		TradeState:     wxpay.TradeStateNotPay,
+		TradeStateDesc: "未支付",
+		Payer:          req.Payer,
+		Amount:         wxpay.Amount{Total: req.Amount.Total, Currency: "CNY"},
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"prepay_id": "wx-prepay-" + req.OutTradeNo})
+}
+
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if _, ok := s.readAuthorized(w, r); !ok {
+		return
+	}
+
+	outTradeNo := strings.TrimPrefix(r.URL.Path, "/v3/pay/transactions/out-trade-no/")
+	order, ok := s.Order(outTradeNo)
+	if !ok {
+		s.writeError(w, http.StatusNotFound, "ORDER_NOT_EXIST", "订单不存在")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, order)
+}
+
+func (s *Server) handleRefund(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.readAuthorized(w, r)
+	if !ok {
+		return
+	}
+
+	var req wxpay.RefundRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.OutRefundNo == "" {
+		s.writeError(w, http.StatusBadRequest, "PARAM_ERROR", "参数错误")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.orders[req.OutTradeNo]
+	if !ok || (order.TradeState != wxpay.TradeStateSuccess && order.TradeState != wxpay.TradeStateRefund) {
+		s.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "订单未支付")
+		return
+	}
+	if req.Amount.Total != order.Amount.Total || req.Amount.Refund > order.Amount.Total {
+		s.writeError(w, http.StatusBadRequest, "PARAM_ERROR", "退款金额错误")
+		return
+	}
+
+	// 同一退款单号重复申请时返回原退款单
+	refund, exists := s.refunds[req.OutRefundNo]
+	if !exists {
+		s.seq++
+		refund = &wxpay.Refund{
+			RefundID:    fmt.Sprintf("5030000000%010d", s.seq),
+			OutRefundNo: req.OutRefundNo,
+			OutTradeNo:  req.OutTradeNo,
+			Status:      wxpay.RefundStatusProcessing,
+			Amount:      req.Amount,
+		}
+		s.refunds[req.OutRefundNo] = refund
+		order.TradeState = wxpay.TradeStateRefund
+	}
+	s.writeJSON(w, http.StatusOK, refund)
+}
+
+// readAuthorized 校验请求签名并读取请求体
+func (s *Server) readAuthorized(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "PARAM_ERROR", "读取请求失败")
+		return nil, false
+	}
+
+	params := parseAuthorization(r.Header.Get("Authorization"))
+	message := r.Method + "\n" + r.URL.RequestURI() + "\n" + params["timestamp"] + "\n" + params["nonce_str"] + "\n" + string(body) + "\n"
+	if params["mchid"] != MchID || wxpay.Verify(&s.merchantKey.PublicKey, message, params["signature"]) != nil {
+		s.writeError(w, http.StatusUnauthorized, "SIGN_ERROR", "签名错误")
+		return nil, false
+	}
+	return body, true
+}
+
+// parseAuthorization 解析 WECHATPAY2-SHA256-RSA2048 认证头
+func parseAuthorization(value string) map[string]string {
+	params := make(map[string]string)
+	value = strings.TrimPrefix(value, "WECHATPAY2-SHA256-RSA2048 ")
+	for _, part := range strings.Split(value, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) == 2 {
+			params[kv[0]] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return params
+}
+
+func (s *Server) writeError(w http.ResponseWriter, status int, code, message string) {
+	s.writeJSON(w, status, map[string]string{"code": code, "message": message})
+}
+
+// writeJSON 写入响应并使用平台私钥签名
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	body, _ := json.Marshal(v)
+	if header, err := wxpay.SignHeaders(s.platformKey, PlatformSerial, body, time.Now()); err == nil {
+		for k, values := range header {
+			w.Header()[k] = values
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -57,4 +57,394 @@ ALTER TABLE user_info
     ADD COLUMN used_invite_code VARCHAR(6),
     ADD COLUMN last_sign_in_date DATE,
     ADD INDEX idx_invite_code (invite_code),
-    ADD INDEX idx_used_invite_code (used_invite_code); 
\ No newline at end of file
+    ADD INDEX idx_used_invite_code (used_invite_code); 
+
+-- 广场内容审核状态：0正常 1因举报自动隐藏 2已下架 3发布前待人工审核
+ALTER TABLE square_content
+    ADD COLUMN status TINYINT NOT NULL DEFAULT 0,
+    ADD COLUMN report_count INT NOT NULL DEFAULT 0,
+    ADD INDEX idx_status (status);
+
+-- 内容举报表
+CREATE TABLE IF NOT EXISTS content_report (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    content_id BIGINT NOT NULL,
+    reporter_id VARCHAR(64) NOT NULL,
+    reason TINYINT NOT NULL,
+    detail VARCHAR(255),
+    status TINYINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_content_reporter (content_id, reporter_id),
+    INDEX idx_status (status)
+);
+
+-- 审核操作日志表
+CREATE TABLE IF NOT EXISTS moderation_log (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    content_id BIGINT NOT NULL,
+    operator VARCHAR(64) NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    note VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_content_id (content_id)
+);
+
+-- 广场标签表
+CREATE TABLE IF NOT EXISTS square_tag (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(32) NOT NULL,
+    use_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_name (name)
+);
+
+-- 广场内容标签关联表
+CREATE TABLE IF NOT EXISTS square_content_tag (
+    content_id BIGINT NOT NULL,
+    tag_id BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (content_id, tag_id),
+    INDEX idx_tag_id (tag_id, content_id),
+    INDEX idx_created_at (created_at)
+);
+
+-- 每条发型记录只能分享一次：先清理重复分享（保留最早的一条），再将 record_id 索引改为唯一索引
+-- 重复分享上的点赞、标签、举报和审核日志转到保留的内容上，同一用户已点赞或举报过保留的内容时删除重复的记录
+CREATE TEMPORARY TABLE square_content_dedupe AS
+SELECT dup.id AS dup_id, keep.keep_id
+FROM square_content dup
+JOIN (SELECT record_id, MIN(id) AS keep_id FROM square_content GROUP BY record_id) keep
+    ON keep.record_id = dup.record_id AND dup.id > keep.keep_id;
+
+UPDATE IGNORE like_record lr JOIN square_content_dedupe d ON d.dup_id = lr.content_id
+SET lr.content_id = d.keep_id;
+DELETE lr FROM like_record lr JOIN square_content_dedupe d ON d.dup_id = lr.content_id;
+
+UPDATE IGNORE square_content_tag ct JOIN square_content_dedupe d ON d.dup_id = ct.content_id
+SET ct.content_id = d.keep_id;
+DELETE ct FROM square_content_tag ct JOIN square_content_dedupe d ON d.dup_id = ct.content_id;
+
+UPDATE IGNORE content_report cr JOIN square_content_dedupe d ON d.dup_id = cr.content_id
+SET cr.content_id = d.keep_id;
+DELETE cr FROM content_report cr JOIN square_content_dedupe d ON d.dup_id = cr.content_id;
+
+UPDATE moderation_log ml JOIN square_content_dedupe d ON d.dup_id = ml.content_id
+SET ml.content_id = d.keep_id;
+
+-- 按合并后的记录重新计算保留内容的点赞数和举报数
+UPDATE square_content sc
+    JOIN (SELECT DISTINCT keep_id FROM square_content_dedupe) keep ON keep.keep_id = sc.id
+SET sc.like_count = (SELECT COUNT(*) FROM like_record lr WHERE lr.content_id = sc.id),
+    sc.report_count = (SELECT COUNT(*) FROM content_report cr WHERE cr.content_id = sc.id);
+
+DELETE sc FROM square_content sc JOIN square_content_dedupe d ON d.dup_id = sc.id;
+DROP TEMPORARY TABLE square_content_dedupe;
+
+ALTER TABLE square_content
+    DROP INDEX idx_record_id,
+    ADD UNIQUE KEY uk_record_id (record_id);
+
+-- 收藏夹表
+CREATE TABLE IF NOT EXISTS favorite_collection (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    name VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_user_name (user_id, name)
+);
+
+-- 收藏记录表，collection_id 为0表示默认收藏夹
+CREATE TABLE IF NOT EXISTS favorite (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    content_id BIGINT NOT NULL,
+    collection_id BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_user_content (user_id, content_id),
+    INDEX idx_user_collection (user_id, collection_id, id)
+);
+
+-- 站内通知表，同一对象上未读的同类通知聚合为一条
+CREATE TABLE IF NOT EXISTS notification (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    content_id BIGINT NOT NULL DEFAULT 0,
+    actor_count INT NOT NULL DEFAULT 0,
+    last_actor_id VARCHAR(64) NOT NULL DEFAULT '',
+    is_read TINYINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_user_id (user_id, id),
+    INDEX idx_user_unread (user_id, is_read, type, content_id)
+);
+
+-- 通知参与人表，用于聚合去重
+CREATE TABLE IF NOT EXISTS notification_actor (
+    notification_id BIGINT NOT NULL,
+    actor_id VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (notification_id, actor_id)
+);
+
+-- 小程序订阅消息授权表，一次性订阅每次授权可发送一条消息
+CREATE TABLE IF NOT EXISTS wx_subscription (
+    user_id VARCHAR(64) NOT NULL,
+    template_id VARCHAR(64) NOT NULL,
+    remaining INT NOT NULL DEFAULT 0,
+    last_result VARCHAR(16) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, template_id),
+    INDEX idx_template_remaining (template_id, remaining)
+);
+
+-- 连续签到和补签卡
+ALTER TABLE user_info
+    ADD COLUMN sign_in_streak INT NOT NULL DEFAULT 0,
+    ADD COLUMN repair_cards INT NOT NULL DEFAULT 0;
+
+-- 签到日志表
+CREATE TABLE IF NOT EXISTS sign_in_log (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    sign_date DATE NOT NULL,
+    streak INT NOT NULL DEFAULT 0,
+    reward INT NOT NULL DEFAULT 0,
+    is_repair TINYINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_user_date (user_id, sign_date)
+);
+
+-- 邀请关系表，状态：0待首次生成 1已发放奖励 2命中防刷 3邀请人达到上限
+CREATE TABLE IF NOT EXISTS invite_relation (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    inviter_id VARCHAR(64) NOT NULL,
+    invitee_id VARCHAR(64) NOT NULL,
+    invite_code VARCHAR(16) NOT NULL,
+    device_id VARCHAR(128) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    status TINYINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    rewarded_at TIMESTAMP NULL,
+    UNIQUE KEY uk_invitee_id (invitee_id),
+    INDEX idx_inviter_id (inviter_id, id),
+    INDEX idx_ip (ip, created_at),
+    INDEX idx_device_id (device_id)
+);
+
+-- 邀请奖励表，记录每级邀请人和被邀请人获得的奖励
+CREATE TABLE IF NOT EXISTS invite_reward (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    relation_id BIGINT NOT NULL,
+    beneficiary_id VARCHAR(64) NOT NULL,
+    level INT NOT NULL, -- 0为被邀请人，1为直接邀请人，2为间接邀请人
+    amount INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_relation_beneficiary (relation_id, beneficiary_id),
+    INDEX idx_beneficiary_id (beneficiary_id)
+);
+
+-- 历史邀请关系迁移：已使用邀请码的用户按已发放奖励处理
+INSERT IGNORE INTO invite_relation (inviter_id, invitee_id, invite_code, status, rewarded_at)
+SELECT inviter.user_id, invitee.user_id, invitee.used_invite_code, 1, invitee.updated_at
+FROM user_info invitee
+JOIN user_info inviter ON inviter.invite_code = invitee.used_invite_code
+WHERE invitee.used_invite_code IS NOT NULL AND invitee.used_invite_code != '';
+
+-- 支持自定义邀请码，邀请码最长16位，列上已有的唯一索引保持不变
+ALTER TABLE user_info
+    MODIFY COLUMN invite_code VARCHAR(16),
+    MODIFY COLUMN used_invite_code VARCHAR(16);
+
+-- coin流水表，记录每一次coin变动
+CREATE TABLE IF NOT EXISTS coin_ledger (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    amount INT NOT NULL, -- 增加为正，扣除为负
+    balance INT NOT NULL,
+    source VARCHAR(32) NOT NULL,
+    ref_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_id (user_id, id),
+    INDEX idx_source_ref (source, ref_id)
+);
+
+-- coin充值商品表，价格单位为分，status：1上架 0下架
+CREATE TABLE IF NOT EXISTS coin_product (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(64) NOT NULL,
+    coins INT NOT NULL,
+    bonus_coins INT NOT NULL DEFAULT 0,
+    price INT NOT NULL,
+    sort INT NOT NULL DEFAULT 0,
+    status TINYINT NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+INSERT IGNORE INTO coin_product (id, name, coins, bonus_coins, price, sort) VALUES
+    (1, '60金币', 60, 0, 600, 1),
+    (2, '300金币', 300, 30, 3000, 2),
+    (3, '680金币', 680, 120, 6800, 3);
+
+-- 支付订单表，状态：0待支付 1已支付 2已关闭 3退款中 4已退款
+CREATE TABLE IF NOT EXISTS pay_order (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    out_trade_no VARCHAR(32) NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    product_id BIGINT NOT NULL,
+    description VARCHAR(128) NOT NULL,
+    coins INT NOT NULL,
+    amount INT NOT NULL,
+    status TINYINT NOT NULL DEFAULT 0,
+    prepay_id VARCHAR(64) NOT NULL DEFAULT '',
+    transaction_id VARCHAR(32) NOT NULL DEFAULT '',
+    paid_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_out_trade_no (out_trade_no),
+    INDEX idx_user_id (user_id, id)
+);
+
+-- 退款单表，状态：0处理中 1成功 2失败
+CREATE TABLE IF NOT EXISTS pay_refund (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    out_refund_no VARCHAR(64) NOT NULL,
+    out_trade_no VARCHAR(32) NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    amount INT NOT NULL,
+    coins INT NOT NULL,
+    status TINYINT NOT NULL DEFAULT 0,
+    refund_id VARCHAR(32) NOT NULL DEFAULT '',
+    reason VARCHAR(80) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_out_refund_no (out_refund_no),
+    INDEX idx_out_trade_no (out_trade_no)
+);
+
+-- 用户会员表，每个用户只有一条记录，到期时间早于当前时间即为非会员
+CREATE TABLE IF NOT EXISTS user_membership (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    tier VARCHAR(16) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_user_id (user_id)
+);
+
+-- 会员每日免费生成次数使用记录，日期按业务时区计算
+CREATE TABLE IF NOT EXISTS daily_free_usage (
+    user_id VARCHAR(64) NOT NULL,
+    usage_date DATE NOT NULL,
+    used_count INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, usage_date)
+);
+
+-- 会员商品：购买后开通对应等级的会员
+ALTER TABLE coin_product
+    ADD COLUMN membership_tier VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN membership_days INT NOT NULL DEFAULT 0;
+
+ALTER TABLE pay_order
+    ADD COLUMN membership_tier VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN membership_days INT NOT NULL DEFAULT 0;
+
+INSERT IGNORE INTO coin_product (id, name, coins, bonus_coins, price, sort, membership_tier, membership_days) VALUES
+    (101, 'VIP月卡', 0, 0, 1800, 11, 'vip', 30),
+    (102, 'SVIP月卡', 0, 0, 3800, 12, 'svip', 30);
+
+-- 兑换活动表
+CREATE TABLE IF NOT EXISTS redeem_campaign (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    name VARCHAR(64) NOT NULL,
+    coins INT NOT NULL DEFAULT 0,
+    membership_tier VARCHAR(16) NOT NULL DEFAULT '',
+    membership_days INT NOT NULL DEFAULT 0,
+    per_user_limit INT NOT NULL DEFAULT 1, -- 0表示不限制
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 兑换码表，max_uses为0表示不限制兑换次数
+CREATE TABLE IF NOT EXISTS redeem_code (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    campaign_id BIGINT NOT NULL,
+    code VARCHAR(16) NOT NULL,
+    max_uses INT NOT NULL DEFAULT 1,
+    used_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_code (code),
+    INDEX idx_campaign_id (campaign_id, id)
+);
+
+-- 兑换记录表
+CREATE TABLE IF NOT EXISTS redeem_log (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    code_id BIGINT NOT NULL,
+    campaign_id BIGINT NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_campaign_user (campaign_id, user_id),
+    INDEX idx_code_id (code_id)
+);
+
+-- coin批次表，记录每笔增加的coin及其剩余数量，expire_date为空表示永不过期，否则当天结束后过期
+CREATE TABLE IF NOT EXISTS coin_lot (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id VARCHAR(64) NOT NULL,
+    source VARCHAR(32) NOT NULL,
+    ref_id VARCHAR(64) NOT NULL DEFAULT '',
+    amount INT NOT NULL,
+    remaining INT NOT NULL,
+    expire_date DATE NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_expire (user_id, expire_date),
+    INDEX idx_expire_date (expire_date)
+);
+
+-- 迁移：批次表上线前的余额记为一个永不过期的批次
+INSERT INTO coin_lot (user_id, source, amount, remaining)
+SELECT user_id, 'legacy', coin, coin FROM user_info
+WHERE coin > 0 AND NOT EXISTS (SELECT 1 FROM coin_lot WHERE coin_lot.user_id = user_info.user_id);
+
+-- 限流令牌桶表，rate_limit.store 为 mysql 时使用；删除任意行等价于把对应的桶装满，可定期清理长时间未更新的行
+CREATE TABLE IF NOT EXISTS rate_limit_bucket (
+    bucket_key VARCHAR(191) PRIMARY KEY,
+    tokens DOUBLE NOT NULL,
+    updated_ms BIGINT NOT NULL,
+    INDEX idx_updated_ms (updated_ms)
+);
+
+-- 发型生成并发名额表，每行一个名额，holder 为空或租约过期表示空闲；名额行由服务按 generation.max_concurrency 自动创建
+CREATE TABLE IF NOT EXISTS generation_slot (
+    slot INT PRIMARY KEY,
+    holder VARCHAR(32) NULL,
+    expires_at DATETIME(3) NULL,
+    INDEX idx_holder (holder)
+);
+
+-- 发型生成排队表，优先的请求排在前面，同优先级按加入顺序；expires_at 之前没有续期的票据视为已离开
+CREATE TABLE IF NOT EXISTS generation_queue (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    ticket VARCHAR(32) NOT NULL,
+    user_id VARCHAR(64) NOT NULL,
+    priority TINYINT NOT NULL DEFAULT 0,
+    expires_at DATETIME(3) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_ticket (ticket),
+    INDEX idx_expires_at (expires_at)
+);
+
+-- 用户设置的提示信息语言，为空时按请求的 Accept-Language
+ALTER TABLE user_info
+    ADD COLUMN language VARCHAR(8) NOT NULL DEFAULT '' AFTER repair_cards;
+
+-- 需要人工审核的新昵称，审核通过前继续展示原昵称
+ALTER TABLE user_info
+    ADD COLUMN pending_nickname VARCHAR(64) NOT NULL DEFAULT '' AFTER nickname;